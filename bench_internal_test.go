@@ -28,7 +28,7 @@ var bnInternalRe = regexp.MustCompile(bnInternalPattern)
 func BenchmarkCompilePlanOnly(b *testing.B) {
 	b.ReportAllocs()
 	for b.Loop() {
-		d, err := compileDecoder[bnInternalSimple](bnInternalPattern, bnInternalRe)
+		d, err := compileDecoder[bnInternalSimple](bnInternalPattern, bnInternalRe, newDecoderConfig(nil), nil)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -0,0 +1,323 @@
+package regextra
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"reflect"
+	"regexp"
+	"unsafe"
+)
+
+// FindNamedBytes is the []byte form of [FindNamed]. The value aliases target;
+// see the package doc's "Byte slices" section.
+func FindNamedBytes(re *regexp.Regexp, target []byte, groupName string) ([]byte, bool) {
+	idxs := subexpIndexes(re, groupName)
+	if len(idxs) == 0 {
+		return nil, false
+	}
+	loc := re.FindSubmatchIndex(target)
+	if loc == nil {
+		return nil, false
+	}
+	var value []byte
+	for _, idx := range idxs {
+		if start := loc[2*idx]; start >= 0 {
+			value = groupBytes(target, start, loc[2*idx+1])
+		}
+	}
+	return value, true
+}
+
+// FindAllNamedBytes is the []byte form of [FindAllNamed]: nil when the group
+// name is not declared on re, an empty slice when re has no matches. Each
+// value aliases target.
+func FindAllNamedBytes(re *regexp.Regexp, target []byte, groupName string) [][]byte {
+	idxs := subexpIndexes(re, groupName)
+	if len(idxs) == 0 {
+		return nil
+	}
+	locs := re.FindAllSubmatchIndex(target, -1)
+	if len(locs) == 0 {
+		return [][]byte{}
+	}
+	out := make([][]byte, len(locs))
+	for i, loc := range locs {
+		for _, idx := range idxs {
+			if start := loc[2*idx]; start >= 0 {
+				out[i] = groupBytes(target, start, loc[2*idx+1])
+			}
+		}
+	}
+	return out
+}
+
+// NamedGroupsBytes is the []byte form of [NamedGroups]. Every declared group
+// is present; one that did not participate maps to nil. Each value aliases
+// target.
+func NamedGroupsBytes(re *regexp.Regexp, target []byte) map[string][]byte {
+	m := re.FindSubmatchIndex(target)
+	if m == nil {
+		return make(map[string][]byte)
+	}
+	names := re.SubexpNames()
+	result := make(map[string][]byte, len(names))
+	fillNamedGroupBytes(result, names, target, m)
+	return result
+}
+
+// AllNamedGroupsBytes is the []byte form of [AllNamedGroups]: every
+// occurrence of every named group in the first match, in declaration order,
+// with nil for an occurrence that did not participate. Each value aliases
+// target.
+func AllNamedGroupsBytes(re *regexp.Regexp, target []byte) map[string][][]byte {
+	result := make(map[string][][]byte)
+	loc := re.FindSubmatchIndex(target)
+	if loc == nil {
+		return result
+	}
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		var value []byte
+		if start := loc[2*i]; start >= 0 {
+			value = groupBytes(target, start, loc[2*i+1])
+		}
+		result[name] = append(result[name], value)
+	}
+	return result
+}
+
+// NamedGroupsPerMatchBytes is the []byte form of [NamedGroupsPerMatch]. On no
+// match it returns an empty (non-nil) slice. Each value aliases target.
+func NamedGroupsPerMatchBytes(re *regexp.Regexp, target []byte) []map[string][]byte {
+	locs := re.FindAllSubmatchIndex(target, -1)
+	if len(locs) == 0 {
+		return []map[string][]byte{}
+	}
+	names := re.SubexpNames()
+	out := make([]map[string][]byte, len(locs))
+	for i, m := range locs {
+		result := make(map[string][]byte, len(names))
+		fillNamedGroupBytes(result, names, target, m)
+		out[i] = result
+	}
+	return out
+}
+
+// NamedGroupsPerMatchSeqBytes is the []byte form of [NamedGroupsPerMatchSeq].
+// Each value aliases target.
+func NamedGroupsPerMatchSeqBytes(re *regexp.Regexp, target []byte) iter.Seq[map[string][]byte] {
+	return func(yield func(map[string][]byte) bool) {
+		locs := re.FindAllSubmatchIndex(target, -1)
+		names := re.SubexpNames()
+		for _, m := range locs {
+			result := make(map[string][]byte, len(names))
+			fillNamedGroupBytes(result, names, target, m)
+			if !yield(result) {
+				return
+			}
+		}
+	}
+}
+
+// fillNamedGroupBytes is fillNamedGroupValues for a []byte target, always
+// including non-participating groups (as nil).
+func fillNamedGroupBytes(dst map[string][]byte, names []string, target []byte, m []int) {
+	for i, name := range names {
+		if i == 0 || name == "" {
+			continue
+		}
+		start, end := m[2*i], m[2*i+1]
+		if start < 0 {
+			if _, ok := dst[name]; !ok {
+				dst[name] = nil
+			}
+			continue
+		}
+		dst[name] = groupBytes(target, start, end)
+	}
+}
+
+// groupBytes returns target[start:end] with its capacity clipped, so an
+// append to the result cannot write into the rest of target.
+func groupBytes(target []byte, start, end int) []byte {
+	return target[start:end:end]
+}
+
+// ReplaceBytes is the []byte form of [Replace]. The result is always a new
+// slice, even when nothing was replaced; target is never modified.
+func ReplaceBytes(re *regexp.Regexp, target []byte, replacements map[string][]byte) []byte {
+	return replaceNamedBytes(re, target, -1, func(name string, _ []byte) ([]byte, bool) {
+		repl, ok := replacements[name]
+		return repl, ok
+	})
+}
+
+// ReplaceFirstBytes is the []byte form of [ReplaceFirst]. The result is
+// always a new slice.
+func ReplaceFirstBytes(re *regexp.Regexp, target []byte, replacements map[string][]byte) []byte {
+	return replaceNamedBytes(re, target, 1, func(name string, _ []byte) ([]byte, bool) {
+		repl, ok := replacements[name]
+		return repl, ok
+	})
+}
+
+// ReplaceFuncBytes is the []byte form of [ReplaceFunc]. match aliases target
+// and is valid only for the duration of the call. The result is always a new
+// slice.
+func ReplaceFuncBytes(re *regexp.Regexp, target []byte, fn func(group string, match []byte) []byte) []byte {
+	return replaceNamedBytes(re, target, -1, func(name string, matched []byte) ([]byte, bool) {
+		return fn(name, matched), true
+	})
+}
+
+// replaceNamedBytes is replaceNamed for a []byte target, sharing its span
+// order (see appendNamedSpans). Unlike replaceNamed it copies target even when
+// nothing matches, so callers may always modify the result.
+func replaceNamedBytes(re *regexp.Regexp, target []byte, limit int, replFor func(name string, matched []byte) ([]byte, bool)) []byte {
+	matches := re.FindAllSubmatchIndex(target, limit)
+	if len(matches) == 0 {
+		return bytes.Clone(target)
+	}
+	names := re.SubexpNames()
+
+	out := make([]byte, 0, len(target))
+	spans := make([]namedSpan, 0, len(names))
+	cursor := 0
+	for _, m := range matches {
+		spans = appendNamedSpans(spans[:0], names, m)
+		for _, sp := range spans {
+			if sp.start < cursor {
+				continue
+			}
+			repl, ok := replFor(sp.name, groupBytes(target, sp.start, sp.end))
+			if !ok {
+				continue
+			}
+			out = append(out, target[cursor:sp.start]...)
+			out = append(out, repl...)
+			cursor = sp.end
+		}
+	}
+	return append(out, target[cursor:]...)
+}
+
+// ZeroCopy makes the Decoder's []byte methods ([Decoder.OneBytes],
+// [Decoder.AllBytes], [Decoder.IterBytes]) decode string values without
+// copying them out of the buffer. By default each match's text is copied into
+// a new string before decoding, so the result owns its memory; under ZeroCopy
+// a string field — and anything else that keeps the text it is given: a
+// [RegexUnmarshaler], a registered converter, a [DecodeError]'s Value — shares
+// the buffer's memory instead.
+//
+// The caller must guarantee the buffer outlives every result decoded from it
+// and is not modified while any is in use; a write to the buffer changes
+// strings Go otherwise treats as immutable. Use it for buffers that are
+// read-only for their lifetime, such as an mmap'd file. The string methods
+// and [Decoder.Scan], whose buffer is reused, are unaffected.
+func ZeroCopy() DecoderOption {
+	return func(c *DecoderConfig) { c.ZeroCopy = true }
+}
+
+// OneBytes is the []byte form of [Decoder.One]. String values are copied out
+// of target unless the Decoder was compiled with [ZeroCopy].
+func (d *Decoder[T]) OneBytes(target []byte) (T, error) {
+	matches := d.matcher.FindSubmatchIndex(target)
+	if matches == nil {
+		return d.zero, ErrNoMatch
+	}
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if err := d.decodeBytes(rv, target, matches, 0); err != nil {
+		return v, fmt.Errorf("regextra.Decoder.OneBytes: %w", err)
+	}
+	return v, nil
+}
+
+// AllBytes is the []byte form of [Decoder.All]. String values are copied out
+// of target unless the Decoder was compiled with [ZeroCopy].
+func (d *Decoder[T]) AllBytes(target []byte) ([]T, error) {
+	allMatches := d.matcher.FindAllSubmatchIndex(target, -1)
+	if len(allMatches) == 0 {
+		return []T{}, nil
+	}
+	out := make([]T, len(allMatches))
+	for i, matches := range allMatches {
+		rv := reflect.ValueOf(&out[i]).Elem()
+		if err := d.decodeBytes(rv, target, matches, i); err != nil {
+			return out[:i+1], fmt.Errorf("regextra.Decoder.AllBytes: match %d: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// IterBytes is the []byte form of [Decoder.Iter]. String values are copied
+// out of target unless the Decoder was compiled with [ZeroCopy].
+func (d *Decoder[T]) IterBytes(target []byte) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		allMatches := d.matcher.FindAllSubmatchIndex(target, -1)
+		for i, matches := range allMatches {
+			var v T
+			rv := reflect.ValueOf(&v).Elem()
+			err := d.decodeBytes(rv, target, matches, i)
+			if err != nil {
+				err = fmt.Errorf("regextra.Decoder.IterBytes: %w", err)
+			}
+			if !yield(v, err) {
+				return
+			}
+		}
+	}
+}
+
+// decodeBytes is decode for a match in a []byte target. Under ZeroCopy it
+// decodes against a string view of target itself. Otherwise it copies only
+// the match's text into a string, decodes against that with the indices
+// rebased onto it, and moves any error's location back into target, so a
+// result never holds more of the buffer than its own values.
+func (d *Decoder[T]) decodeBytes(rv reflect.Value, target []byte, matches []int, n int) error {
+	if d.cfg.ZeroCopy {
+		return d.decode(rv, bytesView(target), matches, n)
+	}
+	base := matches[0]
+	rebased := make([]int, len(matches))
+	for i, idx := range matches {
+		if idx >= 0 {
+			idx -= base
+		}
+		rebased[i] = idx
+	}
+	err := d.decode(rv, string(target[base:matches[1]]), rebased, n)
+	if err != nil {
+		relocateError(err, bytesView(target), base)
+	}
+	return err
+}
+
+// bytesView returns a string sharing b's memory. The string is only valid
+// while b is unmodified.
+func bytesView(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// relocateError shifts the location of a decode error reported against a
+// match's own text by base bytes, into target, recomputing its line and
+// column there.
+func relocateError(err error, target string, base int) {
+	switch e := err.(type) {
+	case *DecodeErrors:
+		for _, err := range e.Errors {
+			relocateError(err, target, base)
+		}
+	case *DecodeError:
+		e.Offset += base
+		e.End += base
+		e.Line, e.Column = lineColumn(target, e.Offset)
+	case *RequiredGroupError:
+		e.Offset += base
+		e.End += base
+		e.Line, e.Column = lineColumn(target, e.Offset)
+	}
+}
@@ -0,0 +1,279 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+	"unsafe"
+
+	rx "github.com/jecoms/regextra"
+)
+
+// bytesParityCases exercise the []byte forms against their string forms.
+var bytesParityCases = []struct {
+	pattern, target string
+}{
+	{`(?P<key>\w+)=(?P<value>\w*)`, "a=1 b= c=33"},
+	{`(?P<key>\w+)(?:=(?P<value>\w+))?`, "flag x=1"},
+	{`(?:id=(?P<ref>\d+)|name=(?P<ref>\w+))`, "name=bob id=7"},
+	{`(?P<outer>(?P<inner>\d+)-\d+)`, "1-2 and 30-4"},
+	{`(?P<n>\d+)`, "none"},
+}
+
+func TestBytesParity(t *testing.T) {
+	for _, tt := range bytesParityCases {
+		re := regexp.MustCompile(tt.pattern)
+		b := []byte(tt.target)
+		for _, name := range append(re.SubexpNames()[1:], "undeclared") {
+			got, gotOK := rx.FindNamedBytes(re, b, name)
+			want, wantOK := rx.FindNamed(re, tt.target, name)
+			if string(got) != want || gotOK != wantOK {
+				t.Errorf("FindNamedBytes(%q, %q) = %q, %v; want %q, %v", tt.pattern, name, got, gotOK, want, wantOK)
+			}
+			if got, want := rx.FindAllNamedBytes(re, b, name), rx.FindAllNamed(re, tt.target, name); !equalStrings(got, want) {
+				t.Errorf("FindAllNamedBytes(%q, %q) = %q, want %q", tt.pattern, name, got, want)
+			}
+		}
+		if got, want := rx.NamedGroupsBytes(re, b), rx.NamedGroups(re, tt.target); !equalMap(got, want) {
+			t.Errorf("NamedGroupsBytes(%q) = %q, want %q", tt.pattern, got, want)
+		}
+		gotAll, wantAll := rx.AllNamedGroupsBytes(re, b), rx.AllNamedGroups(re, tt.target)
+		if len(gotAll) != len(wantAll) {
+			t.Errorf("AllNamedGroupsBytes(%q) = %q, want %q", tt.pattern, gotAll, wantAll)
+		}
+		for name, values := range wantAll {
+			if !equalStrings(gotAll[name], values) {
+				t.Errorf("AllNamedGroupsBytes(%q)[%s] = %q, want %q", tt.pattern, name, gotAll[name], values)
+			}
+		}
+		perMatch := rx.NamedGroupsPerMatch(re, tt.target)
+		gotPer := rx.NamedGroupsPerMatchBytes(re, b)
+		gotSeq := slices.Collect(rx.NamedGroupsPerMatchSeqBytes(re, b))
+		if gotPer == nil || len(gotPer) != len(perMatch) || len(gotSeq) != len(perMatch) {
+			t.Fatalf("NamedGroupsPerMatchBytes(%q) = %q, Seq %q; want %q", tt.pattern, gotPer, gotSeq, perMatch)
+		}
+		for i := range perMatch {
+			if !equalMap(gotPer[i], perMatch[i]) || !equalMap(gotSeq[i], perMatch[i]) {
+				t.Errorf("NamedGroupsPerMatchBytes(%q)[%d] = %q, Seq %q; want %q", tt.pattern, i, gotPer[i], gotSeq[i], perMatch[i])
+			}
+		}
+
+		repl := map[string]string{}
+		replBytes := map[string][]byte{}
+		for _, name := range re.SubexpNames()[1:] {
+			repl[name] = "<" + name + ">"
+			replBytes[name] = []byte("<" + name + ">")
+		}
+		if got, want := rx.ReplaceBytes(re, b, replBytes), rx.Replace(re, tt.target, repl); string(got) != want {
+			t.Errorf("ReplaceBytes(%q) = %q, want %q", tt.pattern, got, want)
+		}
+		if got, want := rx.ReplaceFirstBytes(re, b, replBytes), rx.ReplaceFirst(re, tt.target, repl); string(got) != want {
+			t.Errorf("ReplaceFirstBytes(%q) = %q, want %q", tt.pattern, got, want)
+		}
+		got := rx.ReplaceFuncBytes(re, b, func(group string, match []byte) []byte {
+			return append([]byte(group+":"), match...)
+		})
+		want := rx.ReplaceFunc(re, tt.target, func(group, match string) string { return group + ":" + match })
+		if string(got) != want {
+			t.Errorf("ReplaceFuncBytes(%q) = %q, want %q", tt.pattern, got, want)
+		}
+		if string(b) != tt.target {
+			t.Errorf("target modified to %q", b)
+		}
+	}
+}
+
+func equalStrings(got [][]byte, want []string) bool {
+	if (got == nil) != (want == nil) || len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if string(got[i]) != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMap(got map[string][]byte, want map[string]string) bool {
+	if got == nil || len(got) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if g, ok := got[k]; !ok || string(g) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBytesAliasing(t *testing.T) {
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\w*)(?:;(?P<note>\w+))?`)
+	b := []byte("k=v rest")
+	m := rx.NamedGroupsBytes(re, b)
+
+	// Non-participating is nil; an empty span is empty but not nil.
+	if m["note"] != nil {
+		t.Errorf(`m["note"] = %q, want nil`, m["note"])
+	}
+	if empty := rx.NamedGroupsBytes(re, []byte("k=")); empty["value"] == nil || len(empty["value"]) != 0 {
+		t.Errorf(`empty span = %#v, want a non-nil empty slice`, empty["value"])
+	}
+
+	// Values share the target's memory...
+	b[0] = 'K'
+	if string(m["key"]) != "K" {
+		t.Errorf(`m["key"] = %q after writing the target, want "K"`, m["key"])
+	}
+	// ...but cannot be appended into the rest of it.
+	if cap(m["key"]) != len(m["key"]) {
+		t.Errorf(`cap(m["key"]) = %d, want %d`, cap(m["key"]), len(m["key"]))
+	}
+	_ = append(m["key"], 'X')
+	if string(b) != "K=v rest" {
+		t.Errorf("target = %q after appending to a value", b)
+	}
+
+	// Replace always returns a fresh slice, even with nothing to replace.
+	out := rx.ReplaceBytes(regexp.MustCompile(`(?P<n>\d+)`), b, map[string][]byte{"n": []byte("#")})
+	out[0] = 'x'
+	if b[0] != 'K' {
+		t.Error("ReplaceBytes(no match) returned target itself")
+	}
+}
+
+func TestDecoder_bytes(t *testing.T) {
+	type pair struct {
+		Key   string
+		Value int
+	}
+	const pattern = `(?P<key>\w+)=(?P<value>\w+)`
+	dec := rx.MustCompile[pair](pattern)
+	input := []byte("a=1 b=2")
+
+	one, err := dec.OneBytes(input)
+	if err != nil || one != (pair{"a", 1}) {
+		t.Errorf("OneBytes() = %+v, %v", one, err)
+	}
+	all, err := dec.AllBytes(input)
+	if err != nil || !slices.Equal(all, []pair{{"a", 1}, {"b", 2}}) {
+		t.Errorf("AllBytes() = %+v, %v", all, err)
+	}
+	var iterated []pair
+	for v, err := range dec.IterBytes(input) {
+		if err != nil {
+			t.Fatalf("IterBytes() error = %v", err)
+		}
+		iterated = append(iterated, v)
+	}
+	if !slices.Equal(iterated, all) {
+		t.Errorf("IterBytes() = %+v, want %+v", iterated, all)
+	}
+	if _, err := dec.OneBytes([]byte("none")); !errors.Is(err, rx.ErrNoMatch) {
+		t.Errorf("OneBytes(no match) error = %v, want ErrNoMatch", err)
+	}
+	if got, err := dec.AllBytes(nil); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("AllBytes(nil) = %#v, %v; want an empty slice", got, err)
+	}
+
+	// By default decoded strings own their memory.
+	input[0] = 'z'
+	if all[0].Key != "a" {
+		t.Errorf("Key = %q after writing the buffer, want a copy", all[0].Key)
+	}
+
+	// Under ZeroCopy they point into the buffer.
+	zc := rx.MustCompileWith[pair](pattern, rx.ZeroCopy())
+	if !zc.Config().ZeroCopy {
+		t.Error("Config().ZeroCopy = false")
+	}
+	v, err := zc.OneBytes(input[4:])
+	if err != nil {
+		t.Fatalf("OneBytes() error = %v", err)
+	}
+	if unsafe.StringData(v.Key) != &input[4] {
+		t.Error("ZeroCopy Key does not share the buffer")
+	}
+}
+
+func TestDecoder_bytesErrorLocation(t *testing.T) {
+	type pair struct {
+		Key   string `regex:"key,required"`
+		Value int
+	}
+	input := []byte("a=1\nb=x\nc=3\n=4")
+	for _, opts := range [][]rx.DecoderOption{nil, {rx.ZeroCopy()}, {rx.AggregateErrors()}} {
+		dec := rx.MustCompileWith[pair](`(?P<key>\w*)=(?P<value>\w+)`, opts...)
+		want, err := dec.All(string(input))
+		if err == nil {
+			t.Fatal("All() succeeded, want a decode error")
+		}
+		got, gotErr := dec.AllBytes(input)
+		if !slices.Equal(got, want) {
+			t.Errorf("AllBytes() = %+v, want %+v", got, want)
+		}
+		var de, gotDE *rx.DecodeError
+		errors.As(err, &de)
+		errors.As(gotErr, &gotDE)
+		if gotDE == nil || gotDE.Value != de.Value || gotDE.Offset != de.Offset || gotDE.End != de.End || gotDE.Line != de.Line || gotDE.Column != de.Column {
+			t.Errorf("AllBytes() DecodeError = %+v, want %+v", gotDE, de)
+		}
+
+		var wantRGE, gotRGE *rx.RequiredGroupError
+		var errs []error
+		for _, err := range dec.Iter(string(input)) {
+			errs = append(errs, err)
+		}
+		i := 0
+		for _, err := range dec.IterBytes(input) {
+			errors.As(errs[i], &wantRGE)
+			if errors.As(err, &gotRGE) && *gotRGE != *wantRGE {
+				t.Errorf("IterBytes() RequiredGroupError = %+v, want %+v", gotRGE, wantRGE)
+			}
+			i++
+		}
+		if gotRGE == nil || gotRGE.Line != 4 {
+			t.Errorf("IterBytes() RequiredGroupError = %+v, want one on line 4", gotRGE)
+		}
+	}
+}
+
+func ExampleNamedGroupsBytes() {
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\w*)`)
+	m := rx.NamedGroupsBytes(re, []byte("mode=fast"))
+	fmt.Printf("%s %s\n", m["key"], m["value"])
+	// Output:
+	// mode fast
+}
+
+func ExampleReplaceFuncBytes() {
+	re := regexp.MustCompile(`token=(?P<token>\w+)`)
+	out := rx.ReplaceFuncBytes(re, []byte("GET /?token=s3cr3t"), func(_ string, match []byte) []byte {
+		return []byte("REDACTED")
+	})
+	fmt.Printf("%s\n", out)
+	// Output:
+	// GET /?token=REDACTED
+}
+
+func ExampleDecoder_IterBytes() {
+	type Pair struct {
+		Key   string
+		Value int
+	}
+	// The buffer stands in for an mmap'd file that is never written, so the
+	// decoded keys may point into it.
+	buf := []byte("a=1\nb=2\n")
+	dec := rx.MustCompileWith[Pair](`(?m)^(?P<key>\w+)=(?P<value>\d+)$`, rx.ZeroCopy())
+	for p, err := range dec.IterBytes(buf) {
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%+v\n", p)
+	}
+	// Output:
+	// {Key:a Value:1}
+	// {Key:b Value:2}
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// segment is one piece of the encode plan, as regextra's encodeSegment for
+// the subset the generator supports: a literal run, a field, a `?` section,
+// or an alternation.
+type segment struct {
+	literal      string
+	field        *leaf
+	group        string
+	optional     []segment
+	alternatives [][]segment
+}
+
+// errNotSupported marks a pattern the reflective Encoder inverts but the
+// generator does not.
+var errNotSupported = errors.New("not supported by the generated encoder")
+
+// encodePlan inverts the pattern ast into segments over the fields of st, as
+// regextra's Decoder.Encoder does, failing where it would and on repetitions,
+// which the generator does not support.
+func encodePlan(st *types.Struct, named *types.Named, ast *syntax.Regexp, key string) ([]segment, error) {
+	var leaves []leaf
+	// A misplaced `prefix` flag was already reported by buildPlan.
+	_ = walkFields(st, nil, leaf{}, []types.Type{named}, key, func(lf leaf) error {
+		leaves = append(leaves, lf)
+		return nil
+	})
+	var b segmentBuilder
+	if err := b.walk(ast, leaves, named); err != nil {
+		return nil, err
+	}
+	b.flush()
+	return b.segments, nil
+}
+
+// segmentBuilder accumulates one level of the encode plan, merging adjacent
+// literals.
+type segmentBuilder struct {
+	segments []segment
+	lit      strings.Builder
+}
+
+func (b *segmentBuilder) flush() {
+	if b.lit.Len() > 0 {
+		b.segments = append(b.segments, segment{literal: b.lit.String()})
+		b.lit.Reset()
+	}
+}
+
+func (b *segmentBuilder) add(seg segment) {
+	b.flush()
+	b.segments = append(b.segments, seg)
+}
+
+// walk mirrors regextra's walkEncodeAST.
+func (b *segmentBuilder) walk(re *syntax.Regexp, leaves []leaf, named *types.Named) error {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.lit.WriteString(string(re.Rune))
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := b.walk(sub, leaves, named); err != nil {
+				return err
+			}
+		}
+	case syntax.OpCapture:
+		return b.capture(re, leaves, named)
+	case syntax.OpBeginLine, syntax.OpBeginText,
+		syntax.OpEndLine, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary,
+		syntax.OpEmptyMatch:
+	case syntax.OpAlternate:
+		alts := make([][]segment, len(re.Sub))
+		for i, sub := range re.Sub {
+			var branch segmentBuilder
+			if err := branch.walk(sub, leaves, named); err != nil {
+				return err
+			}
+			branch.flush()
+			alts[i] = branch.segments
+		}
+		b.add(segment{alternatives: alts})
+	case syntax.OpQuest, syntax.OpRepeat, syntax.OpStar, syntax.OpPlus:
+		if !hasNamedCapture(re.Sub[0]) {
+			return notInvertibleError("a quantifier (`*`, `+`, `?`, or `{n,m}`)")
+		}
+		if re.Op != syntax.OpQuest && (re.Op != syntax.OpRepeat || re.Min != 0 || re.Max != 1) {
+			return fmt.Errorf("a repetition around a named capture is %w", errNotSupported)
+		}
+		var sub segmentBuilder
+		if err := sub.walk(re.Sub[0], leaves, named); err != nil {
+			return err
+		}
+		sub.flush()
+		b.add(segment{optional: sub.segments})
+	case syntax.OpCharClass:
+		return notInvertibleError("a character class (`[...]`)")
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return notInvertibleError("an any-character wildcard (`.`)")
+	default:
+		return notInvertibleError(fmt.Sprintf("a non-invertible construct (%s)", re.Op))
+	}
+	return nil
+}
+
+// capture mirrors regextra's walkCapture: a named group becomes a field,
+// resolved as resolveEncodeField does; an unnamed one must be literal.
+func (b *segmentBuilder) capture(re *syntax.Regexp, leaves []leaf, named *types.Named) error {
+	if re.Name == "" {
+		s, ok := literalString(re.Sub[0])
+		if !ok {
+			return notInvertibleError("an unnamed capturing group with non-literal content")
+		}
+		b.lit.WriteString(s)
+		return nil
+	}
+	var lf *leaf
+	for i := range leaves {
+		if leaves[i].name() == re.Name {
+			lf = &leaves[i]
+			break
+		}
+	}
+	if lf == nil {
+		for i := range leaves {
+			if !leaves[i].tagged() && strings.EqualFold(leaves[i].name(), re.Name) {
+				lf = &leaves[i]
+				break
+			}
+		}
+	}
+	if lf == nil {
+		return fmt.Errorf("capture group %q maps to no exported field of %s", re.Name, typeString(named))
+	}
+	t := lf.typ
+	if isCollection(t) {
+		if _, ok := splitSeparator(lf.tag.opts); !ok {
+			return fmt.Errorf("list field %s is %w", lf.fieldPath(), errNotSupported)
+		}
+		t = t.Underlying().(*types.Slice).Elem()
+	}
+	if _, ok := encodeConv(deref(t)); !ok {
+		return fmt.Errorf("field %s has unsupported type %s", lf.fieldPath(), typeString(lf.typ))
+	}
+	if _, ok := deref(t).(*types.Pointer); ok {
+		return fmt.Errorf("field %s has unsupported type %s", lf.fieldPath(), typeString(lf.typ))
+	}
+	b.add(segment{field: lf, group: re.Name})
+	return nil
+}
+
+// notInvertibleError mirrors regextra's ErrNotInvertible message.
+func notInvertibleError(construct string) error {
+	return fmt.Errorf("pattern is not invertible: contains %s outside a named capture group", construct)
+}
+
+// ── Emission ────────────────────────────────────────────────────────────────
+
+// encoder emits the Encode function and its body.
+func (g *generator) encoder(segments []segment) {
+	g.use("strings")
+	name, typ := g.funcName("Encode"), g.named.Obj().Name()
+	g.printf("// %s renders v as a string %s decodes back into v, as the\n", name, g.funcName("Decode"))
+	g.printf("// Encoder of regextra.MustCompile[%s](%s) does, without reflection.\n", typ, g.cfg.patternName)
+	g.printf("func %s(v %s) (string, error) {\n", name, typ)
+	g.printf("var b strings.Builder\n")
+	g.printf("if err := %sencode(&v, &b); err != nil {\n", g.prefix)
+	g.printf("return \"\", fmt.Errorf(\"%s: %%w\", err)\n}\n", name)
+	g.printf("return b.String(), nil\n}\n\n")
+
+	g.printf("// %sencode writes v to b, segment by segment of the pattern.\n", g.prefix)
+	g.printf("func %sencode(v *%s, b *strings.Builder) error {\n", g.prefix, typ)
+	g.segments(segments)
+	g.printf("return nil\n}\n\n")
+}
+
+// segments emits one level of the encode plan.
+func (g *generator) segments(segments []segment) {
+	for _, seg := range segments {
+		switch {
+		case seg.optional != nil:
+			if cond := g.sectionPresent(seg.optional); cond == "true" {
+				g.segments(seg.optional)
+			} else {
+				g.printf("if %s {\n", cond)
+				g.segments(seg.optional)
+				g.printf("}\n")
+			}
+		case seg.alternatives != nil:
+			g.alternatives(seg.alternatives)
+		case seg.field != nil:
+			g.encodeField(seg)
+		default:
+			g.printf("b.WriteString(%s)\n", strconv.Quote(seg.literal))
+		}
+	}
+}
+
+// alternatives emits the choice of the first branch whose fields are all set,
+// failing on the first field of the first branch when none is.
+func (g *generator) alternatives(alts [][]segment) {
+	g.printf("switch {\n")
+	for _, branch := range alts {
+		cond := g.branchSet(branch)
+		if cond == "true" {
+			g.printf("default:\n")
+			g.segments(branch)
+			g.printf("}\n")
+			return
+		}
+		g.printf("case %s:\n", cond)
+		g.segments(branch)
+	}
+	seg := firstField(alts[0])
+	g.use(regextra)
+	g.use("errors")
+	g.printf("default:\nreturn &regextra.EncodeError{Field: %q, Group: %q, Type: %q, Err: errors.New(%q)}\n}\n",
+		seg.field.fieldPath(), seg.group, typeString(seg.field.typ), "no alternative of the pattern has all of its fields set")
+}
+
+// encodeField emits the rendering of one field into b, with
+// encodeFieldValue's conversions and error messages.
+func (g *generator) encodeField(seg segment) {
+	lf := seg.field
+	g.printf("// %s, into group %q.\n{\n", lf.fieldPath(), seg.group)
+	fail := func(err string) {
+		g.use(regextra)
+		g.printf("return &regextra.EncodeError{Field: %q, Group: %q, Type: %q, Err: %s}\n", lf.fieldPath(), seg.group, typeString(lf.typ), err)
+	}
+	g.printf("var s string\n")
+	if isCollection(lf.typ) {
+		sep, _ := splitSeparator(lf.tag.opts)
+		elem := lf.typ.Underlying().(*types.Slice).Elem()
+		g.printf("parts := make([]string, len(%s))\n", lf.expr())
+		g.printf("for i := range %s {\nvar s string\n", lf.expr())
+		g.renderValue(elem, lf.expr()+"[i]", lf.tag.opts, func(err string) {
+			fail(fmt.Sprintf("fmt.Errorf(\"element %%d: %%w\", i, %s)", err))
+		})
+		g.printf("parts[i] = s\n}\n")
+		g.printf("s = strings.Join(parts, %s)\n", strconv.Quote(sep))
+	} else {
+		g.renderValue(lf.typ, lf.expr(), lf.tag.opts, fail)
+	}
+	g.printf("b.WriteString(s)\n}\n")
+}
+
+// renderValue emits statements setting s to the string form of x, an
+// addressable expression of type t.
+func (g *generator) renderValue(t types.Type, x string, opts map[string]string, fail func(err string)) {
+	recv := x
+	if _, ok := t.(*types.Pointer); ok {
+		g.use("errors")
+		g.printf("if %s == nil {\n", x)
+		fail("errors.New(" + strconv.Quote("cannot encode nil pointer of type "+typeString(t)) + ")")
+		g.printf("}\n")
+		t, x = deref(t), "*"+x
+	}
+	c, _ := encodeConv(t)
+	switch c {
+	case convString:
+		if types.Identical(t, types.Typ[types.String]) {
+			g.printf("s = %s\n", x)
+		} else {
+			g.printf("s = string(%s)\n", x)
+		}
+	case convInt:
+		g.use("strconv")
+		g.printf("s = strconv.FormatInt(int64(%s), 10)\n", x)
+	case convUint:
+		g.use("strconv")
+		g.printf("s = strconv.FormatUint(uint64(%s), 10)\n", x)
+	case convFloat:
+		g.use("strconv")
+		g.printf("s = strconv.FormatFloat(float64(%s), 'g', -1, %d)\n", x, bitSize(t))
+	case convBool:
+		g.use("strconv")
+		g.printf("s = strconv.FormatBool(bool(%s))\n", x)
+	case convDuration:
+		g.printf("s = %s.String()\n", recv)
+	case convTime:
+		layout := "time.RFC3339Nano"
+		if l := opts["layout"]; l != "" {
+			layout = strconv.Quote(l)
+		} else {
+			g.use("time")
+		}
+		g.printf("s = %s.Format(%s)\n", recv, layout)
+	case convRegex:
+		g.printf("{\nvar err error\nif s, err = %s.MarshalRegex(); err != nil {\n", recv)
+		fail("err")
+		g.printf("}\n}\n")
+	case convText:
+		g.printf("if text, err := %s.MarshalText(); err != nil {\n", recv)
+		fail(fmt.Sprintf("fmt.Errorf(\"cannot encode %s: %%w\", err)", typeString(t)))
+		g.printf("} else {\ns = string(text)\n}\n")
+	}
+}
+
+// sectionPresent returns the condition under which an optional section is
+// emitted, as regextra's sectionPresent decides it: any of its fields
+// present.
+func (g *generator) sectionPresent(segments []segment) string {
+	var terms []string
+	for _, seg := range segments {
+		var cond string
+		switch {
+		case seg.optional != nil:
+			cond = g.sectionPresent(seg.optional)
+		case seg.alternatives != nil:
+			var alts []string
+			for _, branch := range seg.alternatives {
+				alts = append(alts, g.sectionPresent(branch))
+			}
+			cond = or(alts)
+		case seg.field != nil:
+			cond = g.fieldPresent(seg.field, seg.field.tag.omitempty)
+		default:
+			continue
+		}
+		terms = append(terms, cond)
+	}
+	return or(terms)
+}
+
+// branchSet returns the condition under which an alternation branch is
+// chosen, as regextra's branchSet decides it: every field it fills present
+// and non-empty.
+func (g *generator) branchSet(segments []segment) string {
+	var terms []string
+	for _, seg := range segments {
+		switch {
+		case seg.alternatives != nil:
+			var alts []string
+			for _, branch := range seg.alternatives {
+				alts = append(alts, g.branchSet(branch))
+			}
+			terms = append(terms, or(alts))
+		case seg.field != nil:
+			terms = append(terms, g.fieldPresent(seg.field, true))
+		}
+	}
+	return and(terms)
+}
+
+// fieldPresent returns the condition under which a field holds a value to
+// emit, as regextra's fieldPresent decides it: a non-nil pointer and, when
+// emptyIsAbsent, a non-empty value.
+func (g *generator) fieldPresent(lf *leaf, emptyIsAbsent bool) string {
+	x := lf.expr()
+	if _, ok := lf.typ.(*types.Pointer); ok {
+		return x + " != nil"
+	}
+	if !emptyIsAbsent {
+		return "true"
+	}
+	switch t := lf.typ.Underlying().(type) {
+	case *types.Slice:
+		return "len(" + x + ") != 0"
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsString != 0:
+			return x + ` != ""`
+		case t.Info()&types.IsBoolean != 0:
+			return x
+		default:
+			return x + " != 0"
+		}
+	}
+	if types.Comparable(lf.typ) {
+		return x + " != (" + g.goType(lf.typ) + "{})"
+	}
+	g.use("reflect")
+	return "!reflect.ValueOf(" + x + ").IsZero()"
+}
+
+// firstField returns the first field segment of a plan, as regextra's
+// firstFieldSegment does. A branch that is not always set has one.
+func firstField(segments []segment) segment {
+	for _, seg := range segments {
+		switch {
+		case seg.field != nil:
+			return seg
+		case seg.optional != nil:
+			if f := firstField(seg.optional); f.field != nil {
+				return f
+			}
+		case seg.alternatives != nil:
+			if f := firstField(seg.alternatives[0]); f.field != nil {
+				return f
+			}
+		}
+	}
+	return segment{}
+}
+
+// or and and join conditions, folding the constant "true".
+func or(terms []string) string {
+	for _, t := range terms {
+		if t == "true" {
+			return "true"
+		}
+	}
+	if len(terms) == 0 {
+		return "false"
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return "(" + strings.Join(terms, " || ") + ")"
+}
+
+func and(terms []string) string {
+	var kept []string
+	for _, t := range terms {
+		if t != "true" {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		return "true"
+	}
+	return strings.Join(kept, " && ")
+}
+
+// literalString mirrors regextra's literalString: the fixed text re reduces
+// to, if any.
+func literalString(re *syntax.Regexp) (string, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune), true
+	case syntax.OpEmptyMatch,
+		syntax.OpBeginLine, syntax.OpBeginText,
+		syntax.OpEndLine, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return "", true
+	case syntax.OpConcat:
+		var b strings.Builder
+		for _, sub := range re.Sub {
+			s, ok := literalString(sub)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(s)
+		}
+		return b.String(), true
+	case syntax.OpCapture:
+		if re.Name != "" {
+			return "", false
+		}
+		return literalString(re.Sub[0])
+	}
+	return "", false
+}
+
+// hasNamedCapture reports whether re contains a named capture group.
+func hasNamedCapture(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpCapture && re.Name != "" {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasNamedCapture(sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// encodePlan fails where Decoder.Encoder would, and on repetitions, pointing
+// at -encode=false.
+func TestRun_encodeErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"char class",
+			"type T struct{ A string }\nconst P = `[ab](?P<a>x)`",
+			"pattern is not invertible: contains a character class (`[...]`) outside a named capture group",
+		},
+		{
+			"wildcard",
+			"type T struct{ A string }\nconst P = `.(?P<a>x)`",
+			"pattern is not invertible: contains an any-character wildcard (`.`) outside a named capture group",
+		},
+		{
+			"repetition",
+			"type T struct{ A string }\nconst P = `(?:(?P<a>\\w);)*`",
+			"a repetition around a named capture is not supported by the generated encoder",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runError(t, tt.src, true)
+			if !strings.Contains(got, tt.want) || !strings.Contains(got, "pass -encode=false") {
+				t.Errorf("run() error = %q, want it to contain %q and the -encode=false hint", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_decodeOnly(t *testing.T) {
+	src := "type T struct{ A string }\nconst P = `(?:(?P<a>\\w);)*`"
+	out, err := run(writePackage(t, src), "", config{typeName: "T", patternName: "P", tagKey: "regex", command: "regextra-gen"})
+	if err != nil {
+		t.Fatalf("run(-encode=false) error = %v", err)
+	}
+	if s := string(out); !strings.Contains(s, "func DecodeT(") || strings.Contains(s, "EncodeT") {
+		t.Errorf("run(-encode=false) output declares the wrong functions:\n%s", s)
+	}
+}
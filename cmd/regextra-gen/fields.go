@@ -0,0 +1,499 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldTag is the parsed form of one field's struct tag — the regextra
+// package's parseFieldTag, over the tag's source text.
+type fieldTag struct {
+	name      string
+	opts      map[string]string
+	required  bool
+	prefix    bool
+	omitempty bool
+	skip      bool
+	// unsupported names the tag's first option the generator does not
+	// support — a value transform, a numeric format, or a time option other
+	// than a Go layout= — or "" for none.
+	unsupported string
+}
+
+// parseTag parses the key entry of the struct tag tag with regextra's
+// grammar: a group name, then `key=value` options and the `required`,
+// `prefix`, and `omitempty` flags. Unknown options and flags are kept or
+// ignored as regextra does; options the generator does not support are
+// recorded so buildPlan can reject them.
+func parseTag(tag, key string) fieldTag {
+	s := reflect.StructTag(tag).Get(key)
+	if s == "-" {
+		return fieldTag{skip: true}
+	}
+	if s == "" {
+		return fieldTag{}
+	}
+	parts := strings.Split(s, ",")
+	ft := fieldTag{name: strings.TrimSpace(parts[0])}
+	for _, p := range parts[1:] {
+		k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok {
+			switch k {
+			case "required":
+				ft.required = true
+			case "prefix":
+				ft.prefix = true
+			case "omitempty":
+				ft.omitempty = true
+			case "trim", "lower", "upper", "unquote", "percent":
+				if ft.unsupported == "" {
+					ft.unsupported = k
+				}
+			}
+			continue
+		}
+		k = strings.TrimSpace(k)
+		switch k {
+		case "trimprefix", "trimsuffix", "replace", "base", "sep", "unit", "prec", "unitbase", "layouts", "tz":
+			if ft.unsupported == "" {
+				ft.unsupported = k + "="
+			}
+		case "layout":
+			switch l := strings.TrimSpace(v); l {
+			case "unix", "unixmilli", "unixmicro", "unixnano":
+				if ft.unsupported == "" {
+					ft.unsupported = k + "=" + l
+				}
+			}
+		}
+		if ft.opts == nil {
+			ft.opts = make(map[string]string, len(parts)-1)
+		}
+		ft.opts[k] = strings.TrimSpace(v)
+	}
+	return ft
+}
+
+// splitSeparator returns the separator a `split=` option names, spelled as
+// regextra spells it, and whether the option is set.
+func splitSeparator(opts map[string]string) (string, bool) {
+	sep, ok := opts["split"]
+	if !ok {
+		return "", false
+	}
+	switch sep {
+	case "", "comma":
+		return ",", true
+	case "space":
+		return " ", true
+	case "tab":
+		return "\t", true
+	}
+	return sep, true
+}
+
+// leaf is one field the struct walk reaches, as regextra's structLeaf.
+type leaf struct {
+	// path holds the Go field names from the root type to the leaf.
+	path []string
+	typ  types.Type
+	tag  fieldTag
+	// prefix and prefixFromName are the group-name prefix accumulated from
+	// enclosing `prefix` fields, and whether a Go field name contributed to it.
+	prefix         string
+	prefixFromName bool
+}
+
+func (lf leaf) tagged() bool { return lf.tag.name != "" && !lf.prefixFromName }
+
+// name returns the group name the leaf is addressable by.
+func (lf leaf) name() string {
+	if lf.tag.name != "" {
+		return lf.prefix + lf.tag.name
+	}
+	return lf.prefix + lf.path[len(lf.path)-1]
+}
+
+// fieldPath is the leaf's selector path from the root, as regextra reports it
+// in DecodeError.Field.
+func (lf leaf) fieldPath() string { return strings.Join(lf.path, ".") }
+
+// expr is the leaf's selector expression on the generated code's v.
+func (lf leaf) expr() string { return "v." + lf.fieldPath() }
+
+// walkFields visits the leaves of st in declaration order, flattening
+// untagged embedded structs and `prefix` structs as regextra's
+// visitStructFields does. A struct reached through a pointer is not
+// supported.
+func walkFields(st *types.Struct, parent []string, outer leaf, stack []types.Type, key string, visit func(leaf) error) error {
+	for i := range st.NumFields() {
+		f := st.Field(i)
+		tag := parseTag(st.Tag(i), key)
+		if tag.skip {
+			continue
+		}
+		path := append(slices.Clip(parent), f.Name())
+
+		ft := f.Type()
+		_, isPtr := ft.(*types.Pointer)
+		inner := deref(ft)
+		_, isStruct := inner.Underlying().(*types.Struct)
+		nestable := isStruct && !convertsItself(inner)
+		embedded := f.Embedded() && tag.name == "" && !tag.prefix && nestable
+		if embedded && isPtr && !f.Exported() {
+			continue
+		}
+		if !f.Exported() && !embedded {
+			continue
+		}
+
+		if tag.prefix && !nestable {
+			return fmt.Errorf("field %s has `prefix` option but is %s, not a struct", strings.Join(path, "."), typeString(ft))
+		}
+		if embedded || tag.prefix {
+			if slices.ContainsFunc(stack, func(t types.Type) bool { return types.Identical(t, inner) }) {
+				continue
+			}
+			if isPtr {
+				return fmt.Errorf("field %s: flattening a struct through a pointer is not supported", strings.Join(path, "."))
+			}
+			next := outer
+			if tag.prefix {
+				name := tag.name
+				if name == "" {
+					name = f.Name()
+					next.prefixFromName = true
+				}
+				next.prefix += name + "_"
+			}
+			if err := walkFields(inner.Underlying().(*types.Struct), path, next, append(stack, inner), key, visit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := visit(leaf{path: path, typ: ft, tag: tag, prefix: outer.prefix, prefixFromName: outer.prefixFromName}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeField is one entry of the decode plan: a leaf and the groups it reads.
+type decodeField struct {
+	leaf
+	// groups holds the submatch index of every occurrence of the leaf's group.
+	groups []int
+	// group is the name DecodeError.Group reports.
+	group string
+	// collect is set for a slice field, which takes every occurrence.
+	collect bool
+}
+
+// buildPlan maps the leaves of st to the groups of re as regextra's
+// buildDecodePlan does under Compile's strict validation, and additionally
+// rejects what the generator does not support.
+func buildPlan(st *types.Struct, stack []types.Type, re *regexp.Regexp, repeated map[int]bool, key string) ([]decodeField, error) {
+	var plan []decodeField
+	err := walkFields(st, nil, leaf{}, stack, key, func(lf leaf) error {
+		opts := lf.tag.opts
+		groupName := lf.name()
+		if !lf.tagged() {
+			groupName = matchGroupName(re, groupName)
+		}
+		def, hasDefault := opts["default"]
+		var groups []int
+		if groupName != "" {
+			for i, n := range re.SubexpNames() {
+				if i != 0 && n == groupName {
+					groups = append(groups, i)
+				}
+			}
+			if len(groups) == 0 && !hasDefault {
+				return fmt.Errorf("field %s references group %q which is not declared on the pattern", lf.fieldPath(), groupName)
+			}
+		}
+
+		if lf.tag.unsupported != "" {
+			return fmt.Errorf("field %s: the `%s` tag option is not supported", lf.fieldPath(), lf.tag.unsupported)
+		}
+
+		t := lf.typ
+		collect := isCollection(t)
+		if collect {
+			if _, ok := t.Underlying().(*types.Array); ok {
+				return fmt.Errorf("field %s: array fields are not supported", lf.fieldPath())
+			}
+			t = t.Underlying().(*types.Slice).Elem()
+		}
+		if _, ok := opts["layout"]; ok && !isTime(deref(t)) {
+			return fmt.Errorf("field %s has `layout=` option but is %s, not time.Time", lf.fieldPath(), typeString(lf.typ))
+		}
+		if _, ok := opts["split"]; ok && !collect {
+			return fmt.Errorf("field %s has `split=` option but is %s, not a slice or array", lf.fieldPath(), typeString(lf.typ))
+		}
+		if _, ok := t.(*types.Pointer); ok && isCollection(deref(t)) {
+			return fmt.Errorf("field %s has unsupported type %s", lf.fieldPath(), typeString(lf.typ))
+		}
+		if _, ok := decodeConv(deref(t)); !ok {
+			return fmt.Errorf("field %s has unsupported type %s", lf.fieldPath(), typeString(lf.typ))
+		}
+		if collect {
+			for _, gi := range groups {
+				if repeated[gi] {
+					return fmt.Errorf("field %s: a list group inside a repetition is not supported", lf.fieldPath())
+				}
+			}
+		}
+		if hasDefault {
+			if err := checkDefault(t, def, collect, opts); err != nil {
+				return fmt.Errorf("field %s default %q does not convert to %s: %w", lf.fieldPath(), def, typeString(lf.typ), err)
+			}
+		}
+		if len(groups) == 0 && !hasDefault && !lf.tag.required {
+			return nil
+		}
+
+		group := ""
+		if len(groups) > 0 {
+			group = re.SubexpNames()[groups[0]]
+		} else if lf.tag.name != "" {
+			group = lf.name()
+		}
+		plan = append(plan, decodeField{leaf: lf, groups: groups, group: group, collect: collect})
+		return nil
+	})
+	return plan, err
+}
+
+// matchGroupName returns the group of re a field name selects: the exact
+// name, else the first case-insensitive match, else "".
+func matchGroupName(re *regexp.Regexp, fieldName string) string {
+	if re.SubexpIndex(fieldName) != -1 {
+		return fieldName
+	}
+	for _, n := range re.SubexpNames() {
+		if n != "" && strings.EqualFold(n, fieldName) {
+			return n
+		}
+	}
+	return ""
+}
+
+// checkDefault converts a `default=` value for an element type t at
+// generation time, as Compile does, for the conversions the generator can
+// run itself; a type that converts itself is checked when decoding.
+func checkDefault(t types.Type, def string, collect bool, opts map[string]string) error {
+	values := []string{def}
+	if sep, ok := splitSeparator(opts); ok && collect {
+		values = strings.Split(def, sep)
+	}
+	t = deref(t)
+	c, _ := decodeConv(t)
+	for _, s := range values {
+		if collect && s == "" {
+			continue
+		}
+		var err error
+		switch c {
+		case convInt:
+			_, err = strconv.ParseInt(s, 10, bitSize(t))
+		case convUint:
+			_, err = strconv.ParseUint(s, 10, bitSize(t))
+		case convFloat:
+			_, err = strconv.ParseFloat(s, bitSize(t))
+		case convBool:
+			_, err = strconv.ParseBool(s)
+		case convDuration:
+			_, err = time.ParseDuration(s)
+		case convTime:
+			if layout := opts["layout"]; layout != "" {
+				_, err = time.Parse(layout, s)
+			} else {
+				err = parseTime(s)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTime reports whether s parses under one of regextra's fallback time
+// layouts, returning the first layout's error when none does.
+func parseTime(s string) error {
+	var first error
+	for _, layout := range timeLayouts {
+		_, err := time.Parse(layout, s)
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// timeLayouts are regextra's fallback time.Time layouts, by the names the
+// generated code refers to them with.
+var timeLayouts = []string{time.RFC3339Nano, time.RFC3339, time.DateTime, time.DateOnly, time.TimeOnly}
+
+var timeLayoutNames = []string{"time.RFC3339Nano", "time.RFC3339", "time.DateTime", "time.DateOnly", "time.TimeOnly"}
+
+// conv is how a (non-pointer) value converts to or from its string form.
+type conv int
+
+const (
+	convString conv = iota
+	convInt
+	convUint
+	convFloat
+	convBool
+	convDuration
+	convTime
+	convRegex // UnmarshalRegex / MarshalRegex
+	convText  // UnmarshalText / MarshalText
+)
+
+// Method sets of the interfaces regextra dispatches on.
+var (
+	regexUnmarshaler = methodInterface("UnmarshalRegex", types.Typ[types.String], types.Universe.Lookup("error").Type())
+	textUnmarshaler  = methodInterface("UnmarshalText", types.NewSlice(types.Typ[types.Byte]), types.Universe.Lookup("error").Type())
+	regexMarshaler   = methodInterface("MarshalRegex", nil, types.Typ[types.String], types.Universe.Lookup("error").Type())
+	textMarshaler    = methodInterface("MarshalText", nil, types.NewSlice(types.Typ[types.Byte]), types.Universe.Lookup("error").Type())
+)
+
+// methodInterface returns the interface of the one method name, taking param
+// (none when nil) and returning results.
+func methodInterface(name string, param types.Type, results ...types.Type) *types.Interface {
+	var params []*types.Var
+	if param != nil {
+		params = append(params, types.NewParam(token.NoPos, nil, "", param))
+	}
+	var res []*types.Var
+	for _, r := range results {
+		res = append(res, types.NewParam(token.NoPos, nil, "", r))
+	}
+	sig := types.NewSignatureType(nil, nil, nil, types.NewTuple(params...), types.NewTuple(res...), false)
+	iface := types.NewInterfaceType([]*types.Func{types.NewFunc(token.NoPos, nil, name, sig)}, nil)
+	return iface.Complete()
+}
+
+// decodeConv returns how a field of the non-pointer type t decodes, in
+// setFieldValue's precedence: RegexUnmarshaler, the time types,
+// TextUnmarshaler, then the kind. ok is false for an unsupported type.
+func decodeConv(t types.Type) (c conv, ok bool) {
+	switch {
+	case types.Implements(types.NewPointer(t), regexUnmarshaler):
+		return convRegex, true
+	case isTime(t):
+		return convTime, true
+	case isDuration(t):
+		return convDuration, true
+	case types.Implements(types.NewPointer(t), textUnmarshaler):
+		return convText, true
+	}
+	return basicConv(t)
+}
+
+// encodeConv is decodeConv's encode-side mirror, in encodeFieldValue's
+// precedence.
+func encodeConv(t types.Type) (c conv, ok bool) {
+	switch {
+	case types.Implements(t, regexMarshaler) || types.Implements(types.NewPointer(t), regexMarshaler):
+		return convRegex, true
+	case isTime(t):
+		return convTime, true
+	case isDuration(t):
+		return convDuration, true
+	case types.Implements(t, textMarshaler) || types.Implements(types.NewPointer(t), textMarshaler):
+		return convText, true
+	}
+	return basicConv(t)
+}
+
+// basicConv classifies t by its underlying basic kind.
+func basicConv(t types.Type) (conv, bool) {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return 0, false
+	}
+	switch b.Kind() {
+	case types.String:
+		return convString, true
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+		return convInt, true
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		return convUint, true
+	case types.Float32, types.Float64:
+		return convFloat, true
+	case types.Bool:
+		return convBool, true
+	}
+	return 0, false
+}
+
+// bitSize returns the bit size of the numeric basic type underlying t, as
+// reflect.Type.Bits does.
+func bitSize(t types.Type) int {
+	switch t.Underlying().(*types.Basic).Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int, types.Uint:
+		return strconv.IntSize
+	}
+	return 64
+}
+
+// convertsItself mirrors regextra's convertsItself, less the converter
+// registries, which the generator does not consult.
+func convertsItself(t types.Type) bool {
+	if isTime(t) {
+		return true
+	}
+	pt := types.NewPointer(t)
+	return types.Implements(pt, regexUnmarshaler) || types.Implements(pt, textUnmarshaler) ||
+		types.Implements(pt, regexMarshaler) || types.Implements(pt, textMarshaler)
+}
+
+// isCollection reports whether a field of type t is a list of elements.
+func isCollection(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		return !convertsItself(t)
+	}
+	return false
+}
+
+func isTime(t types.Type) bool     { return isNamed(t, "time", "Time") }
+func isDuration(t types.Type) bool { return isNamed(t, "time", "Duration") }
+
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == pkg && n.Obj().Name() == name
+}
+
+// deref returns the element type of a pointer type, or t itself.
+func deref(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// typeString renders t as reflect.Type.String does, for error messages.
+func typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string { return p.Name() })
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// buildPlan rejects what Compile rejects, and what the generator does not
+// support, naming the field.
+func TestRun_fieldErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"undeclared group",
+			"type T struct{ A string `regex:\"a\"` }\nconst P = `(?P<b>x)`",
+			`field A references group "a" which is not declared on the pattern`,
+		},
+		{
+			"bad default",
+			"type T struct{ A int `regex:\"a,default=x\"` }\nconst P = `(?P<a>\\d*)`",
+			`field A default "x" does not convert to int`,
+		},
+		{
+			"layout on non-time",
+			"type T struct{ A int `regex:\"a,layout=2006\"` }\nconst P = `(?P<a>\\d+)`",
+			"field A has `layout=` option but is int, not time.Time",
+		},
+		{
+			"split on scalar",
+			"type T struct{ A int `regex:\"a,split=comma\"` }\nconst P = `(?P<a>\\d+)`",
+			"field A has `split=` option but is int, not a slice or array",
+		},
+		{
+			"prefix on scalar",
+			"type T struct{ A int `regex:\"a_,prefix\"` }\nconst P = `(?P<a>\\d+)`",
+			"field A has `prefix` option but is int, not a struct",
+		},
+		{
+			"flatten through pointer",
+			"type In struct{ A int }\ntype T struct{ *In }\nconst P = `(?P<a>\\d+)`",
+			"field In: flattening a struct through a pointer is not supported",
+		},
+		{
+			"array",
+			"type T struct{ A [2]int `regex:\"a\"` }\nconst P = `(?P<a>\\d+)`",
+			"field A: array fields are not supported",
+		},
+		{
+			"map",
+			"type T struct{ A map[string]int `regex:\"a\"` }\nconst P = `(?P<a>\\d+)`",
+			"field A has unsupported type map[string]int",
+		},
+		{
+			"value transform",
+			"type T struct{ A int `regex:\"a,trim,trimprefix=#\"` }\nconst P = `(?P<a>.+)`",
+			"field A: the `trim` tag option is not supported",
+		},
+		{
+			"replace",
+			"type T struct{ A string `regex:\"a,replace=_:space\"` }\nconst P = `(?P<a>.+)`",
+			"field A: the `replace=` tag option is not supported",
+		},
+		{
+			"numeric format",
+			"type T struct{ A int `regex:\"a,base=16\"` }\nconst P = `(?P<a>.+)`",
+			"field A: the `base=` tag option is not supported",
+		},
+		{
+			"unit",
+			"type T struct{ A int64 `regex:\"a,unit=bytes,prec=1\"` }\nconst P = `(?P<a>.+)`",
+			"field A: the `unit=` tag option is not supported",
+		},
+		{
+			"epoch layout",
+			"type T struct{ A time.Time `regex:\"a,layout=unixmilli\"` }\nconst P = `(?P<a>\\d+)`",
+			"field A: the `layout=unixmilli` tag option is not supported",
+		},
+		{
+			"time zone",
+			"type T struct{ A time.Time `regex:\"a,layouts=15:04|15:04:05,tz=UTC\"` }\nconst P = `(?P<a>.+)`",
+			"field A: the `layouts=` tag option is not supported",
+		},
+		{
+			"list in repetition",
+			"type T struct{ A []int `regex:\"a,split=comma\"` }\nconst P = `(?:(?P<a>[\\d,]+);)+`",
+			"field A: a list group inside a repetition is not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runError(t, tt.src, false); !strings.Contains(got, tt.want) {
+				t.Errorf("run() error = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
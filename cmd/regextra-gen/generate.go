@@ -0,0 +1,406 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// generator emits the decode and encode functions for one struct type.
+type generator struct {
+	printer
+	pkg   *types.Package
+	named *types.Named
+	cfg   config
+	// prefix starts the names of the file's unexported helpers, so files
+	// generated for several types of one package do not collide.
+	prefix string
+	// imports records the packages the emitted code uses, by path.
+	imports map[string]bool
+	// helpers records which shared helper functions the emitted code calls.
+	helpers map[string]bool
+}
+
+// generate returns the formatted source of the file regextra-gen writes for
+// named, decoded with pattern, in package pkg.
+func generate(pkg *types.Package, named *types.Named, pattern string, cfg config) ([]byte, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %s: %w", cfg.patternName, err)
+	}
+	ast, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %s: %w", cfg.patternName, err)
+	}
+	st := named.Underlying().(*types.Struct)
+	plan, err := buildPlan(st, []types.Type{named}, re, repeatedCaptures(ast, false, nil), cfg.tagKey)
+	if err != nil {
+		return nil, err
+	}
+	var segments []segment
+	if cfg.encode {
+		if segments, err = encodePlan(st, named, ast, cfg.tagKey); err != nil {
+			return nil, fmt.Errorf("%w (pass -encode=false to generate the decoder alone)", err)
+		}
+	}
+
+	g := &generator{
+		pkg:     pkg,
+		named:   named,
+		cfg:     cfg,
+		prefix:  "_" + named.Obj().Name() + "_",
+		imports: map[string]bool{"regexp": true},
+		helpers: map[string]bool{},
+	}
+	g.decoder(plan)
+	if cfg.encode {
+		g.encoder(segments)
+	}
+	g.emitHelpers()
+	code := g.String()
+
+	var out printer
+	out.printf("// Code generated by %s; DO NOT EDIT.\n\n", cfg.command)
+	out.printf("package %s\n\n", pkg.Name())
+	out.printf("import (\n")
+	// Standard packages first, then the rest, as goimports groups them.
+	var std, other []string
+	for p := range g.imports {
+		if strings.Contains(strings.Split(p, "/")[0], ".") {
+			other = append(other, p)
+		} else {
+			std = append(std, p)
+		}
+	}
+	slices.Sort(std)
+	slices.Sort(other)
+	for _, p := range std {
+		out.printf("%q\n", p)
+	}
+	if len(other) > 0 {
+		out.printf("\n")
+	}
+	for _, p := range other {
+		out.printf("%q\n", p)
+	}
+	out.printf(")\n\n")
+	out.printf("var %sregexp = regexp.MustCompile(%s)\n\n", g.prefix, cfg.patternName)
+	out.WriteString(code)
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// funcName returns the generated entrypoint name for verb ("Decode" or
+// "Encode"), unexported for an unexported type.
+func (g *generator) funcName(verb string) string {
+	name := g.named.Obj().Name()
+	if !token.IsExported(name) {
+		verb = strings.ToLower(verb)
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return verb + name
+}
+
+// goType renders t as Go source in the generated file's package, recording
+// the imports it needs.
+func (g *generator) goType(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = true
+		return p.Name()
+	})
+}
+
+// regextra is the import path of the package generated code refers to.
+const regextra = "github.com/jecoms/regextra"
+
+// use records that the emitted code refers to the standard package path.
+func (g *generator) use(path string) { g.imports[path] = true }
+
+// ── Decode ──────────────────────────────────────────────────────────────────
+
+// decoder emits the Decode function and its per-field body.
+func (g *generator) decoder(plan []decodeField) {
+	g.use(regextra)
+	g.use("fmt")
+	name, typ := g.funcName("Decode"), g.named.Obj().Name()
+	g.printf("// %s decodes the first match of %s in target, as\n", name, g.cfg.patternName)
+	g.printf("// regextra.MustCompile[%s](%s).One does, without reflection.\n", typ, g.cfg.patternName)
+	g.printf("// It returns regextra.ErrNoMatch when target does not match.\n")
+	g.printf("func %s(target string) (%s, error) {\n", name, typ)
+	g.printf("var v %s\n", typ)
+	g.printf("m := %sregexp.FindStringSubmatchIndex(target)\n", g.prefix)
+	g.printf("if m == nil {\nreturn v, regextra.ErrNoMatch\n}\n")
+	g.printf("if err := %sdecode(&v, target, m); err != nil {\n", g.prefix)
+	g.printf("return v, fmt.Errorf(\"%s: %%w\", err)\n}\n", name)
+	g.printf("return v, nil\n}\n\n")
+
+	g.printf("// %sdecode fills v from the match m of target, field by field.\n", g.prefix)
+	g.printf("func %sdecode(v *%s, target string, m []int) error {\n", g.prefix, typ)
+	for _, fd := range plan {
+		if fd.collect {
+			g.decodeList(fd)
+		} else {
+			g.decodeScalar(fd)
+		}
+	}
+	g.printf("return nil\n}\n\n")
+}
+
+// decodeScalar emits the block decoding one non-list field: the last
+// participating occurrence of its group wins, `default=` substitutes for an
+// absent or empty value, and otherwise the field is skipped or, if required,
+// fails.
+func (g *generator) decodeScalar(fd decodeField) {
+	t := fd.typ
+	elem := deref(t)
+	c, _ := decodeConv(elem)
+	_, hasDefault := fd.tag.opts["default"]
+	locate := (fd.tag.required && !hasDefault) || canFail(c)
+
+	g.printf("// %s, from group %q.\n{\n", fd.fieldPath(), fd.group)
+	g.printf("s, found := \"\", false\n")
+	if locate {
+		g.printf("start, end := m[0], m[1]\n")
+	}
+	for _, gi := range fd.groups {
+		g.printf("if m[%d] >= 0 {\n", 2*gi)
+		g.printf("s, found = target[m[%d]:m[%d]], true\n", 2*gi, 2*gi+1)
+		if locate {
+			g.printf("start, end = m[%d], m[%d]\n", 2*gi, 2*gi+1)
+		}
+		g.printf("}\n")
+	}
+	if hasDefault {
+		g.printf("if !found || s == \"\" {\ns = %s\n}\n", strconv.Quote(fd.tag.opts["default"]))
+		g.printf("{\n")
+	} else {
+		g.printf("if found && s != \"\" {\n")
+	}
+	fail := func(err string) {
+		g.helpers["decodeError"] = true
+		g.printf("return %sdecodeError(%q, %q, %q, s, target, start, end, %s)\n", g.prefix, fd.fieldPath(), fd.group, typeString(t), err)
+	}
+	dst := fd.expr()
+	if _, ok := t.(*types.Pointer); ok {
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", dst, dst, g.goType(elem))
+		dst = "*" + dst
+	}
+	g.convertFrom(elem, c, dst, fd.tag.opts, fail)
+	g.printf("}")
+	if !hasDefault && fd.tag.required {
+		g.printf(" else {\n")
+		g.requiredError(fd)
+		g.printf("}")
+	}
+	g.printf("\n}\n")
+}
+
+// decodeList emits the block decoding one slice field: every participating,
+// non-empty occurrence is an element, each split on `split=` when set.
+func (g *generator) decodeList(fd decodeField) {
+	t := fd.typ
+	elemT := t.Underlying().(*types.Slice).Elem()
+	c, _ := decodeConv(deref(elemT))
+	def, hasDefault := fd.tag.opts["default"]
+	locate := (fd.tag.required && !hasDefault) || canFail(c)
+
+	g.printf("// %s, from every occurrence of group %q.\n{\n", fd.fieldPath(), fd.group)
+	g.printf("var values []string\n")
+	if locate {
+		g.printf("var offsets []int\n")
+		g.printf("start, end := m[0], m[1]\n")
+	}
+	for _, gi := range fd.groups {
+		if locate {
+			g.printf("if m[%d] >= 0 {\n", 2*gi)
+			g.printf("start, end = m[%d], m[%d]\n", 2*gi, 2*gi+1)
+			g.printf("if start != end {\nvalues = append(values, target[start:end])\noffsets = append(offsets, start)\n}\n}\n")
+		} else {
+			g.printf("if m[%d] >= 0 && m[%d] != m[%d] {\n", 2*gi, 2*gi, 2*gi+1)
+			g.printf("values = append(values, target[m[%d]:m[%d]])\n}\n", 2*gi, 2*gi+1)
+		}
+	}
+	switch {
+	case hasDefault:
+		g.printf("if len(values) == 0 {\nvalues = []string{%s}\n", strconv.Quote(def))
+		if locate {
+			g.printf("offsets = []int{-1}\n")
+		}
+		g.printf("}\n")
+	case fd.tag.required:
+		g.printf("if len(values) == 0 {\n")
+		g.requiredError(fd)
+		g.printf("}\n")
+	}
+	if sep, ok := splitSeparator(fd.tag.opts); ok {
+		g.use("strings")
+		g.printf("{\n// Split each value on %q, dropping empty pieces.\n", sep)
+		g.printf("var parts []string\n")
+		if locate {
+			g.printf("var partOffsets []int\n")
+			g.printf("for i, s := range values {\noffset := offsets[i]\n")
+		} else {
+			g.printf("for _, s := range values {\n")
+		}
+		g.printf("for {\np, rest, more := strings.Cut(s, %s)\n", strconv.Quote(sep))
+		g.printf("if p != \"\" {\nparts = append(parts, p)\n")
+		if locate {
+			g.printf("partOffsets = append(partOffsets, offset)\n")
+		}
+		g.printf("}\nif !more {\nbreak\n}\n")
+		if locate {
+			g.printf("if offset >= 0 {\noffset += len(p) + %d\n}\n", len(sep))
+		}
+		g.printf("s = rest\n}\n}\n")
+		if locate {
+			g.printf("values, offsets = parts, partOffsets\n}\n")
+		} else {
+			g.printf("values = parts\n}\n")
+		}
+	}
+
+	if !hasDefault {
+		// Nothing participated: the field is skipped.
+		g.printf("if len(values) > 0 {\n")
+	} else {
+		g.printf("{\n")
+	}
+	g.printf("out := make(%s, len(values))\n", g.goType(t))
+	g.printf("for i, s := range values {\n")
+	fail := func(err string) {
+		g.helpers["decodeError"] = true
+		g.printf("if offsets[i] >= 0 {\nstart, end = offsets[i], offsets[i]+len(s)\n}\n")
+		g.printf("return %sdecodeError(%q, %q, %q, s, target, start, end, fmt.Errorf(\"element %%d: %%w\", i, %s))\n", g.prefix, fd.fieldPath(), fd.group, typeString(t), err)
+	}
+	dst := "out[i]"
+	if _, ok := elemT.(*types.Pointer); ok {
+		g.printf("out[i] = new(%s)\n", g.goType(deref(elemT)))
+		dst = "*out[i]"
+	}
+	g.convertFrom(deref(elemT), c, dst, fd.tag.opts, fail)
+	g.printf("}\n%s = out\n}\n}\n", fd.expr())
+}
+
+// requiredError emits the return of the RequiredGroupError for fd.
+func (g *generator) requiredError(fd decodeField) {
+	g.use("strings")
+	g.printf("return &regextra.RequiredGroupError{\nField: %q,\nGroup: %q,\n", fd.fieldPath(), fd.group)
+	g.printf("Offset: start,\nEnd: end,\n")
+	g.printf("Line: strings.Count(target[:start], \"\\n\") + 1,\n")
+	g.printf("Column: start - strings.LastIndexByte(target[:start], '\\n'),\n}\n")
+}
+
+// canFail reports whether converting to a value of conv c can fail.
+func canFail(c conv) bool { return c != convString }
+
+// convertFrom emits statements converting the string s into dst, an
+// assignable expression of the non-pointer type t, with setFieldValue's
+// conversion and error messages; fail emits the statement returning the
+// error expression it is passed.
+func (g *generator) convertFrom(t types.Type, c conv, dst string, opts map[string]string, fail func(err string)) {
+	recv := strings.TrimPrefix(dst, "*")
+	ts := typeString(t)
+	switch c {
+	case convString:
+		if types.Identical(t, types.Typ[types.String]) {
+			g.printf("%s = s\n", dst)
+		} else {
+			g.printf("%s = %s(s)\n", dst, g.goType(t))
+		}
+	case convInt, convUint, convFloat:
+		g.use("strconv")
+		parse := map[conv]string{convInt: "ParseInt(s, 10, %s)", convUint: "ParseUint(s, 10, %s)", convFloat: "ParseFloat(s, %s)"}[c]
+		bits := strconv.Itoa(bitSize(t))
+		if k := t.Underlying().(*types.Basic).Kind(); k == types.Int || k == types.Uint {
+			bits = "strconv.IntSize"
+		}
+		g.printf("if n, err := strconv."+parse+"; err != nil {\n", bits)
+		fail(fmt.Sprintf("fmt.Errorf(\"cannot convert %%q to %s: %%w\", s, err)", ts))
+		g.printf("} else {\n%s = %s(n)\n}\n", dst, g.goType(t))
+	case convBool:
+		g.use("strconv")
+		g.printf("if b, err := strconv.ParseBool(s); err != nil {\n")
+		fail("fmt.Errorf(\"cannot convert %q to bool: %w\", s, err)")
+		g.printf("} else {\n%s = %s(b)\n}\n", dst, g.goType(t))
+	case convDuration:
+		g.use("time")
+		g.printf("if d, err := time.ParseDuration(s); err != nil {\n")
+		fail("fmt.Errorf(\"cannot convert %q to time.Duration: %w\", s, err)")
+		g.printf("} else {\n%s = d\n}\n", dst)
+	case convTime:
+		g.use("time")
+		if layout := opts["layout"]; layout != "" {
+			g.printf("if t, err := time.Parse(%s, s); err != nil {\n", strconv.Quote(layout))
+			fail(fmt.Sprintf("fmt.Errorf(\"cannot convert %%q to time.Time using layout %%q: %%w\", s, %s, err)", strconv.Quote(layout)))
+		} else {
+			g.helpers["parseTime"] = true
+			g.printf("if t, err := %sparseTime(s); err != nil {\n", g.prefix)
+			fail("fmt.Errorf(\"cannot convert %q to time.Time: %w\", s, err)")
+		}
+		g.printf("} else {\n%s = t\n}\n", dst)
+	case convRegex:
+		g.printf("if err := %s.UnmarshalRegex(s); err != nil {\n", recv)
+		fail("err")
+		g.printf("}\n")
+	case convText:
+		g.printf("if err := %s.UnmarshalText([]byte(s)); err != nil {\n", recv)
+		fail(fmt.Sprintf("fmt.Errorf(\"cannot convert %%q to %s: %%w\", s, err)", ts))
+		g.printf("}\n")
+	}
+}
+
+// emitHelpers emits the shared helper functions the generated code calls.
+func (g *generator) emitHelpers() {
+	if g.helpers["decodeError"] {
+		g.use("strings")
+		g.printf("// %sdecodeError builds the DecodeError for a group that failed to\n// convert.\n", g.prefix)
+		g.printf("func %sdecodeError(field, group, typ, value, target string, start, end int, err error) error {\n", g.prefix)
+		g.printf("return &regextra.DecodeError{\nField: field,\nGroup: group,\nValue: value,\nType: typ,\nErr: err,\n")
+		g.printf("Offset: start,\nEnd: end,\n")
+		g.printf("Line: strings.Count(target[:start], \"\\n\") + 1,\n")
+		g.printf("Column: start - strings.LastIndexByte(target[:start], '\\n'),\n}\n}\n\n")
+	}
+	if g.helpers["parseTime"] {
+		g.printf("// %sparseTime parses s with the first of regextra's fallback layouts\n// that accepts it.\n", g.prefix)
+		g.printf("func %sparseTime(s string) (time.Time, error) {\n", g.prefix)
+		g.printf("var first error\n")
+		g.printf("for _, layout := range []string{%s} {\n", strings.Join(timeLayoutNames, ", "))
+		g.printf("t, err := time.Parse(layout, s)\nif err == nil {\nreturn t, nil\n}\n")
+		g.printf("if first == nil {\nfirst = err\n}\n}\nreturn time.Time{}, first\n}\n\n")
+	}
+}
+
+// repeatedCaptures adds to dst the index of every capture group of re that
+// can match more than once per match: one inside a `*`, `+`, or `{n,m}`
+// repetition whose maximum exceeds one.
+func repeatedCaptures(re *syntax.Regexp, inRepeat bool, dst map[int]bool) map[int]bool {
+	if dst == nil {
+		dst = make(map[int]bool)
+	}
+	switch re.Op {
+	case syntax.OpCapture:
+		if inRepeat {
+			dst[re.Cap] = true
+		}
+	case syntax.OpStar, syntax.OpPlus:
+		inRepeat = true
+	case syntax.OpRepeat:
+		inRepeat = inRepeat || re.Max == -1 || re.Max > 1
+	}
+	for _, sub := range re.Sub {
+		repeatedCaptures(sub, inRepeat, dst)
+	}
+	return dst
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRun_unexportedType(t *testing.T) {
+	src := "type entry struct{ Host string }\nconst pattern = `(?P<host>\\w+)`"
+	out, err := run(writePackage(t, src), "", config{typeName: "entry", patternName: "pattern", tagKey: "regex", encode: true, command: "regextra-gen -type entry -pattern pattern"})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	s := string(out)
+	for _, want := range []string{
+		"// Code generated by regextra-gen -type entry -pattern pattern; DO NOT EDIT.",
+		"func decodeEntry(target string) (entry, error)",
+		"func encodeEntry(v entry) (string, error)",
+		"var _entry_regexp = regexp.MustCompile(pattern)",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("run() output lacks %q:\n%s", want, s)
+		}
+	}
+}
+
+func TestRun_tagKey(t *testing.T) {
+	src := "type T struct{ A string `re:\"host\"` }\nconst P = `(?P<host>\\w+)`"
+	out, err := run(writePackage(t, src), "", config{typeName: "T", patternName: "P", tagKey: "re", encode: true})
+	if err != nil {
+		t.Fatalf("run(-tag re) error = %v", err)
+	}
+	if !strings.Contains(string(out), `// A, from group "host".`) {
+		t.Errorf("run(-tag re) did not map A to host:\n%s", out)
+	}
+}
@@ -0,0 +1,45 @@
+// Package accesslog is regextra-gen's worked example: an access-log line
+// type whose DecodeEntry and EncodeEntry are generated into
+// entry_regextra.go, and held to the reflective Decoder by its test.
+package accesslog
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+)
+
+//go:generate go run ../.. -type Entry -pattern entryPattern
+
+// entryPattern matches lines such as
+//
+//	10.0.0.7 [2024-03-01 12:00:00] GET /api/items 200 12ms tags=a,b user=ada
+//
+// with a status of "-" for a request that never completed.
+const entryPattern = `^(?P<client>\S+) \[(?P<time>[^\]]+)\] (?P<method>GET|POST|PUT|DELETE) (?P<path>\S+) (?:(?P<status>\d+)|-) (?P<latency>\w+)(?: tags=(?P<tags>[\w,]+))?(?: user=(?P<user>\w+))?$`
+
+// Entry is one decoded access-log line.
+type Entry struct {
+	Client  netip.Addr    `regex:"client,required"`
+	Time    time.Time     `regex:"time,layout=2006-01-02 15:04:05"`
+	Method  Method        `regex:"method"`
+	Path    string        `regex:"path"`
+	Status  int           `regex:"status"`
+	Latency time.Duration `regex:"latency"`
+	Tags    []string      `regex:"tags,split=comma,omitempty"`
+	User    *string       `regex:"user"`
+}
+
+// Method is an HTTP method, decoded case-insensitively and encoded upper-case.
+type Method string
+
+// UnmarshalRegex implements regextra.RegexUnmarshaler.
+func (m *Method) UnmarshalRegex(s string) error {
+	*m = Method(strings.ToUpper(s))
+	return nil
+}
+
+// MarshalRegex implements regextra.RegexMarshaler.
+func (m Method) MarshalRegex() (string, error) {
+	return string(m), nil
+}
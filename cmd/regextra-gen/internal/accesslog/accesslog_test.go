@@ -0,0 +1,40 @@
+package accesslog
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jecoms/regextra"
+	"github.com/jecoms/regextra/regextratest"
+)
+
+func TestEntryParity(t *testing.T) {
+	dec := regextra.MustCompile[Entry](entryPattern)
+	regextratest.AssertDecodeParity(t, dec, DecodeEntry,
+		"10.0.0.7 [2024-03-01 12:00:00] GET /api/items 200 12ms tags=a,b user=ada",
+		"10.0.0.7 [2024-03-01 12:00:00] get /api/items - 1.5s",
+		"::1 [2024-03-01 12:00:00] POST / 201 0s tags=,x,",
+		// Conversion failures: address, time, status, latency.
+		"nope [2024-03-01 12:00:00] GET / 200 1s",
+		"10.0.0.7 [yesterday] GET / 200 1s",
+		"10.0.0.7 [2024-03-01 12:00:00] GET / 99999999999999999999 1s",
+		"10.0.0.7 [2024-03-01 12:00:00] GET / 200 soon",
+		// No match.
+		"",
+		"10.0.0.7 GET /",
+	)
+
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	user := "ada"
+	when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	regextratest.AssertEncodeParity(t, enc, EncodeEntry,
+		Entry{Client: netip.MustParseAddr("10.0.0.7"), Time: when, Method: "GET", Path: "/", Status: 200, Latency: time.Second, Tags: []string{"a", "b"}, User: &user},
+		Entry{Client: netip.MustParseAddr("::1"), Time: when, Method: "DELETE", Path: "/x"},
+		// A method outside the alternation, which Encode emits as is.
+		Entry{Client: netip.MustParseAddr("10.0.0.7"), Method: "PATCH", Path: "/"},
+	)
+}
@@ -0,0 +1,288 @@
+// Code generated by regextra-gen -type Entry -pattern entryPattern; DO NOT EDIT.
+
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jecoms/regextra"
+)
+
+var _Entry_regexp = regexp.MustCompile(entryPattern)
+
+// DecodeEntry decodes the first match of entryPattern in target, as
+// regextra.MustCompile[Entry](entryPattern).One does, without reflection.
+// It returns regextra.ErrNoMatch when target does not match.
+func DecodeEntry(target string) (Entry, error) {
+	var v Entry
+	m := _Entry_regexp.FindStringSubmatchIndex(target)
+	if m == nil {
+		return v, regextra.ErrNoMatch
+	}
+	if err := _Entry_decode(&v, target, m); err != nil {
+		return v, fmt.Errorf("DecodeEntry: %w", err)
+	}
+	return v, nil
+}
+
+// _Entry_decode fills v from the match m of target, field by field.
+func _Entry_decode(v *Entry, target string, m []int) error {
+	// Client, from group "client".
+	{
+		s, found := "", false
+		start, end := m[0], m[1]
+		if m[2] >= 0 {
+			s, found = target[m[2]:m[3]], true
+			start, end = m[2], m[3]
+		}
+		if found && s != "" {
+			if err := v.Client.UnmarshalText([]byte(s)); err != nil {
+				return _Entry_decodeError("Client", "client", "netip.Addr", s, target, start, end, fmt.Errorf("cannot convert %q to netip.Addr: %w", s, err))
+			}
+		} else {
+			return &regextra.RequiredGroupError{
+				Field:  "Client",
+				Group:  "client",
+				Offset: start,
+				End:    end,
+				Line:   strings.Count(target[:start], "\n") + 1,
+				Column: start - strings.LastIndexByte(target[:start], '\n'),
+			}
+		}
+	}
+	// Time, from group "time".
+	{
+		s, found := "", false
+		start, end := m[0], m[1]
+		if m[4] >= 0 {
+			s, found = target[m[4]:m[5]], true
+			start, end = m[4], m[5]
+		}
+		if found && s != "" {
+			if t, err := time.Parse("2006-01-02 15:04:05", s); err != nil {
+				return _Entry_decodeError("Time", "time", "time.Time", s, target, start, end, fmt.Errorf("cannot convert %q to time.Time using layout %q: %w", s, "2006-01-02 15:04:05", err))
+			} else {
+				v.Time = t
+			}
+		}
+	}
+	// Method, from group "method".
+	{
+		s, found := "", false
+		start, end := m[0], m[1]
+		if m[6] >= 0 {
+			s, found = target[m[6]:m[7]], true
+			start, end = m[6], m[7]
+		}
+		if found && s != "" {
+			if err := v.Method.UnmarshalRegex(s); err != nil {
+				return _Entry_decodeError("Method", "method", "accesslog.Method", s, target, start, end, err)
+			}
+		}
+	}
+	// Path, from group "path".
+	{
+		s, found := "", false
+		if m[8] >= 0 {
+			s, found = target[m[8]:m[9]], true
+		}
+		if found && s != "" {
+			v.Path = s
+		}
+	}
+	// Status, from group "status".
+	{
+		s, found := "", false
+		start, end := m[0], m[1]
+		if m[10] >= 0 {
+			s, found = target[m[10]:m[11]], true
+			start, end = m[10], m[11]
+		}
+		if found && s != "" {
+			if n, err := strconv.ParseInt(s, 10, strconv.IntSize); err != nil {
+				return _Entry_decodeError("Status", "status", "int", s, target, start, end, fmt.Errorf("cannot convert %q to int: %w", s, err))
+			} else {
+				v.Status = int(n)
+			}
+		}
+	}
+	// Latency, from group "latency".
+	{
+		s, found := "", false
+		start, end := m[0], m[1]
+		if m[12] >= 0 {
+			s, found = target[m[12]:m[13]], true
+			start, end = m[12], m[13]
+		}
+		if found && s != "" {
+			if d, err := time.ParseDuration(s); err != nil {
+				return _Entry_decodeError("Latency", "latency", "time.Duration", s, target, start, end, fmt.Errorf("cannot convert %q to time.Duration: %w", s, err))
+			} else {
+				v.Latency = d
+			}
+		}
+	}
+	// Tags, from every occurrence of group "tags".
+	{
+		var values []string
+		if m[14] >= 0 && m[14] != m[15] {
+			values = append(values, target[m[14]:m[15]])
+		}
+		{
+			// Split each value on ",", dropping empty pieces.
+			var parts []string
+			for _, s := range values {
+				for {
+					p, rest, more := strings.Cut(s, ",")
+					if p != "" {
+						parts = append(parts, p)
+					}
+					if !more {
+						break
+					}
+					s = rest
+				}
+			}
+			values = parts
+		}
+		if len(values) > 0 {
+			out := make([]string, len(values))
+			for i, s := range values {
+				out[i] = s
+			}
+			v.Tags = out
+		}
+	}
+	// User, from group "user".
+	{
+		s, found := "", false
+		if m[16] >= 0 {
+			s, found = target[m[16]:m[17]], true
+		}
+		if found && s != "" {
+			if v.User == nil {
+				v.User = new(string)
+			}
+			*v.User = s
+		}
+	}
+	return nil
+}
+
+// EncodeEntry renders v as a string DecodeEntry decodes back into v, as the
+// Encoder of regextra.MustCompile[Entry](entryPattern) does, without reflection.
+func EncodeEntry(v Entry) (string, error) {
+	var b strings.Builder
+	if err := _Entry_encode(&v, &b); err != nil {
+		return "", fmt.Errorf("EncodeEntry: %w", err)
+	}
+	return b.String(), nil
+}
+
+// _Entry_encode writes v to b, segment by segment of the pattern.
+func _Entry_encode(v *Entry, b *strings.Builder) error {
+	// Client, into group "client".
+	{
+		var s string
+		if text, err := v.Client.MarshalText(); err != nil {
+			return &regextra.EncodeError{Field: "Client", Group: "client", Type: "netip.Addr", Err: fmt.Errorf("cannot encode netip.Addr: %w", err)}
+		} else {
+			s = string(text)
+		}
+		b.WriteString(s)
+	}
+	b.WriteString(" [")
+	// Time, into group "time".
+	{
+		var s string
+		s = v.Time.Format("2006-01-02 15:04:05")
+		b.WriteString(s)
+	}
+	b.WriteString("] ")
+	// Method, into group "method".
+	{
+		var s string
+		{
+			var err error
+			if s, err = v.Method.MarshalRegex(); err != nil {
+				return &regextra.EncodeError{Field: "Method", Group: "method", Type: "accesslog.Method", Err: err}
+			}
+		}
+		b.WriteString(s)
+	}
+	b.WriteString(" ")
+	// Path, into group "path".
+	{
+		var s string
+		s = v.Path
+		b.WriteString(s)
+	}
+	b.WriteString(" ")
+	switch {
+	case v.Status != 0:
+		// Status, into group "status".
+		{
+			var s string
+			s = strconv.FormatInt(int64(v.Status), 10)
+			b.WriteString(s)
+		}
+	default:
+		b.WriteString("-")
+	}
+	b.WriteString(" ")
+	// Latency, into group "latency".
+	{
+		var s string
+		s = v.Latency.String()
+		b.WriteString(s)
+	}
+	if len(v.Tags) != 0 {
+		b.WriteString(" tags=")
+		// Tags, into group "tags".
+		{
+			var s string
+			parts := make([]string, len(v.Tags))
+			for i := range v.Tags {
+				var s string
+				s = v.Tags[i]
+				parts[i] = s
+			}
+			s = strings.Join(parts, ",")
+			b.WriteString(s)
+		}
+	}
+	if v.User != nil {
+		b.WriteString(" user=")
+		// User, into group "user".
+		{
+			var s string
+			if v.User == nil {
+				return &regextra.EncodeError{Field: "User", Group: "user", Type: "*string", Err: errors.New("cannot encode nil pointer of type *string")}
+			}
+			s = *v.User
+			b.WriteString(s)
+		}
+	}
+	return nil
+}
+
+// _Entry_decodeError builds the DecodeError for a group that failed to
+// convert.
+func _Entry_decodeError(field, group, typ, value, target string, start, end int, err error) error {
+	return &regextra.DecodeError{
+		Field:  field,
+		Group:  group,
+		Value:  value,
+		Type:   typ,
+		Err:    err,
+		Offset: start,
+		End:    end,
+		Line:   strings.Count(target[:start], "\n") + 1,
+		Column: start - strings.LastIndexByte(target[:start], '\n'),
+	}
+}
@@ -0,0 +1,189 @@
+// Command regextra-gen generates reflection-free decode and encode functions
+// for a struct type and the regextra pattern it is decoded with.
+//
+// A [regextra.Decoder] maps fields to groups once, at Compile, but still
+// writes each match through reflect.Value.Field and setFieldValue's type
+// switch, and an [regextra.Encoder] reads fields through reflect on every
+// call. For the hottest parsers, regextra-gen resolves the same field mapping
+// at build time and emits plain Go: each group is converted with a direct
+// strconv, time, or method call and assigned to its field by name.
+//
+// Usage:
+//
+//	regextra-gen -type Entry -pattern entryPattern [-tag key] [-encode=false] [-output file] [dir]
+//
+// It is meant for go:generate, run in the package that declares the type:
+//
+//	//go:generate go run github.com/jecoms/regextra/cmd/regextra-gen -type Entry -pattern entryPattern
+//
+// -type names a struct type and -pattern a string constant, both declared at
+// package level in dir (default "."). -tag reads field configuration from
+// another struct tag key, like [regextra.TagKey]. The output, written to
+// entry_regextra.go unless -output says otherwise, declares
+//
+//	func DecodeEntry(target string) (Entry, error)
+//	func EncodeEntry(v Entry) (string, error)
+//
+// (decodeEntry and encodeEntry for an unexported type). DecodeEntry behaves as
+// regextra.MustCompile[Entry](entryPattern).One does — the same group for
+// each field, the same `default=`, `required`, `layout=`, and `split=`
+// handling, the same values, and the same [regextra.DecodeError] and
+// [regextra.RequiredGroupError] details, with the function's name as the
+// error prefix. EncodeEntry behaves as the Decoder's Encoder's Encode does.
+// Use [github.com/jecoms/regextra/regextratest] in a test to hold the two to
+// that.
+//
+// regextra-gen applies Compile's validation and fails where Compile or
+// Decoder.Encoder would. It also fails on what it does not generate, naming
+// the field or construct, so the reflective API remains the fallback:
+//
+//   - A field must be a string, bool, integer, float, time.Time,
+//     time.Duration, or a type whose pointer implements
+//     [regextra.RegexUnmarshaler] or encoding.TextUnmarshaler (and, for
+//     -encode, [regextra.RegexMarshaler] or encoding.TextMarshaler); a
+//     pointer to one; or a slice of these. Arrays, maps, and interfaces are
+//     not supported.
+//   - Embedded and `prefix` structs are flattened as the Decoder does, but
+//     not through a pointer.
+//   - A list field's group may not sit inside a `*`, `+`, or `{n,m}`
+//     repetition.
+//   - The value transform options — trim, lower, upper, unquote,
+//     trimprefix=, trimsuffix=, replace= — and the numeric format options —
+//     base=, sep=, percent, unit=, prec=, unitbase= — are not supported, nor are
+//     the epoch layouts layout=unix, unixmilli, unixmicro, and unixnano,
+//     layouts=, or tz=.
+//   - The encoder covers literals, named captures, anchors, `?` sections,
+//     and alternations, but not repetitions or list fields; pass
+//     -encode=false to generate the decoder alone.
+//
+// Generated code does not consult [regextra.Converters] or any other
+// [regextra.DecoderOption] than -tag.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeName := flag.String("type", "", "struct type to generate for (required)")
+	patternName := flag.String("pattern", "", "string constant holding the pattern (required)")
+	tagKey := flag.String("tag", "regex", "struct tag key fields are configured under")
+	encode := flag.Bool("encode", true, "generate an encode function too")
+	output := flag.String("output", "", "output file (default <type>_regextra.go)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: regextra-gen -type T -pattern P [flags] [dir]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeName == "" || *patternName == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(*typeName)+"_regextra.go")
+	}
+
+	src, err := run(dir, filepath.Base(out), config{
+		typeName:    *typeName,
+		patternName: *patternName,
+		tagKey:      *tagKey,
+		encode:      *encode,
+		command:     "regextra-gen " + strings.Join(os.Args[1:], " "),
+	})
+	if err == nil {
+		err = os.WriteFile(out, src, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "regextra-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// config is one generation request: what the flags name.
+type config struct {
+	typeName    string
+	patternName string
+	tagKey      string
+	encode      bool
+	// command is the invocation recorded in the generated file's header.
+	command string
+}
+
+// run loads the package in dir, leaving out the file named skip (the previous
+// output, which may be stale), and returns the generated source for cfg.
+func run(dir, skip string, cfg config) ([]byte, error) {
+	pkg, err := loadPackage(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+	tn, ok := pkg.Scope().Lookup(cfg.typeName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("no type %s in package %s", cfg.typeName, pkg.Name())
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a defined type", cfg.typeName)
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("%s is not a struct type", cfg.typeName)
+	}
+	c, ok := pkg.Scope().Lookup(cfg.patternName).(*types.Const)
+	if !ok || c.Val().Kind() != constant.String {
+		return nil, fmt.Errorf("no string constant %s in package %s", cfg.patternName, pkg.Name())
+	}
+	return generate(pkg, named, constant.StringVal(c.Val()), cfg)
+}
+
+// loadPackage parses and type-checks the non-test Go files of the package in
+// dir. Type errors are tolerated: the package may refer to functions a
+// previous run generated into the skipped file.
+func loadPackage(dir, skip string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		if name == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(bp.ImportPath, fset, files, nil)
+	return pkg, nil
+}
+
+// printer accumulates generated source.
+type printer struct {
+	bytes.Buffer
+}
+
+func (p *printer) printf(format string, args ...any) {
+	fmt.Fprintf(&p.Buffer, format, args...)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePackage writes src as the only file of a package in a fresh directory
+// and returns the directory.
+func writePackage(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte("package p\n\n"+src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// runError runs the generator on src for type T and pattern P and returns its
+// error message, failing the test when generation succeeds.
+func runError(t *testing.T, src string, encode bool) string {
+	t.Helper()
+	_, err := run(writePackage(t, src), "", config{typeName: "T", patternName: "P", tagKey: "regex", encode: encode})
+	if err == nil {
+		t.Fatalf("run() succeeded, want an error")
+	}
+	return err.Error()
+}
+
+// The committed example output must be what the generator produces now.
+func TestRun_golden(t *testing.T) {
+	dir := filepath.Join("internal", "accesslog")
+	want, err := os.ReadFile(filepath.Join(dir, "entry_regextra.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := run(dir, "entry_regextra.go", config{
+		typeName:    "Entry",
+		patternName: "entryPattern",
+		tagKey:      "regex",
+		encode:      true,
+		command:     "regextra-gen -type Entry -pattern entryPattern",
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("entry_regextra.go is stale; run go generate in %s", dir)
+	}
+}
+
+func TestRun_lookupErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"no type", `const P = "x"`, "no type T in package p"},
+		{"alias", "type T = struct{}\nconst P = `x`", "T is not a defined type"},
+		{"not a struct", "type T int\nconst P = `x`", "T is not a struct type"},
+		{"no constant", "type T struct{}\nvar P = `x`", "no string constant P in package p"},
+		{"bad pattern", "type T struct{}\nconst P = `(`", "pattern P: error parsing regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runError(t, tt.src, true); !strings.Contains(got, tt.want) {
+				t.Errorf("run() error = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
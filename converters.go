@@ -0,0 +1,235 @@
+package regextra
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// DecodeFunc converts a captured value into a value of the type it is
+// registered for (see [Converters]). opts holds the field's parsed
+// `key=value` tag options, such as `layout`; it is nil when the field has
+// none and must not be modified. A nil result sets the field's zero value.
+type DecodeFunc func(value string, opts map[string]string) (any, error)
+
+// EncodeFunc renders a value of the type it is registered for into the string
+// a group captures — the inverse of its [DecodeFunc], so an Encode then decode
+// round-trips. v holds a value of exactly that type; opts is as for
+// DecodeFunc.
+type EncodeFunc func(v any, opts map[string]string) (string, error)
+
+// Converters is a registry of conversion functions keyed by field type: the
+// extension point for types you cannot add [RegexUnmarshaler] /
+// [RegexMarshaler] methods to, such as a decimal type or a generated enum from
+// another module.
+//
+// A registered converter is the first thing the decode and encode paths try
+// for a field of exactly that type — before a pointer is dereferenced, before
+// the type's own RegexUnmarshaler or encoding.TextUnmarshaler methods, and
+// before the built-in time and kind conversions. Registering *T is separate
+// from registering T: a *T field with only T registered has its pointer
+// allocated (or dereferenced, on encode) and then converts through T's entry.
+// A slice or array type with a converter is one value, not a list of
+// elements; register the element type to convert elements.
+//
+// Two registries are consulted, in order: the one a Decoder was compiled with
+// through [UseConverters], then [DefaultConverters]. Register types before
+// compiling the Decoders that use them: whether a field is a list and which
+// converter decodes it are decided when the Decoder is compiled, and whether
+// [Decoder.Encoder] accepts its type and which converter encodes it when the
+// Encoder is derived.
+//
+// A Converters is safe for concurrent use; lookups take no lock. The zero
+// value is an empty registry ready to use.
+type Converters struct {
+	mu sync.Mutex // serializes Register
+	m  atomic.Pointer[map[reflect.Type]converter]
+}
+
+// converter is one registered type's pair of conversion functions; either may
+// be nil.
+type converter struct {
+	decode DecodeFunc
+	encode EncodeFunc
+}
+
+// DefaultConverters is the package-level registry, consulted after a Decoder's
+// own ([UseConverters]) by every decode and encode entrypoint, [Unmarshal] and
+// [UnmarshalAll] included. Register into it from an init function to make a
+// type decodable everywhere.
+var DefaultConverters = NewConverters()
+
+// NewConverters returns an empty registry.
+func NewConverters() *Converters {
+	return &Converters{}
+}
+
+// Register sets the conversion functions for fields of type t, replacing any
+// earlier registration of t. Either function may be nil: a type with no
+// encode function cannot be encoded unless it has another string form, and
+// one with no decode function falls through to the other conversions when
+// decoding. Register panics if t is nil.
+func (c *Converters) Register(t reflect.Type, decode DecodeFunc, encode EncodeFunc) {
+	if t == nil {
+		panic("regextra: Converters.Register of nil type")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var next map[reflect.Type]converter
+	if cur := c.m.Load(); cur != nil {
+		next = maps.Clone(*cur)
+	} else {
+		next = make(map[reflect.Type]converter, 1)
+	}
+	next[t] = converter{decode: decode, encode: encode}
+	c.m.Store(&next)
+}
+
+// RegisterConverter is the typed form of [Converters.Register]: it registers
+// decode and encode for fields of type T, so neither function has to assert
+// its argument or box its result. Either may be nil.
+//
+//	regextra.RegisterConverter(regextra.DefaultConverters,
+//	    func(s string, _ map[string]string) (decimal.Decimal, error) { return decimal.NewFromString(s) },
+//	    func(d decimal.Decimal, _ map[string]string) (string, error) { return d.String(), nil },
+//	)
+func RegisterConverter[T any](c *Converters, decode func(string, map[string]string) (T, error), encode func(T, map[string]string) (string, error)) {
+	var dec DecodeFunc
+	if decode != nil {
+		dec = func(value string, opts map[string]string) (any, error) {
+			return decode(value, opts)
+		}
+	}
+	var enc EncodeFunc
+	if encode != nil {
+		enc = func(v any, opts map[string]string) (string, error) {
+			return encode(v.(T), opts)
+		}
+	}
+	c.Register(reflect.TypeFor[T](), dec, enc)
+}
+
+// lookup returns t's registration in c. A nil c holds nothing.
+func (c *Converters) lookup(t reflect.Type) (converter, bool) {
+	if c == nil {
+		return converter{}, false
+	}
+	m := c.m.Load()
+	if m == nil {
+		return converter{}, false
+	}
+	conv, ok := (*m)[t]
+	return conv, ok
+}
+
+// UseConverters makes a Decoder, and the [Encoder] derived from it, consult
+// c before [DefaultConverters]. A nil c consults only the default registry.
+func UseConverters(c *Converters) DecoderOption {
+	return func(cfg *DecoderConfig) { cfg.Converters = c }
+}
+
+// decodeFunc returns the decode function registered for t, looking in
+// cfg.Converters and then DefaultConverters, or nil.
+func (cfg *DecoderConfig) decodeFunc(t reflect.Type) DecodeFunc {
+	if conv, ok := cfg.Converters.lookup(t); ok && conv.decode != nil {
+		return conv.decode
+	}
+	if conv, ok := DefaultConverters.lookup(t); ok {
+		return conv.decode
+	}
+	return nil
+}
+
+// fieldConverter is the registered decode function a field's values convert
+// through, resolved once when the field's decode plan entry is built: decode
+// applies once derefs levels of pointer have been allocated. The zero value
+// means no level of the type has one.
+type fieldConverter struct {
+	decode DecodeFunc
+	derefs int
+}
+
+// resolveConverter returns the fieldConverter for values of type t: t's own
+// decode function, or else, for a pointer type, its pointee's, following the
+// dereferencing setFieldValue does.
+func (cfg *DecoderConfig) resolveConverter(t reflect.Type) fieldConverter {
+	for derefs := 0; ; derefs++ {
+		if decode := cfg.decodeFunc(t); decode != nil {
+			return fieldConverter{decode: decode, derefs: derefs}
+		}
+		if t.Kind() != reflect.Ptr {
+			return fieldConverter{}
+		}
+		t = t.Elem()
+	}
+}
+
+// encodeConverter is fieldConverter's encode-side mirror: the registered
+// encode function a field's values render through, resolved once when the
+// Encoder is derived. encode applies once derefs levels of pointer have been
+// dereferenced; with list set the field is a `split=` list and encode applies
+// to each element.
+type encodeConverter struct {
+	encode EncodeFunc
+	derefs int
+	list   bool
+}
+
+// resolveEncodeConverter returns the encodeConverter for a field of type t
+// with tag options opts: as resolveConverter does for decoding, but on the
+// element type of a `split=` list.
+func (cfg *DecoderConfig) resolveEncodeConverter(t reflect.Type, opts map[string]string) encodeConverter {
+	var conv encodeConverter
+	if _, split := splitSeparator(opts); split && isCollectionType(t, cfg) {
+		conv.list = true
+		t = t.Elem()
+	}
+	for ; ; conv.derefs++ {
+		if conv.encode = cfg.encodeFunc(t); conv.encode != nil || t.Kind() != reflect.Ptr {
+			return conv
+		}
+		t = t.Elem()
+	}
+}
+
+// encodeFunc is decodeFunc's encode-side mirror.
+func (cfg *DecoderConfig) encodeFunc(t reflect.Type) EncodeFunc {
+	if conv, ok := cfg.Converters.lookup(t); ok && conv.encode != nil {
+		return conv.encode
+	}
+	if conv, ok := DefaultConverters.lookup(t); ok {
+		return conv.encode
+	}
+	return nil
+}
+
+// registered reports whether t has a converter in either registry, in which
+// case it converts as a single value.
+func (cfg *DecoderConfig) registered(t reflect.Type) bool {
+	if _, ok := cfg.Converters.lookup(t); ok {
+		return true
+	}
+	_, ok := DefaultConverters.lookup(t)
+	return ok
+}
+
+// setConverted sets field from value through a registered decode function,
+// checking that its result has the field's type.
+func setConverted(field reflect.Value, value string, opts map[string]string, decode DecodeFunc) error {
+	v, err := decode(value, opts)
+	if err != nil {
+		return fmt.Errorf("cannot convert %q to %s: %w", value, field.Type(), err)
+	}
+	if v == nil {
+		field.SetZero()
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("cannot convert %q to %s: converter returned %T", value, field.Type(), v)
+	}
+	field.Set(rv)
+	return nil
+}
@@ -0,0 +1,255 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	rx "github.com/jecoms/regextra"
+)
+
+// money stands in for a type from another module: no methods of ours, and
+// unexported fields the built-in conversions cannot reach.
+type money struct {
+	cents int64
+}
+
+func parseMoney(s string, _ map[string]string) (money, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return money{}, err
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || len(frac) != 2 {
+		return money{}, fmt.Errorf("bad cents %q", frac)
+	}
+	return money{w*100 + f}, nil
+}
+
+func formatMoney(m money, _ map[string]string) (string, error) {
+	return fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100), nil
+}
+
+func moneyConverters() *rx.Converters {
+	c := rx.NewConverters()
+	rx.RegisterConverter(c, parseMoney, formatMoney)
+	return c
+}
+
+func TestConverters_decodeAndEncode(t *testing.T) {
+	type order struct {
+		Total money   `regex:"total"`
+		Tip   *money  `regex:"tip"`
+		Items []money `regex:"item"`
+		Note  string  `regex:"note"`
+	}
+	const pattern = `total=(?P<total>[\d.]+) tip=(?P<tip>[\d.]+) items=(?P<item>[\d.]+)(?:,(?P<item>[\d.]+))* (?P<note>\w+)`
+	convs := moneyConverters()
+	dec := rx.MustCompileWith[order](pattern, rx.UseConverters(convs))
+	const line = "total=12.50 tip=2.00 items=4.25,8.25 thanks"
+	got, err := dec.One(line)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	want := order{Total: money{1250}, Tip: &money{200}, Items: []money{{425}, {825}}, Note: "thanks"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("One() = %+v, want %+v", got, want)
+	}
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	if s, err := enc.Encode(got); err != nil || s != line {
+		t.Errorf("Encode() = %q, %v; want %q", s, err, line)
+	}
+
+	// Like decoding, the Encoder resolves its encode functions when it is
+	// derived, so a later registration does not change it.
+	rx.RegisterConverter(convs, parseMoney, func(money, map[string]string) (string, error) { return "x", nil })
+	if s, err := enc.Encode(got); err != nil || s != line {
+		t.Errorf("Encode() after Register = %q, %v; want %q", s, err, line)
+	}
+
+	// A split= list encodes each element through the converter.
+	type bill struct {
+		Items []money `regex:"items,split=;"`
+	}
+	billEnc, err := rx.MustCompileWith[bill](`(?P<items>\S+)`, rx.UseConverters(moneyConverters())).Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	if s, err := billEnc.Encode(bill{[]money{{1}, {250}}}); err != nil || s != "0.01;2.50" {
+		t.Errorf("Encode(split list) = %q, %v", s, err)
+	}
+
+	// Without the registry the type has no conversion.
+	if _, err := rx.MustCompile[order](pattern).One(line); err == nil {
+		t.Error("Compile One() without converters succeeded, want an unsupported-type error")
+	}
+}
+
+// textEnum has its own UnmarshalText; a registered converter overrides it.
+type textEnum string
+
+func (e *textEnum) UnmarshalText(b []byte) error {
+	*e = textEnum("text:" + string(b))
+	return nil
+}
+
+func TestConverters_precedeTypeMethods(t *testing.T) {
+	type rec struct {
+		Kind textEnum `regex:"kind"`
+	}
+	c := rx.NewConverters()
+	rx.RegisterConverter(c, func(s string, _ map[string]string) (textEnum, error) {
+		return textEnum("conv:" + s), nil
+	}, nil)
+	got, err := rx.MustCompileWith[rec](`(?P<kind>\w+)`, rx.UseConverters(c)).One("a")
+	if err != nil || got.Kind != "conv:a" {
+		t.Errorf("One() = %+v, %v; want the converter's conv:a", got, err)
+	}
+	got, _ = rx.MustCompile[rec](`(?P<kind>\w+)`).One("a")
+	if got.Kind != "text:a" {
+		t.Errorf("Compile One() = %+v, want UnmarshalText's text:a", got)
+	}
+}
+
+// defaultOnly and shadowed are registered in DefaultConverters; no other test
+// uses them.
+type (
+	defaultOnly struct{ s string }
+	shadowed    struct{ s string }
+)
+
+func TestConverters_defaultRegistry(t *testing.T) {
+	rx.RegisterConverter(rx.DefaultConverters, func(s string, _ map[string]string) (defaultOnly, error) {
+		return defaultOnly{"default:" + s}, nil
+	}, nil)
+	rx.RegisterConverter(rx.DefaultConverters, func(s string, _ map[string]string) (shadowed, error) {
+		return shadowed{"default:" + s}, nil
+	}, nil)
+	type rec struct {
+		A defaultOnly `regex:"a"`
+		B shadowed    `regex:"b"`
+	}
+	re := regexp.MustCompile(`(?P<a>\w+) (?P<b>\w+)`)
+	var v rec
+	if err := rx.Unmarshal(re, "x y", &v); err != nil || v.A.s != "default:x" || v.B.s != "default:y" {
+		t.Errorf("Unmarshal() = %+v, %v; want both from the default registry", v, err)
+	}
+	// A Decoder's own registry is consulted first.
+	own := rx.NewConverters()
+	rx.RegisterConverter(own, func(s string, _ map[string]string) (shadowed, error) {
+		return shadowed{"own:" + s}, nil
+	}, nil)
+	got, err := rx.MustCompileWith[rec](re.String(), rx.UseConverters(own)).One("x y")
+	if err != nil || got.A.s != "default:x" || got.B.s != "own:y" {
+		t.Errorf("One() = %+v, %v; want A from the default registry and B from the Decoder's", got, err)
+	}
+
+	// The converter is resolved at Compile, so a later registration reaches
+	// only Decoders compiled after it.
+	late := rx.NewConverters()
+	dec := rx.MustCompileWith[rec](re.String(), rx.UseConverters(late))
+	rx.RegisterConverter(late, func(s string, _ map[string]string) (shadowed, error) {
+		return shadowed{"late:" + s}, nil
+	}, nil)
+	if got, err := dec.One("x y"); err != nil || got.B.s != "default:y" {
+		t.Errorf("One() = %+v, %v; want B from the registries as they were at Compile", got, err)
+	}
+	if got, err := rx.MustCompileWith[rec](re.String(), rx.UseConverters(late)).One("x y"); err != nil || got.B.s != "late:y" {
+		t.Errorf("One() after Register = %+v, %v; want B from the new registration", got, err)
+	}
+}
+
+func TestConverters_errors(t *testing.T) {
+	type rec struct {
+		Price money `regex:"price"`
+	}
+	dec := rx.MustCompileWith[rec](`(?P<price>\S+)`, rx.UseConverters(moneyConverters()))
+	_, err := dec.One("4.5")
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Field != "Price" || !strings.Contains(err.Error(), `bad cents "5"`) {
+		t.Errorf("One(bad) error = %v, want a DecodeError carrying the converter's error", err)
+	}
+
+	// A converter returning the wrong type is a DecodeError, not a panic.
+	wrong := rx.NewConverters()
+	wrong.Register(reflect.TypeFor[money](), func(string, map[string]string) (any, error) { return 7, nil }, nil)
+	if _, err := rx.MustCompileWith[rec](`(?P<price>\S+)`, rx.UseConverters(wrong)).One("1.00"); !errors.As(err, &de) {
+		t.Errorf("One(wrong type) error = %v, want a DecodeError", err)
+	}
+
+	// A decode-only registration leaves the type unencodable.
+	decodeOnly := rx.NewConverters()
+	rx.RegisterConverter(decodeOnly, parseMoney, nil)
+	if _, err := rx.MustCompileWith[rec](`(?P<price>\S+)`, rx.UseConverters(decodeOnly)).Encoder(); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("Encoder() with a decode-only converter error = %v, want ErrInvalidStruct", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register(nil) did not panic")
+		}
+	}()
+	rx.NewConverters().Register(nil, nil, nil)
+}
+
+// A registered slice type converts as one value rather than collecting elements.
+func TestConverters_registeredSliceIsOneValue(t *testing.T) {
+	type csv []string
+	type rec struct {
+		Fields csv `regex:"f"`
+	}
+	c := rx.NewConverters()
+	rx.RegisterConverter(c, func(s string, _ map[string]string) (csv, error) {
+		return strings.Split(s, ","), nil
+	}, func(v csv, _ map[string]string) (string, error) {
+		return strings.Join(v, ","), nil
+	})
+	dec := rx.MustCompileWith[rec](`(?P<f>\S+)`, rx.UseConverters(c))
+	got, err := dec.One("a,b,c")
+	if err != nil || !reflect.DeepEqual(got.Fields, csv{"a", "b", "c"}) {
+		t.Errorf("One() = %+v, %v; want [a b c]", got, err)
+	}
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	if s, err := enc.Encode(got); err != nil || s != "a,b,c" {
+		t.Errorf("Encode() = %q, %v; want a,b,c", s, err)
+	}
+}
+
+func ExampleRegisterConverter() {
+	// Celsius comes from a package we cannot add methods to.
+	type Celsius struct{ Degrees float64 }
+	convs := rx.NewConverters()
+	rx.RegisterConverter(convs,
+		func(s string, _ map[string]string) (Celsius, error) {
+			f, err := strconv.ParseFloat(strings.TrimSuffix(s, "C"), 64)
+			return Celsius{f}, err
+		},
+		func(c Celsius, _ map[string]string) (string, error) {
+			return strconv.FormatFloat(c.Degrees, 'f', -1, 64) + "C", nil
+		},
+	)
+	type Reading struct {
+		Sensor string
+		Temp   Celsius
+	}
+	dec := rx.MustCompileWith[Reading](`(?P<sensor>\w+)=(?P<temp>-?[\d.]+C)`, rx.UseConverters(convs))
+	r, _ := dec.One("kitchen=21.5C")
+	fmt.Println(r.Sensor, r.Temp.Degrees)
+	enc, _ := dec.Encoder()
+	s, _ := enc.Encode(Reading{"porch", Celsius{-3}})
+	fmt.Println(s)
+	// Output:
+	// kitchen 21.5
+	// porch=-3C
+}
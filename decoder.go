@@ -1,12 +1,20 @@
 package regextra
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"reflect"
 	"regexp"
+	"regexp/syntax"
+	"slices"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 )
 
 // ErrNoMatch is returned by [Decoder.One] when the target string does not
@@ -19,11 +27,14 @@ var ErrNoMatch = errors.New("regextra: no match")
 // errors.Is rather than parsing the message. ErrInvalidPattern wraps a bad
 // regular expression; ErrInvalidStruct wraps every destination-shape problem
 // (T is not a struct, a field references an undeclared group, a `default=`
-// value does not convert, or `layout=` sits on a non-time.Time field). Each
-// wrapped error keeps its descriptive detail — and, where one exists, the
-// underlying cause — reachable via errors.Is/As. Like ErrNoMatch, these
-// sentinels carry the bare `regextra:` prefix reserved for package-level
-// sentinels.
+// value does not convert, `layout=` or `tz=` sits on a non-time.Time field,
+// `prefix` sits on a non-struct field, `split=` on a non-slice field, a group
+// declared `%{NAME:group:type}` under [UsePatterns] decodes into a field of
+// another type, or, under [StrictTypes], a group can match a character its
+// field's type rejects). Each wrapped error keeps its descriptive detail —
+// and, where one exists, the underlying cause — reachable via errors.Is/As.
+// Like ErrNoMatch, these sentinels carry the bare `regextra:` prefix reserved
+// for package-level sentinels.
 var (
 	ErrInvalidPattern = errors.New("regextra: invalid pattern")
 	ErrInvalidStruct  = errors.New("regextra: invalid struct")
@@ -47,20 +58,41 @@ type Decoder[T any] struct {
 	pattern string
 	re      *regexp.Regexp
 
+	// matcher is the regexp every decode entrypoint matches with: re itself,
+	// unless a slice or array field's group sits inside a repetition, in which
+	// case it is re's pattern with that repetition wrapped in an extra capture
+	// group (see expandRepeats), or the FullMatch or LongestMatch option is set
+	// (see buildMatcher). The fields' group indexes refer to matcher.
+	matcher *regexp.Regexp
+
 	// fields is the precomputed decode plan, one entry per exported struct
 	// field that maps to a regex group. Unmapped or unexported fields are
 	// not represented.
 	fields []fieldDecoder
 
+	// cfg holds the [DecoderOption]s the Decoder was compiled with.
+	cfg DecoderConfig
+
 	// zero is a cached reflect.Value of T's zero value, used by One when no
 	// match is found.
 	zero T
+
+	// enc and encErr hold the result of deriving the Decoder's Encoder for
+	// ReplaceAll, computed once under encOnce.
+	encOnce sync.Once
+	enc     *Encoder[T]
+	encErr  error
 }
 
-// fieldDecoder is the precomputed decode plan for one struct field.
+// fieldDecoder is the precomputed decode plan for one struct field. Unmarshal
+// builds a plan per call, so the state only some fields need lives behind
+// extra, keeping the common entry small.
 type fieldDecoder struct {
-	// fieldIndex is the index into T's struct fields (StructField.Index[0]).
-	fieldIndex int
+	// field is the field's index in T (StructField.Index[0]) when it is a
+	// top-level field; otherwise its path is extra.index.
+	field int
+	// extra holds the field's less common state, or is nil when it has none.
+	extra *fieldExtra
 	// groupIndexes holds the submatch index of every occurrence of the
 	// field's group name, in declaration order. Go's regexp allows the same
 	// group name to appear more than once in a pattern (e.g. across
@@ -77,6 +109,56 @@ type fieldDecoder struct {
 	// an empty span with no default) fails decode with a *RequiredGroupError
 	// instead of being skipped.
 	required bool
+	// collect is set for a slice or array field (see isCollectionType): every
+	// participating occurrence of the group contributes an element, rather
+	// than the last one winning.
+	collect bool
+}
+
+// fieldExtra is the part of a fieldDecoder that a plain top-level field does
+// not need.
+type fieldExtra struct {
+	// index is the field's index path from T (StructField.Index semantics)
+	// when it is reached through an embedded or `prefix`-flattened struct, or
+	// nil for a top-level field.
+	index []int
+	// transforms holds the tag's value transforms in tag order, applied to
+	// matched text before conversion. Nil if the tag has none.
+	transforms []valueTransform
+	// repeats parallels groupIndexes for a collect field: repeats[i] is the
+	// repetition holding groupIndexes[i], whose every iteration contributes an
+	// element, or nil for an occurrence outside one. Nil when no occurrence is
+	// repeated.
+	repeats []*decodeRepeat
+	// conv is the registered converter for the field's type, or for its
+	// element type when collect is set, resolved when the plan is built so a
+	// decode does no registry lookup.
+	conv fieldConverter
+}
+
+// path returns the field's index path from T.
+func (fd *fieldDecoder) path() []int {
+	if fd.extra != nil && fd.extra.index != nil {
+		return fd.extra.index
+	}
+	return []int{fd.field}
+}
+
+// value returns the field fd decodes into within rv, allocating nil embedded
+// or `prefix` struct pointers on the way (see fieldByIndex).
+func (fd *fieldDecoder) value(rv reflect.Value) reflect.Value {
+	if fd.extra != nil && fd.extra.index != nil {
+		return fieldByIndex(rv, fd.extra.index)
+	}
+	return rv.Field(fd.field)
+}
+
+// converter returns the registered converter fd's values decode through.
+func (fd *fieldDecoder) converter() fieldConverter {
+	if fd.extra != nil {
+		return fd.extra.conv
+	}
+	return fieldConverter{}
 }
 
 // Compile parses pattern and validates T's struct tags against it.
@@ -86,13 +168,28 @@ type fieldDecoder struct {
 //   - T is not a struct type
 //   - A field's `regex:"name"` tag references a group not declared on pattern
 //   - A field's `regex:",default=<value>"` cannot be converted to the field's type
-//   - A field uses `regex:",layout=..."` on a non-time.Time field
+//   - A field uses `regex:",layout=..."`, `regex:",layouts=..."`, or
+//     `regex:",tz=..."` on a non-time.Time field, uses layout= and layouts=
+//     together or layouts= with an empty layout, or names a zone the time
+//     zone database does not know
+//   - A field uses `regex:",prefix"` on a field that is not a struct
+//   - A field uses `regex:",split=..."` on a field that is not a slice or array
+//   - A field uses `regex:",base=..."` on a field that is not an integer, or
+//     names a base other than 0 or 2 through 36
+//   - A field uses `regex:",sep=..."` on a field that is not an integer or
+//     float, or `regex:",percent"` on a field that is not a float
+//   - A field uses `regex:",unit=..."` on a field that is not an integer or
+//     float, with a unit other than bytes or si, or alongside base= or
+//     percent; or uses prec= or unitbase= without it, or with a bad value
+//
+// Fields of untagged embedded structs and of `prefix`-flagged struct fields are
+// validated exactly like top-level fields, under their prefixed group names.
 //
 // Once Compile returns nil, the resulting Decoder is fully validated and
 // guaranteed not to produce tag-related errors at decode time.
 //
 // Failures are categorized by wrapped sentinel: the first cause above wraps
-// [ErrInvalidPattern] and the remaining four wrap [ErrInvalidStruct], so
+// [ErrInvalidPattern] and every other failure wraps [ErrInvalidStruct], so
 // callers can branch on the failure kind with errors.Is instead of parsing the
 // message. [MustCompile] panics with the same wrapped error.
 func Compile[T any](pattern string) (*Decoder[T], error) {
@@ -100,7 +197,183 @@ func Compile[T any](pattern string) (*Decoder[T], error) {
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
 	}
-	return compileDecoder[T](pattern, re)
+	return compileDecoder[T](pattern, re, newDecoderConfig(nil), nil)
+}
+
+// DecoderOption configures how a [Decoder] built by [CompileWith] matches and
+// decodes, or how a single [UnmarshalWith] call does.
+type DecoderOption func(*DecoderConfig)
+
+// DecoderConfig is the effective configuration of a [Decoder]: the defaults
+// overlaid with the [DecoderOption]s it was compiled with. [Decoder.Config]
+// returns it, for logging; it prints readably with %+v.
+type DecoderConfig struct {
+	// TagKey is the struct tag key fields are configured under, "regex" by
+	// default ([TagKey]).
+	TagKey string
+	// Location is the time zone a time.Time value without its own offset is
+	// read in; nil means UTC ([DefaultLocation]).
+	Location *time.Location
+	// InferYear fills in the year of a time.Time value read with a layout
+	// that has none ([InferYear]).
+	InferYear bool
+	// YearReference is the instant InferYear places such a value nearest;
+	// the zero Time means the time of each decode ([InferYear]).
+	YearReference time.Time
+	// FullMatch requires a match to span the whole target ([FullMatch]).
+	FullMatch bool
+	// LongestMatch selects leftmost-longest matching ([LongestMatch]).
+	LongestMatch bool
+	// Lenient skips the struct-tag validation Compile performs ([Lenient]).
+	Lenient bool
+	// AggregateErrors reports every failing field of a match
+	// ([AggregateErrors]).
+	AggregateErrors bool
+	// Converters is consulted before [DefaultConverters]; nil means only the
+	// default registry ([UseConverters]).
+	Converters *Converters
+	// StrictTypes rejects a group that can match characters its field's type
+	// never accepts ([StrictTypes]).
+	StrictTypes bool
+	// Patterns expanded the pattern's %{NAME} references; nil means the
+	// pattern was compiled as written ([UsePatterns]).
+	Patterns *Patterns
+	// ZeroCopy decodes string values from the []byte methods without
+	// copying them out of the buffer ([ZeroCopy]).
+	ZeroCopy bool
+}
+
+// newDecoderConfig returns the defaults overlaid with opts.
+func newDecoderConfig(opts []DecoderOption) DecoderConfig {
+	cfg := DecoderConfig{TagKey: "regex"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// AggregateErrors makes a decode report every failing field of a match instead
+// of stopping at the first: each field still decodes, and the failures come
+// back together as a [*DecodeErrors] in field order, so a line with three bad
+// values is diagnosed in one pass. Fields that decode cleanly are set as
+// usual. A match with a single failure still returns a *DecodeErrors, holding
+// one element.
+func AggregateErrors() DecoderOption {
+	return func(c *DecoderConfig) { c.AggregateErrors = true }
+}
+
+// TagKey reads field configuration from the struct tag key instead of
+// "regex" — for example to share a struct with another regex-driven decoder,
+// or to keep two configurations of one struct side by side. The tag grammar
+// is unchanged. An empty key restores "regex". A [Decoder.Encoder] derived
+// from the Decoder reads the same key.
+func TagKey(key string) DecoderOption {
+	return func(c *DecoderConfig) {
+		if key == "" {
+			key = "regex"
+		}
+		c.TagKey = key
+	}
+}
+
+// DefaultLocation reads a time.Time value that carries no zone offset — a
+// `2006-01-02 15:04:05` timestamp, say — as local time in loc rather than in
+// UTC, as [time.ParseInLocation] does. A value with an explicit offset keeps
+// it, and an epoch timestamp (`layout=unix` and kin) is shown in loc. The
+// [Encoder] derived from the Decoder writes time.Time values in loc, so they
+// read back as the same instant. A nil loc restores UTC.
+func DefaultLocation(loc *time.Location) DecoderOption {
+	return func(c *DecoderConfig) { c.Location = loc }
+}
+
+// InferYear fills in the year of a time.Time value read with a `layout=` or
+// `layouts=` layout that has a date but no year, such as syslog's
+// "Jan _2 15:04:05", which would otherwise decode into year 0. The year is
+// the one that puts the value nearest ref — ref's own, or across a new year
+// the one before or after — so "Dec 31 23:59:59" read on January 1 is last
+// year's. A zero ref means the time of each decode; pass a fixed one to
+// replay old logs.
+func InferYear(ref time.Time) DecoderOption {
+	return func(c *DecoderConfig) {
+		c.InferYear = true
+		c.YearReference = ref
+	}
+}
+
+// FullMatch requires the pattern to match the whole target rather than any
+// substring of it, as if it were wrapped in `\A(?:...)\z`, without the
+// caller rewriting the pattern. With [Decoder.All] and [Decoder.Iter] at most
+// one match, the whole target, is found.
+func FullMatch() DecoderOption {
+	return func(c *DecoderConfig) { c.FullMatch = true }
+}
+
+// LongestMatch selects leftmost-longest matching, like
+// [regexp.Regexp.Longest]: among the matches starting at the leftmost
+// position, the longest is chosen rather than the one Perl's leftmost-first
+// rules prefer. The Decoder matches with its own copy of the compiled
+// pattern, so the regexp [Decoder.Regexp] returns is not affected.
+func LongestMatch() DecoderOption {
+	return func(c *DecoderConfig) { c.LongestMatch = true }
+}
+
+// Lenient compiles a Decoder with the best-effort validation [Unmarshal] uses
+// instead of [Compile]'s strict checks: a field whose group is not declared is
+// skipped, a stray `layout=`, `split=`, or `prefix` is ignored, and a
+// `default=` that does not convert fails at decode time, as a [DecodeError],
+// rather than at Compile. T must still be a struct. It suits patterns that
+// are assembled at run time, where a struct describes more groups than any
+// one pattern declares.
+func Lenient() DecoderOption {
+	return func(c *DecoderConfig) { c.Lenient = true }
+}
+
+// StrictTypes makes Compile check each group against the type of the field it
+// decodes into, failing with [ErrInvalidStruct] when the group's sub-pattern
+// can match a character no value of that type contains: `(?P<age>\w+)` bound
+// to an int field, or `(?P<n>[\d.]+)` bound to a uint. The check reads the
+// characters each capture's syntax tree admits — literals, classes, and `.` —
+// not the order they come in, so it catches a sub-pattern that is wrong for
+// the type and passes one that is merely loose; `[-\d]+` into an int passes
+// even though "1-" does not convert.
+//
+// Numeric, bool, and time.Duration fields are checked, and the elements of a
+// slice or array of them (with a `split=` separator allowed alongside). A
+// field whose type converts itself — time.Time, a [RegexUnmarshaler] or
+// encoding.TextUnmarshaler, or a type with a registered converter — and a
+// string field accept any text and are not checked. Like the rest of
+// Compile's validation, the check is skipped under [Lenient].
+func StrictTypes() DecoderOption {
+	return func(c *DecoderConfig) { c.StrictTypes = true }
+}
+
+// CompileWith is like [Compile] but applies opts to the resulting Decoder.
+// With no options it is exactly Compile. The options in effect are reported
+// by [Decoder.Config].
+func CompileWith[T any](pattern string, opts ...DecoderOption) (*Decoder[T], error) {
+	cfg := newDecoderConfig(opts)
+	var types map[string]string
+	if cfg.Patterns != nil {
+		expanded, t, err := cfg.Patterns.expand(pattern)
+		if err != nil {
+			return nil, err
+		}
+		pattern, types = expanded, t
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+	return compileDecoder[T](pattern, re, cfg, types)
+}
+
+// MustCompileWith is like [CompileWith] but panics on error.
+func MustCompileWith[T any](pattern string, opts ...DecoderOption) *Decoder[T] {
+	d, err := CompileWith[T](pattern, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return d
 }
 
 // MustCompile is like [Compile] but panics on error. Intended for
@@ -115,7 +388,10 @@ func MustCompile[T any](pattern string) *Decoder[T] {
 	return d
 }
 
-func compileDecoder[T any](pattern string, re *regexp.Regexp) (*Decoder[T], error) {
+// compileDecoder builds the Decoder for re, compiled from pattern. types maps
+// each group a [Patterns] reference declared as int or float to that type, for
+// checkTypeHints.
+func compileDecoder[T any](pattern string, re *regexp.Regexp, cfg DecoderConfig, types map[string]string) (*Decoder[T], error) {
 	var zero T
 	rt := reflect.TypeOf(zero)
 	if rt == nil || rt.Kind() != reflect.Struct {
@@ -123,16 +399,25 @@ func compileDecoder[T any](pattern string, re *regexp.Regexp) (*Decoder[T], erro
 	}
 
 	// strict=true: the Decoder validates eagerly so a successful Compile
-	// guarantees no tag-related decode errors later.
-	fields, err := buildDecodePlan(rt, re, true)
+	// guarantees no tag-related decode errors later — unless the caller
+	// opted out with Lenient.
+	fields, err := buildDecodePlan(rt, re, !cfg.Lenient, &cfg)
 	if err != nil {
 		return nil, err
 	}
+	if !cfg.Lenient {
+		if err := checkTypeHints(rt, re, fields, types, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	matcher, fields := buildMatcher(re, pattern, fields, &cfg)
 
 	return &Decoder[T]{
 		pattern: pattern,
 		re:      re,
+		matcher: matcher,
 		fields:  fields,
+		cfg:     cfg,
 	}, nil
 }
 
@@ -141,41 +426,44 @@ func compileDecoder[T any](pattern string, re *regexp.Regexp) (*Decoder[T], erro
 // executes against a match. It is the single plan-construction path shared by
 // the [Decoder] (compiled once via compileDecoder) and the [Unmarshal] /
 // [UnmarshalAll] free functions (built fresh per call) — one set of
-// field-mapping semantics, so the two paths can't drift again.
+// field-mapping semantics, so the two paths can't drift again. Fields of
+// embedded structs and `prefix`-tagged struct fields are reached through
+// visitStructFields, so the plan holds leaf fields only.
 //
 // strict selects the validation posture. The Decoder passes strict=true and any
-// of three checks fails the build, so a successful Compile is fully validated:
+// of four checks fails the build, so a successful Compile is fully validated:
 //   - a field references a group not declared on the pattern and has no default
 //   - a `default=` value does not convert to the field's type
-//   - a `layout=` option sits on a non-time.Time field
+//   - a `layout=`, `layouts=`, or `tz=` option sits on a non-time.Time field
+//   - a `prefix` flag sits on a field that is not a struct
 //
-// The Unmarshal path passes strict=false and tolerates all three rather than
+// The Unmarshal path passes strict=false and tolerates all four rather than
 // rejecting them — a missing group with no default skips the field, an
 // unconvertible default surfaces only if that field is actually reached at
-// decode time, and a stray `layout=` is ignored on non-time fields by
-// setFieldValue. This preserves Unmarshal's historical best-effort behavior, so
+// decode time, a stray `layout=`, `layouts=`, or `tz=` is ignored on non-time
+// fields by setFieldValue, and a stray `prefix` leaves the field decoded as a
+// leaf. This preserves Unmarshal's historical best-effort behavior, so
 // buildDecodePlan never returns a non-nil error when strict=false.
-func buildDecodePlan(rt reflect.Type, re *regexp.Regexp, strict bool) ([]fieldDecoder, error) {
-	var fields []fieldDecoder
-	for i := range rt.NumField() {
-		sf := rt.Field(i)
-		if !sf.IsExported() {
-			continue
-		}
+//
+// cfg supplies the struct tag key and the conversion options the `default=`
+// check converts under. Under strict, its StrictTypes option adds a fifth
+// check, that no group can match a character its field's type rejects (see
+// checkGroupType).
+func buildDecodePlan(rt reflect.Type, re *regexp.Regexp, strict bool, cfg *DecoderConfig) ([]fieldDecoder, error) {
+	// Sized for a flat struct, the common case, so the plan is one allocation.
+	fields := make([]fieldDecoder, 0, rt.NumField())
+	var ast *syntax.Regexp // parsed on first use by the StrictTypes check
+	err := visitStructFields(rt, cfg, strict, func(lf structLeaf) error {
+		sf, opts, required := lf.field, lf.tag.opts, lf.tag.required
 
-		groupName, opts, required, skip := parseFieldTag(sf)
-		if skip {
-			// `regex:"-"` excludes the field entirely — it never enters the
-			// decode plan and no name fallback is attempted.
-			continue
-		}
-		if groupName == "" {
-			// No explicit tag — fall back to matching the field name against a
-			// declared group: exact first, then case-insensitively via Unicode
-			// simple-fold (see matchGroupName). A field that matches no group
-			// and has no default is treated as a typo and, under strict, fails
-			// the build below.
-			groupName = matchGroupName(re, sf.Name)
+		groupName := lf.name()
+		if !lf.tagged() {
+			// No explicit tag name — fall back to matching the field name
+			// (under its prefix, if any) against a declared group: exact first,
+			// then case-insensitively via Unicode simple-fold (see
+			// matchGroupName). A field that matches no group and has no default
+			// is treated as a typo and, under strict, fails the build below.
+			groupName = matchGroupName(re, groupName)
 		}
 
 		_, hasDefault := opts["default"]
@@ -186,7 +474,7 @@ func buildDecodePlan(rt reflect.Type, re *regexp.Regexp, strict bool) ([]fieldDe
 				// Missing group with no default IS a typo — fail at compile.
 				// With a default, missing group is intentional (the default
 				// always fires). The lenient path skips the field below.
-				return nil, fmt.Errorf("%w: field %s references group %q which is not declared on the pattern", ErrInvalidStruct, sf.Name, groupName)
+				return fmt.Errorf("%w: field %s references group %q which is not declared on the pattern", ErrInvalidStruct, fieldPath(rt, lf.index), groupName)
 			}
 		}
 
@@ -196,19 +484,50 @@ func buildDecodePlan(rt reflect.Type, re *regexp.Regexp, strict bool) ([]fieldDe
 			// request.
 			if def, ok := opts["default"]; ok {
 				probe := reflect.New(sf.Type).Elem()
-				if err := setFieldValue(probe, def, opts); err != nil {
-					return nil, fmt.Errorf("%w: field %s default %q does not convert to %v: %w", ErrInvalidStruct, sf.Name, def, sf.Type, err)
+				var err error
+				if isCollectionType(sf.Type, cfg) {
+					_, _, err = setCollectionValue(probe, []string{def}, nil, opts, nil, cfg.resolveConverter(sf.Type.Elem()), cfg)
+				} else {
+					err = setFieldValue(probe, def, opts, cfg.resolveConverter(sf.Type), cfg)
+				}
+				if err != nil {
+					return fmt.Errorf("%w: field %s default %q does not convert to %v: %w", ErrInvalidStruct, fieldPath(rt, lf.index), def, sf.Type, err)
 				}
 			}
 
-			// Validate `layout=` is only on time.Time fields.
-			if _, ok := opts["layout"]; ok {
-				ft := sf.Type
-				if ft.Kind() == reflect.Ptr {
-					ft = ft.Elem()
+			// Validate `layout=`, `layouts=`, and `tz=` are only on
+			// time.Time fields (or collections of them, whose elements the
+			// options apply to), and well-formed.
+			if err := checkTimeOptions(sf.Type, opts, cfg); err != nil {
+				return fmt.Errorf("%w: field %s %w", ErrInvalidStruct, fieldPath(rt, lf.index), err)
+			}
+
+			// Validate `split=` is only on slice and array fields.
+			if _, ok := opts["split"]; ok && !isCollectionType(sf.Type, cfg) {
+				return fmt.Errorf("%w: field %s has `split=` option but is %v, not a slice or array", ErrInvalidStruct, fieldPath(rt, lf.index), sf.Type)
+			}
+
+			// Validate that each `replace=` names its old text.
+			if err := checkTransforms(lf.tag.transforms); err != nil {
+				return fmt.Errorf("%w: field %s %w", ErrInvalidStruct, fieldPath(rt, lf.index), err)
+			}
+
+			// Validate `base=`, `sep=`, and `percent` against the numeric
+			// kinds that honor them.
+			if err := checkNumberOptions(sf.Type, opts, cfg); err != nil {
+				return fmt.Errorf("%w: field %s %w", ErrInvalidStruct, fieldPath(rt, lf.index), err)
+			}
+
+			// A transform may remove text the type's syntax does not
+			// allow — quotes, padding, a unit suffix — so StrictTypes does
+			// not check the group of a field that has one.
+			if cfg.StrictTypes && len(groupIdxs) > 0 && len(lf.tag.transforms) == 0 {
+				if ast == nil {
+					// re compiled from this source, so it parses.
+					ast, _ = syntax.Parse(re.String(), syntax.Perl)
 				}
-				if ft != timeTimeType {
-					return nil, fmt.Errorf("%w: field %s has `layout=` option but is %v, not time.Time", ErrInvalidStruct, sf.Name, sf.Type)
+				if err := checkGroupType(ast, groupIdxs, sf.Type, opts, cfg); err != nil {
+					return fmt.Errorf("%w: field %s: %w", ErrInvalidStruct, fieldPath(rt, lf.index), err)
 				}
 			}
 		}
@@ -218,20 +537,361 @@ func buildDecodePlan(rt reflect.Type, re *regexp.Regexp, strict bool) ([]fieldDe
 		// with no mapping/default so runDecodePlan can raise a
 		// *RequiredGroupError when it yields no value.
 		if len(groupIdxs) == 0 && !hasDefault && !required {
-			continue
+			return nil
 		}
 
-		fields = append(fields, fieldDecoder{
-			fieldIndex:   i,
+		fd := fieldDecoder{
+			field:        lf.index[0],
 			groupIndexes: groupIdxs,
 			opts:         opts,
 			required:     required,
-		})
+			collect:      isCollectionType(sf.Type, cfg),
+		}
+		valueType := sf.Type
+		if fd.collect {
+			valueType = valueType.Elem()
+		}
+		conv := cfg.resolveConverter(valueType)
+		if len(lf.index) > 1 || lf.tag.transforms != nil || conv.decode != nil {
+			fd.extra = &fieldExtra{transforms: lf.tag.transforms, conv: conv}
+			if len(lf.index) > 1 {
+				fd.extra.index = lf.index
+			}
+		}
+		fields = append(fields, fd)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return fields, nil
 }
 
+// structLeaf is one field visitStructFields reaches: a field decoded (or
+// encoded) as a single value, as opposed to a struct it descends into.
+type structLeaf struct {
+	// field is the leaf's own StructField. Its Index is relative to the struct
+	// that declares it; use index for the path from the root type.
+	field reflect.StructField
+	// index is the leaf's index path from the root type.
+	index []int
+	// tag is the leaf's parsed `regex:"..."` tag.
+	tag fieldTag
+	// prefix is the group-name prefix accumulated from enclosing
+	// `prefix`-flagged fields (e.g. "client_"), or "" at the top level and
+	// under embedded structs, which promote their fields unprefixed.
+	prefix string
+	// prefixFromName is set when some segment of prefix came from a Go field
+	// name (an untagged `regex:",prefix"`) rather than an explicit tag name.
+	prefixFromName bool
+}
+
+// tagged reports whether the leaf's whole group name comes from explicit tag
+// names (matched exactly) rather than, at least in part, from Go field names
+// (matched with a case fold, like any field-name fallback).
+func (lf structLeaf) tagged() bool { return lf.tag.name != "" && !lf.prefixFromName }
+
+// name returns the group name the leaf is addressable by, under its prefix:
+// the tag name when set, otherwise the field's own name.
+func (lf structLeaf) name() string {
+	if lf.tag.name != "" {
+		return lf.prefix + lf.tag.name
+	}
+	return lf.prefix + lf.field.Name
+}
+
+// visitStructFields walks the exported fields of the struct type rt in
+// declaration order, calling visit for every leaf field and descending into
+// nested structs the way encoding/json promotes embedded fields:
+//   - An untagged embedded (anonymous) struct, or pointer to struct, is
+//     flattened: its fields are visited as if declared on rt, under the same
+//     prefix. An embedded struct of an unexported type is still flattened
+//     (its exported fields are promoted), unless it is embedded by pointer,
+//     which could not be allocated.
+//   - A struct, or pointer to struct, field carrying the `prefix` flag is
+//     flattened with its fields' group names prefixed by `<name>_`, where name
+//     is the tag name or, when empty, the field's own name. Prefixes
+//     accumulate through further nesting (`a_b_c`).
+//   - A `regex:"-"` field is skipped, as are unexported non-embedded fields.
+//
+// A struct type that converts itself — time.Time, or one implementing
+// [RegexUnmarshaler], [RegexMarshaler], or the encoding.Text* interfaces — is
+// always a leaf and never flattened. Any other field is a leaf too: a nested
+// struct without `prefix` still reaches setFieldValue and fails there with
+// "unsupported field type", as it always has.
+//
+// strict rejects a `prefix` flag on a field that is not a struct, wrapping
+// [ErrInvalidStruct]; the lenient path treats such a field as a leaf. A
+// struct type that recursively contains itself through `prefix` or embedding
+// is not descended into a second time. visit's first error stops the walk and
+// is returned.
+func visitStructFields(rt reflect.Type, cfg *DecoderConfig, strict bool, visit func(structLeaf) error) error {
+	return walkStructFields(rt, rt, nil, structLeaf{}, []reflect.Type{rt}, cfg, strict, visit)
+}
+
+// walkStructFields is visitStructFields' recursion over one struct level. outer
+// carries the enclosing prefix state (prefix, prefixFromName); its other
+// fields are unused.
+func walkStructFields(root, rt reflect.Type, parent []int, outer structLeaf, stack []reflect.Type, cfg *DecoderConfig, strict bool, visit func(structLeaf) error) error {
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+		tag := parseFieldTag(sf, cfg.TagKey)
+		if tag.skip {
+			// `regex:"-"` excludes the field entirely — it never enters the
+			// plan and no name fallback is attempted.
+			continue
+		}
+		// StructField.Index is already allocated by reflect as []int{i}; reuse
+		// it at the top level so a flat struct costs no extra allocation, and
+		// build a fresh path only below it.
+		index := sf.Index
+		if parent != nil {
+			index = append(slices.Clip(parent), i)
+		}
+
+		st := sf.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		nestable := st.Kind() == reflect.Struct && !convertsItself(st, cfg)
+		embedded := sf.Anonymous && tag.name == "" && !tag.prefix && nestable
+		if embedded && sf.Type.Kind() == reflect.Ptr && !sf.IsExported() {
+			// A nil *unexported embedded pointer can't be allocated through
+			// reflect, so its fields are unreachable.
+			continue
+		}
+		if !sf.IsExported() && !embedded {
+			continue
+		}
+
+		if tag.prefix && !nestable {
+			if strict {
+				return fmt.Errorf("%w: field %s has `prefix` option but is %v, not a struct", ErrInvalidStruct, fieldPath(root, index), sf.Type)
+			}
+		} else if embedded || tag.prefix {
+			if slices.Contains(stack, st) {
+				continue
+			}
+			inner := outer
+			if tag.prefix {
+				name := tag.name
+				if name == "" {
+					name = sf.Name
+					inner.prefixFromName = true
+				}
+				inner.prefix += name + "_"
+			}
+			if err := walkStructFields(root, st, index, inner, append(stack, st), cfg, strict, visit); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := visit(structLeaf{field: sf, index: index, tag: tag, prefix: outer.prefix, prefixFromName: outer.prefixFromName}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertsItself reports whether the type t has its own string form —
+// time.Time, a type whose pointer implements one of the package's or
+// encoding's (un)marshal interfaces, or a type with a converter registered in
+// cfg's registries (see [Converters]) — so visitStructFields treats such a
+// struct as a leaf value rather than flattening it, and isCollectionType treats
+// such a slice (net.IP, say) as a single value rather than a list.
+func convertsItself(t reflect.Type, cfg *DecoderConfig) bool {
+	if t == timeTimeType || cfg.registered(t) {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return pt.Implements(regexUnmarshalerType) || pt.Implements(textUnmarshalerType) ||
+		pt.Implements(regexMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+// isCollectionType reports whether a field of type t decodes as a list of
+// elements — a slice or array that does not convert itself (see
+// convertsItself). Each element converts like a scalar field of the element
+// type.
+func isCollectionType(t reflect.Type, cfg *DecoderConfig) bool {
+	k := t.Kind()
+	return (k == reflect.Slice || k == reflect.Array) && !convertsItself(t, cfg)
+}
+
+// Characters each checked kind accepts, as inclusive [lo, hi] pairs in
+// the layout of syntax.Regexp.Rune, for checkGroupType. They cover every
+// spelling strconv and time.ParseDuration accept: a sign, Go-style digit
+// underscores and hex floats, "Inf"/"NaN" in any case, and duration units.
+var (
+	intRunes      = []rune{'+', '+', '-', '-', '0', '9'}
+	uintRunes     = []rune{'0', '9'}
+	floatRunes    = []rune{'+', '+', '-', '.', '0', '9', 'A', 'F', 'I', 'I', 'N', 'N', 'P', 'P', 'T', 'T', 'X', 'Y', '_', '_', 'a', 'f', 'i', 'i', 'n', 'n', 'p', 'p', 't', 't', 'x', 'y'}
+	boolRunes     = []rune{'0', '1', 'A', 'A', 'E', 'F', 'L', 'L', 'R', 'S', 'T', 'U', 'a', 'a', 'e', 'f', 'l', 'l', 'r', 's', 't', 'u'}
+	durationRunes = []rune{'+', '+', '-', '.', '0', '9', 'h', 'h', 'm', 'n', 's', 's', 'u', 'u', 'µ', 'µ', 'μ', 'μ'}
+)
+
+// checkGroupType implements the [StrictTypes] check for a field of type t
+// bound to the capture groups groupIdxs of the parsed pattern ast: it fails
+// when one of the groups can match a character t's conversion never accepts,
+// naming the group and one such character. A type it cannot reason about —
+// one that converts itself, a string, or a kind decoding rejects anyway —
+// passes.
+func checkGroupType(ast *syntax.Regexp, groupIdxs []int, t reflect.Type, opts map[string]string, cfg *DecoderConfig) error {
+	var sep string
+	if isCollectionType(t, cfg) {
+		t = t.Elem()
+		sep, _ = splitSeparator(opts)
+	}
+	if t.Kind() == reflect.Ptr && !cfg.registered(t) {
+		t = t.Elem()
+	}
+	if convertsItself(t, cfg) {
+		return nil
+	}
+	var accepted []rune
+	switch {
+	case t == timeDurationType:
+		accepted = durationRunes
+	default:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			accepted = intRunes
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			accepted = uintRunes
+		case reflect.Float32, reflect.Float64:
+			accepted = floatRunes
+		case reflect.Bool:
+			accepted = boolRunes
+		default:
+			return nil
+		}
+		accepted = numberOptionRunes(accepted, opts)
+	}
+	if sep != "" {
+		accepted = slices.Clone(accepted)
+		for _, r := range sep {
+			accepted = append(accepted, r, r)
+		}
+	}
+
+	for _, gi := range groupIdxs {
+		capture := findCapture(ast, gi)
+		if capture == nil {
+			continue
+		}
+		if r, ok := firstRejected(captureRunes(capture, nil), accepted); ok {
+			return fmt.Errorf("group %q can match %q, which is never valid in %v", capture.Name, string(r), t)
+		}
+	}
+	return nil
+}
+
+// findCapture returns the capture group numbered index in re, or nil.
+func findCapture(re *syntax.Regexp, index int) *syntax.Regexp {
+	if re.Op == syntax.OpCapture && re.Cap == index {
+		return re
+	}
+	for _, sub := range re.Sub {
+		if c := findCapture(sub, index); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// captureRunes appends to dst every character re can match, as [lo, hi]
+// pairs in no particular order. Empty-width assertions match none.
+func captureRunes(re *syntax.Regexp, dst []rune) []rune {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			dst = append(dst, r, r)
+			if re.Flags&syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+					dst = append(dst, f, f)
+				}
+			}
+		}
+	case syntax.OpCharClass:
+		dst = append(dst, re.Rune...)
+	case syntax.OpAnyCharNotNL:
+		dst = append(dst, 0, '\n'-1, '\n'+1, unicode.MaxRune)
+	case syntax.OpAnyChar:
+		dst = append(dst, 0, unicode.MaxRune)
+	}
+	for _, sub := range re.Sub {
+		dst = captureRunes(sub, dst)
+	}
+	return dst
+}
+
+// firstRejected returns the lowest character in the [lo, hi] pairs of ranges
+// that the pairs of accepted do not cover, if there is one.
+func firstRejected(ranges, accepted []rune) (rune, bool) {
+	found, first := false, rune(0)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1]; {
+			j := 0
+			for j+1 < len(accepted) && !(accepted[j] <= r && r <= accepted[j+1]) {
+				j += 2
+			}
+			if j+1 >= len(accepted) {
+				if !found || r < first {
+					found, first = true, r
+				}
+				break
+			}
+			r = accepted[j+1] + 1
+		}
+	}
+	return first, found
+}
+
+// fieldPath renders the Go selector path of the field at index in rt (e.g.
+// "Client.Host"), for error messages and the Field of [DecodeError],
+// [RequiredGroupError], and [EncodeError]. A top-level field renders as its
+// bare name. Only called on error paths, so the string building is never on
+// the decode hot path.
+func fieldPath(rt reflect.Type, index []int) string {
+	if len(index) == 1 {
+		return rt.Field(index[0]).Name
+	}
+	var b strings.Builder
+	for i, x := range index {
+		if rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+		sf := rt.Field(x)
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(sf.Name)
+		rt = sf.Type
+	}
+	return b.String()
+}
+
+// fieldByIndex returns the field of the struct value v at index, allocating
+// any nil struct pointer it steps through on the way (an embedded *T or a
+// `prefix`-flagged *T), so decoding a nested field materializes its parents
+// only when a value is actually written. A single-element index is the
+// top-level fast path.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	if len(index) == 1 {
+		return v.Field(index[0])
+	}
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
 // subexpIndexes returns the submatch index of every occurrence of the named
 // group on re, in declaration order. Unlike re.SubexpIndex, which reports
 // only the first occurrence, this captures duplicates so decode can find the
@@ -271,22 +931,44 @@ func matchGroupName(re *regexp.Regexp, fieldName string) string {
 // occurrence of a reused name resolves to the same string. The empty case — a
 // default-only field with no declared group — is reached only when a `default=`
 // value itself fails to convert on the lenient Unmarshal path; there the name is
-// the explicit tag (or "" if untagged), worth re-parsing the tag for in that
-// rare case. This matches the name buildDecodePlan resolved at build time.
-func resolveGroupName(re *regexp.Regexp, sf reflect.StructField, groupIndexes []int) string {
+// the explicit tag under its prefix (or "" if untagged), worth re-walking the
+// tags along the field's index path for in that rare case. This matches the
+// name buildDecodePlan resolved at build time.
+func resolveGroupName(re *regexp.Regexp, key string, rt reflect.Type, index []int, groupIndexes []int) string {
 	if len(groupIndexes) > 0 {
 		return re.SubexpNames()[groupIndexes[0]]
 	}
-	name, _, _, _ := parseFieldTag(sf)
-	return name
+	var prefix string
+	for i, x := range index {
+		if rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+		sf := rt.Field(x)
+		tag := parseFieldTag(sf, key)
+		if i == len(index)-1 {
+			if tag.name == "" {
+				return ""
+			}
+			return prefix + tag.name
+		}
+		if tag.prefix {
+			name := tag.name
+			if name == "" {
+				name = sf.Name
+			}
+			prefix += name + "_"
+		}
+		rt = sf.Type
+	}
+	return ""
 }
 
 // One returns the result of decoding the first match of d's pattern in target.
 // Returns [ErrNoMatch] if there's no match. Other errors indicate a per-field
 // conversion failure (a [DecodeError]); in that case the returned T contains
 // whatever fields were successfully decoded before the failure. A matched field
-// whose type is a nested struct, slice, or map is one such failure: these are
-// not flattened, so binding a group to one yields an "unsupported field type"
+// whose type is a nested struct without the `prefix` flag or a map is one such
+// failure: binding a group to one yields an "unsupported field type"
 // error (unless the type implements [RegexUnmarshaler] or
 // encoding.TextUnmarshaler, which convert themselves). The same applies to
 // [Decoder.All] and [Decoder.Iter], which share One's decode path.
@@ -296,13 +978,13 @@ func resolveGroupName(re *regexp.Regexp, sf reflect.StructField, groupIndexes []
 // zero fields". Compare with errors.Is. See the package doc's "No-match
 // behavior" section for the full cross-API contract.
 func (d *Decoder[T]) One(target string) (T, error) {
-	matches := d.re.FindStringSubmatchIndex(target)
+	matches := d.matcher.FindStringSubmatchIndex(target)
 	if matches == nil {
 		return d.zero, ErrNoMatch
 	}
 	var v T
 	rv := reflect.ValueOf(&v).Elem()
-	if err := d.decode(rv, target, matches); err != nil {
+	if err := d.decode(rv, target, matches, 0); err != nil {
 		return v, fmt.Errorf("regextra.Decoder.One: %w", err)
 	}
 	return v, nil
@@ -313,14 +995,14 @@ func (d *Decoder[T]) One(target string) (T, error) {
 // error indicates a per-field conversion failure on one of the matches; the
 // slice up to that point may contain partially-decoded entries.
 func (d *Decoder[T]) All(target string) ([]T, error) {
-	allMatches := d.re.FindAllStringSubmatchIndex(target, -1)
+	allMatches := d.matcher.FindAllStringSubmatchIndex(target, -1)
 	if len(allMatches) == 0 {
 		return []T{}, nil
 	}
 	out := make([]T, len(allMatches))
 	for i, matches := range allMatches {
 		rv := reflect.ValueOf(&out[i]).Elem()
-		if err := d.decode(rv, target, matches); err != nil {
+		if err := d.decode(rv, target, matches, i); err != nil {
 			return out[:i+1], fmt.Errorf("regextra.Decoder.All: match %d: %w", i, err)
 		}
 	}
@@ -349,13 +1031,14 @@ func (d *Decoder[T]) All(target string) ([]T, error) {
 //
 // For a slice of all results with a single error, prefer [Decoder.All].
 // For a single match with a sentinel ErrNoMatch, prefer [Decoder.One].
+// For input too large to hold as one string, read it with [Decoder.Scan].
 func (d *Decoder[T]) Iter(target string) iter.Seq2[T, error] {
 	return func(yield func(T, error) bool) {
-		allMatches := d.re.FindAllStringSubmatchIndex(target, -1)
-		for _, matches := range allMatches {
+		allMatches := d.matcher.FindAllStringSubmatchIndex(target, -1)
+		for i, matches := range allMatches {
 			var v T
 			rv := reflect.ValueOf(&v).Elem()
-			err := d.decode(rv, target, matches)
+			err := d.decode(rv, target, matches, i)
 			if err != nil {
 				err = fmt.Errorf("regextra.Decoder.Iter: %w", err)
 			}
@@ -366,6 +1049,180 @@ func (d *Decoder[T]) Iter(target string) iter.Seq2[T, error] {
 	}
 }
 
+// ScanOption configures [Decoder.Scan]'s record framing and buffering.
+type ScanOption func(*scanConfig)
+
+type scanConfig struct {
+	split    bufio.SplitFunc
+	maxToken int
+}
+
+// ScanSplit sets the split function that frames the input into records. The
+// default is [bufio.ScanLines]: one record per line, with the trailing "\n"
+// (and any "\r" before it) stripped. Any [bufio.SplitFunc] works, including
+// [bufio.ScanWords] or a caller-written framer.
+func ScanSplit(split bufio.SplitFunc) ScanOption {
+	return func(c *scanConfig) { c.split = split }
+}
+
+// ScanSeparator frames records on the literal separator sep instead of on
+// newlines — e.g. "\n\n" for blank-line-separated blocks, or "\x00" for
+// NUL-delimited output. The separator is stripped from each record, and a
+// non-empty tail after the last separator is the final record. An empty sep
+// leaves the splitter unchanged.
+func ScanSeparator(sep string) ScanOption {
+	return func(c *scanConfig) {
+		if sep != "" {
+			c.split = splitOnSeparator([]byte(sep))
+		}
+	}
+}
+
+// ScanMaxTokenSize caps the size of a single record, and therefore the
+// scanner's buffer. The default is [bufio.MaxScanTokenSize] (64 KiB). A record
+// longer than n stops the scan with a [ScanError] wrapping [bufio.ErrTooLong].
+// Non-positive values keep the default.
+func ScanMaxTokenSize(n int) ScanOption {
+	return func(c *scanConfig) {
+		if n > 0 {
+			c.maxToken = n
+		}
+	}
+}
+
+// splitOnSeparator returns a bufio.SplitFunc that frames records on the literal
+// byte sequence sep.
+func splitOnSeparator(sep []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, sep); i >= 0 {
+			return i + len(sep), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// ScanError locates a [Decoder.Scan] failure in the input stream. Err is the
+// underlying cause: a [*DecodeError] or [*RequiredGroupError] for a record that
+// matched but failed to decode, or the reader's own error (or
+// [bufio.ErrTooLong]) for a failure that ends the scan.
+//
+//	var se *regextra.ScanError
+//	if errors.As(err, &se) {
+//	    log.Printf("bad record %d at byte %d: %v", se.Record, se.Offset, se.Err)
+//	}
+type ScanError struct {
+	// Record is the 1-based number of the record the failure belongs to —
+	// the line number under the default line splitter. Records that did not
+	// match are still counted.
+	Record int
+	// Offset is the byte offset of the start of that record in the input.
+	Offset int64
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface. [Decoder.Scan] prepends its
+// `regextra.Decoder.Scan:` prefix when wrapping. When Err is nil (only
+// reachable by constructing the value directly) it reports "no scan error".
+func (e *ScanError) Error() string {
+	if e.Err == nil {
+		return "no scan error"
+	}
+	return fmt.Sprintf("record %d (offset %d): %v", e.Record, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error so [errors.Is]/[errors.As] can reach a
+// wrapped [DecodeError], [RequiredGroupError], or I/O error.
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// Scan returns a range-over-func iterator that reads r incrementally, frames it
+// into records (lines by default), and decodes every match of d's pattern in
+// each record into a T with the cached decode plan. Unlike [Decoder.Iter],
+// which needs the whole input as one string, Scan holds at most one record in
+// memory, so it suits multi-gigabyte log files and unbounded streams:
+//
+//	f, _ := os.Open("access.log")
+//	defer f.Close()
+//	for v, err := range dec.Scan(f) {
+//	    if err != nil {
+//	        log.Printf("skipping: %v", err)
+//	        continue
+//	    }
+//	    process(v)
+//	}
+//
+// Matches never span records: the pattern runs against each record on its own,
+// so a `^`/`$` anchor applies to the record and a record with no match yields
+// nothing. Frame records with [ScanSplit] or [ScanSeparator], and bound the
+// buffer with [ScanMaxTokenSize].
+//
+// Every error is a [*ScanError] carrying the record number and byte offset.
+// A decode failure is yielded with the partially-decoded T and iteration
+// continues, as with Iter. A read error — or a record longer than the maximum
+// token size ([bufio.ErrTooLong]) — is yielded once with a zero T and ends
+// the iteration. Break out of the range body to stop early; Scan reads no
+// further than the record being decoded. r is not closed.
+func (d *Decoder[T]) Scan(r io.Reader, opts ...ScanOption) iter.Seq2[T, error] {
+	cfg := scanConfig{split: bufio.ScanLines, maxToken: bufio.MaxScanTokenSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(yield func(T, error) bool) {
+		// The split func wrapper tracks where each token starts in the
+		// stream: consumed is the stream offset of data[0], and a token that
+		// is a subslice of data (true for every stdlib splitter) starts at
+		// consumed plus its distance into data.
+		var consumed, start int64
+		split := func(data []byte, atEOF bool) (int, []byte, error) {
+			advance, token, err := cfg.split(data, atEOF)
+			if token != nil {
+				start = consumed
+				if i := cap(data) - cap(token); i >= 0 && i < len(data) && len(token) > 0 && &data[i] == &token[0] {
+					start += int64(i)
+				}
+			}
+			consumed += int64(advance)
+			return advance, token, err
+		}
+		sc := bufio.NewScanner(r)
+		sc.Buffer(nil, cfg.maxToken)
+		sc.Split(split)
+
+		record := 0
+		for sc.Scan() {
+			record++
+			tok := sc.Bytes()
+			// Match on the scanner's buffer first; copy the record into a
+			// string (which decoded fields may retain) only when it matches.
+			allMatches := d.matcher.FindAllSubmatchIndex(tok, -1)
+			if len(allMatches) == 0 {
+				continue
+			}
+			target := string(tok)
+			for i, matches := range allMatches {
+				var v T
+				rv := reflect.ValueOf(&v).Elem()
+				err := d.decode(rv, target, matches, i)
+				if err != nil {
+					err = fmt.Errorf("regextra.Decoder.Scan: %w", &ScanError{Record: record, Offset: start, Err: err})
+				}
+				if !yield(v, err) {
+					return
+				}
+			}
+		}
+		if err := sc.Err(); err != nil {
+			yield(d.zero, fmt.Errorf("regextra.Decoder.Scan: %w", &ScanError{Record: record + 1, Offset: consumed, Err: err}))
+		}
+	}
+}
+
 // Pattern returns the regex source pattern this Decoder was compiled from.
 // Useful for logging and debugging.
 func (d *Decoder[T]) Pattern() string {
@@ -380,16 +1237,29 @@ func (d *Decoder[T]) Pattern() string {
 // read-only and safe for concurrent use, but callers must not mutate shared
 // state on it — in particular do not call [regexp.Regexp.Longest], which
 // changes the receiver's matching semantics and would affect the Decoder too.
+// Use the [LongestMatch] option instead.
+//
+// It is always the pattern as written: the [FullMatch] and [LongestMatch]
+// options change how the Decoder matches without changing this regexp.
 func (d *Decoder[T]) Regexp() *regexp.Regexp {
 	return d.re
 }
 
-// decode walks the precomputed field plan against a single match and writes the
-// values into rv (the addressable reflect.Value of a T). It is a thin wrapper
-// over the shared runDecodePlan core, which the [Unmarshal] / [UnmarshalAll]
-// free functions drive too.
-func (d *Decoder[T]) decode(rv reflect.Value, target string, matches []int) error {
-	return runDecodePlan(d.re, d.fields, rv, target, matches)
+// Config returns the Decoder's effective configuration — the defaults
+// overlaid with the options passed to [CompileWith] — so it can be logged
+// alongside [Decoder.Pattern]:
+//
+//	log.Printf("decoder %q: %+v", dec.Pattern(), dec.Config())
+func (d *Decoder[T]) Config() DecoderConfig {
+	return d.cfg
+}
+
+// decode walks the precomputed field plan against a single match — the n'th
+// in target — and writes the values into rv (the addressable reflect.Value of
+// a T). It is a thin wrapper over the shared runDecodePlan core, which the
+// [Unmarshal] / [UnmarshalAll] free functions drive too.
+func (d *Decoder[T]) decode(rv reflect.Value, target string, matches []int, n int) error {
+	return runDecodePlan(d.matcher, d.fields, rv, target, matches, n, &d.cfg)
 }
 
 // runDecodePlan executes a decode plan (from buildDecodePlan) against a single
@@ -399,59 +1269,591 @@ func (d *Decoder[T]) decode(rv reflect.Value, target string, matches []int) erro
 // those indices slice into. It is the single decode core shared by [Decoder]
 // (One/All/Iter) and the [Unmarshal] / [UnmarshalAll] free functions. re is
 // used only to resolve a field's group name lazily when building a DecodeError.
-func runDecodePlan(re *regexp.Regexp, fields []fieldDecoder, rv reflect.Value, target string, matches []int) error {
+// n is the match's 0-based index among target's matches, reported with the
+// error's location.
+//
+// cfg supplies the conversion options (time zone, tag key for error reports).
+// It returns the first field's *DecodeError or *RequiredGroupError, unless
+// cfg.AggregateErrors is set: then every field is attempted and the failures are
+// returned together as a *DecodeErrors.
+func runDecodePlan(re *regexp.Regexp, fields []fieldDecoder, rv reflect.Value, target string, matches []int, n int, cfg *DecoderConfig) error {
+	var errs []error
 	for _, fd := range fields {
-		// Pick the value the same way the map-based readers do (see
-		// namedGroupValues): the last occurrence that participated in the
-		// match wins, even if it matched an empty span. A non-participating
-		// occurrence (negative start index) never overwrites a participating
-		// one. Index pairs are what make this possible — FindStringSubmatch's
-		// strings can't tell a participating-empty group from a
-		// non-participating one. The index slice always holds 2*(NumSubexp+1)
-		// entries, so 2*gi+1 is always in range.
-		var value string
-		var found bool
-		for _, gi := range fd.groupIndexes {
-			start := matches[2*gi]
-			if start < 0 {
-				continue
+		if err := runField(re, fd, rv, target, matches, n, cfg); err != nil {
+			if !cfg.AggregateErrors {
+				return err
 			}
-			value = target[start:matches[2*gi+1]]
-			found = true
+			errs = append(errs, err)
 		}
-		// The skip-or-default contract is shared with the map-based readers via
-		// resolveGroupValue (see its doc): default= substitutes when no
-		// occurrence participated OR the winning value is empty, otherwise an
-		// empty/absent group skips the field rather than feeding "" to the
-		// type converter.
-		value, ok := resolveGroupValue(value, found, fd.opts)
+	}
+	if len(errs) > 0 {
+		return &DecodeErrors{Errors: errs}
+	}
+	return nil
+}
+
+// runField decodes one field of the plan for runDecodePlan.
+func runField(re *regexp.Regexp, fd fieldDecoder, rv reflect.Value, target string, matches []int, n int, cfg *DecoderConfig) error {
+	if fd.collect {
+		return runCollectField(re, fd, rv, target, matches, n, cfg)
+	}
+	// Pick the value the same way the map-based readers do (see
+	// namedGroupValues): the last occurrence that participated in the
+	// match wins, even if it matched an empty span. A non-participating
+	// occurrence (negative start index) never overwrites a participating
+	// one. Index pairs are what make this possible — FindStringSubmatch's
+	// strings can't tell a participating-empty group from a
+	// non-participating one. The index slice always holds 2*(NumSubexp+1)
+	// entries, so 2*gi+1 is always in range. start and end locate the value
+	// for error reports: the winning occurrence, or the whole match when none
+	// participated.
+	var value string
+	var found bool
+	start, end := matches[0], matches[1]
+	for _, gi := range fd.groupIndexes {
+		if matches[2*gi] < 0 {
+			continue
+		}
+		start, end = matches[2*gi], matches[2*gi+1]
+		value = target[start:end]
+		found = true
+	}
+	if found && fd.extra != nil && len(fd.extra.transforms) > 0 {
+		// Transforms run on matched text only, before the empty check
+		// below, so a value they empty counts as absent; default= is
+		// used as written.
+		v, err := applyTransforms(value, fd.extra.transforms)
+		if err != nil {
+			line, col := lineColumn(target, start)
+			return &DecodeError{
+				Field:  fieldPath(rv.Type(), fd.path()),
+				Group:  resolveGroupName(re, cfg.TagKey, rv.Type(), fd.path(), fd.groupIndexes),
+				Value:  value,
+				Type:   fd.value(rv).Type().String(),
+				Err:    err,
+				Offset: start,
+				End:    end,
+				Match:  n,
+				Line:   line,
+				Column: col,
+			}
+		}
+		value = v
+	}
+	// The skip-or-default contract is shared with the map-based readers via
+	// resolveGroupValue (see its doc): default= substitutes when no
+	// occurrence participated OR the winning value is empty, otherwise an
+	// empty/absent group skips the field rather than feeding "" to the
+	// type converter.
+	value, ok := resolveGroupValue(value, found, fd.opts)
+	if !ok {
+		// No usable value. A `required` field fails here (the group did not
+		// participate, matched an empty span, or is undeclared, and no
+		// default= supplied a substitute); every other field is skipped and
+		// left unchanged. Keying on resolveGroupValue's `ok` — not `found` —
+		// means a participating-but-empty span also fails required,
+		// consistent with the shared "empty span = data absence" contract.
+		if fd.required {
+			line, col := lineColumn(target, start)
+			return &RequiredGroupError{
+				Field:  fieldPath(rv.Type(), fd.path()),
+				Group:  resolveGroupName(re, cfg.TagKey, rv.Type(), fd.path(), fd.groupIndexes),
+				Offset: start,
+				End:    end,
+				Match:  n,
+				Line:   line,
+				Column: col,
+			}
+		}
+		return nil
+	}
+	field := fd.value(rv)
+	if err := setFieldValue(field, value, fd.opts, fd.converter(), cfg); err != nil {
+		line, col := lineColumn(target, start)
+		return &DecodeError{
+			Field:  fieldPath(rv.Type(), fd.path()),
+			Group:  resolveGroupName(re, cfg.TagKey, rv.Type(), fd.path(), fd.groupIndexes),
+			Value:  value,
+			Type:   field.Type().String(),
+			Err:    err,
+			Offset: start,
+			End:    end,
+			Match:  n,
+			Line:   line,
+			Column: col,
+		}
+	}
+	return nil
+}
+
+// lineColumn converts a byte offset into s to a 1-based line number and a
+// 1-based byte column within that line.
+func lineColumn(s string, offset int) (line, column int) {
+	before := s[:offset]
+	return strings.Count(before, "\n") + 1, offset - strings.LastIndexByte(before, '\n')
+}
+
+// runCollectField decodes one slice or array field (fd.collect) for
+// runDecodePlan. Where a scalar field takes the last participating occurrence
+// of its group, a collection takes every one, in declaration order, as an
+// element (split further by `split=`, when set); an occurrence inside a
+// repetition contributes one element per iteration. Empty spans are skipped,
+// matching the scalar "empty span = data absence" contract; when nothing
+// remains, `default=` substitutes as a single value (itself split), and
+// otherwise the field is skipped or, if `required`, fails with a
+// *RequiredGroupError.
+//
+// An element's error reports the element's own span; an error on the
+// substituted default, or a required failure, reports the last participating
+// occurrence, or the whole match when none participated, as runField does.
+func runCollectField(re *regexp.Regexp, fd fieldDecoder, rv reflect.Value, target string, matches []int, n int, cfg *DecoderConfig) error {
+	var values []string
+	var offsets []int
+	start, end := matches[0], matches[1]
+	for i, gi := range fd.groupIndexes {
+		if fd.extra != nil && fd.extra.repeats != nil && fd.extra.repeats[i] != nil {
+			values, offsets = fd.extra.repeats[i].values(values, offsets, target, matches, gi)
+			continue
+		}
+		if matches[2*gi] < 0 {
+			continue
+		}
+		start, end = matches[2*gi], matches[2*gi+1]
+		if start == end {
+			continue
+		}
+		values = append(values, target[start:end])
+		offsets = append(offsets, start)
+	}
+	var transforms []valueTransform
+	if fd.extra != nil {
+		transforms = fd.extra.transforms
+	}
+	if len(values) == 0 {
+		def, ok := fd.opts["default"]
 		if !ok {
-			// No usable value. A `required` field fails here (the group did not
-			// participate, matched an empty span, or is undeclared, and no
-			// default= supplied a substitute); every other field is skipped and
-			// left unchanged. Keying on resolveGroupValue's `ok` — not `found` —
-			// means a participating-but-empty span also fails required,
-			// consistent with the shared "empty span = data absence" contract.
 			if fd.required {
-				sf := rv.Type().Field(fd.fieldIndex)
+				line, col := lineColumn(target, start)
 				return &RequiredGroupError{
-					Field: sf.Name,
-					Group: resolveGroupName(re, sf, fd.groupIndexes),
+					Field:  fieldPath(rv.Type(), fd.path()),
+					Group:  resolveGroupName(re, cfg.TagKey, rv.Type(), fd.path(), fd.groupIndexes),
+					Offset: start,
+					End:    end,
+					Match:  n,
+					Line:   line,
+					Column: col,
 				}
 			}
-			continue
+			return nil
 		}
-		field := rv.Field(fd.fieldIndex)
-		if err := setFieldValue(field, value, fd.opts); err != nil {
-			sf := rv.Type().Field(fd.fieldIndex)
-			return &DecodeError{
-				Field: sf.Name,
-				Group: resolveGroupName(re, sf, fd.groupIndexes),
-				Value: value,
-				Type:  field.Type().String(),
-				Err:   err,
-			}
+		values = append(values, def)
+		offsets = append(offsets, -1)
+		transforms = nil
+	}
+	field := fd.value(rv)
+	if value, offset, err := setCollectionValue(field, values, offsets, fd.opts, transforms, fd.converter(), cfg); err != nil {
+		if offset >= 0 {
+			start, end = offset, offset+len(value)
+		}
+		line, col := lineColumn(target, start)
+		return &DecodeError{
+			Field:  fieldPath(rv.Type(), fd.path()),
+			Group:  resolveGroupName(re, cfg.TagKey, rv.Type(), fd.path(), fd.groupIndexes),
+			Value:  value,
+			Type:   field.Type().String(),
+			Err:    err,
+			Offset: start,
+			End:    end,
+			Match:  n,
+			Line:   line,
+			Column: col,
 		}
 	}
 	return nil
 }
+
+// decodeRepeat recovers every iteration of one repetition — a `*`, `+`, or
+// `{n,m}` quantified group holding a named capture — of which Go's regexp
+// reports only the last.
+type decodeRepeat struct {
+	// span is the matcher's capture index of the group wrapped around the
+	// whole repetition.
+	span int
+	// first is the matcher's capture index of the first capture inside the
+	// repeated group; the captures inside keep their order in iter.
+	first int
+	// iter matches one iteration at the start of the repetition's span and the
+	// rest of the span after it: `\A(?:G)()(?:G)*\z` for the repeated group G,
+	// under the flags in effect at the repetition. Its empty group, at index
+	// mark, records where the first iteration ends.
+	iter *regexp.Regexp
+	mark int
+}
+
+// values appends the value of capture gi (a matcher index inside the
+// repetition) from each iteration of the repetition in one match to dst, and
+// its byte offset in target to offsets, skipping empty spans as
+// runCollectField does. The span is split into iterations by
+// repeatedly matching iter against its remainder, so each iteration is the
+// one the whole-pattern match chose given the span's end.
+func (rp *decodeRepeat) values(dst []string, offsets []int, target string, matches []int, gi int) ([]string, []int) {
+	start, end := matches[2*rp.span], matches[2*rp.span+1]
+	if start < 0 {
+		return dst, offsets
+	}
+	gi = gi - rp.first + 1
+	for s := target[start:end]; s != ""; {
+		m := rp.iter.FindStringSubmatchIndex(s)
+		if m == nil || m[2*rp.mark] == 0 {
+			// Unreachable for a span the repetition matched; guards the loop.
+			break
+		}
+		if m[2*gi] >= 0 && m[2*gi] < m[2*gi+1] {
+			dst = append(dst, s[m[2*gi]:m[2*gi+1]])
+			offsets = append(offsets, end-len(s)+m[2*gi])
+		}
+		s = s[m[2*rp.mark]:]
+	}
+	return dst, offsets
+}
+
+// buildMatcher returns the regexp a decode of pattern (compiled as re) matches
+// with under cfg, and fields with their group indexes rewritten for it. The
+// FullMatch option anchors a copy of the pattern at both ends with a
+// non-capturing wrapper, which renumbers no group; list fields inside a
+// repetition are handled by expandRepeats; and LongestMatch switches the
+// result to leftmost-longest matching — on a private copy, never on re, which
+// callers share. With no such option or field it returns re itself.
+func buildMatcher(re *regexp.Regexp, pattern string, fields []fieldDecoder, cfg *DecoderConfig) (*regexp.Regexp, []fieldDecoder) {
+	shared := re
+	if cfg.FullMatch {
+		pattern = `\A(?:` + pattern + `)\z`
+		// pattern compiled as re, so the wrapped form compiles too.
+		re = regexp.MustCompile(pattern)
+	}
+	matcher, fields := expandRepeats(re, pattern, fields)
+	if cfg.LongestMatch {
+		if matcher == shared {
+			matcher = regexp.MustCompile(pattern)
+		}
+		matcher.Longest()
+	}
+	return matcher, fields
+}
+
+// expandRepeats prepares the decode of slice and array fields whose group sits
+// inside a repetition such as `(?:,(?P<item>\w+))*`. Go's regexp reports only
+// the last iteration of a repeated group, so each such repetition — outermost
+// only; a repetition nested in another still yields its last iteration per
+// outer iteration — is wrapped in an extra capture group in a copy of the
+// pattern's syntax tree, giving the span every iteration lies in, and a
+// decodeRepeat splits that span back into iterations at decode time.
+//
+// It returns the regexp to match with, compiled from the copy, and fields
+// with their group indexes rewritten for it. When no collect field's group is
+// repeated, it returns re and fields unchanged, so patterns without lists pay
+// nothing beyond a parse. The extra groups are unnamed and change neither
+// which text matches nor any named group's span.
+func expandRepeats(re *regexp.Regexp, pattern string, fields []fieldDecoder) (*regexp.Regexp, []fieldDecoder) {
+	collected := make(map[int]bool)
+	for _, fd := range fields {
+		if fd.collect {
+			for _, gi := range fd.groupIndexes {
+				collected[gi] = true
+			}
+		}
+	}
+	if len(collected) == 0 {
+		return re, fields
+	}
+	ast, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil || !repeatsCollected(ast, collected, false) {
+		return re, fields
+	}
+
+	x := repeatExpansion{collected: collected, remap: make([]int, re.NumSubexp()+1), repeatOf: make(map[int]*decodeRepeat)}
+	expanded := x.expand(ast, false)
+	if x.err != nil {
+		return re, fields
+	}
+	matcher, err := regexp.Compile(patternString(expanded))
+	if err != nil {
+		return re, fields
+	}
+
+	out := make([]fieldDecoder, len(fields))
+	for i, fd := range fields {
+		idxs := make([]int, len(fd.groupIndexes))
+		var repeats []*decodeRepeat
+		for j, gi := range fd.groupIndexes {
+			idxs[j] = x.remap[gi]
+			if rp := x.repeatOf[idxs[j]]; rp != nil && fd.collect {
+				if repeats == nil {
+					repeats = make([]*decodeRepeat, len(idxs))
+				}
+				repeats[j] = rp
+			}
+		}
+		fd.groupIndexes = idxs
+		if repeats != nil {
+			// Copy the extra state rather than modify the entry fd shares
+			// with fields.
+			var extra fieldExtra
+			if fd.extra != nil {
+				extra = *fd.extra
+			}
+			extra.repeats = repeats
+			fd.extra = &extra
+		}
+		out[i] = fd
+	}
+	return matcher, out
+}
+
+// repeatsMany reports whether re is a repetition that can iterate more than
+// once: `*`, `+`, or `{n,m}` with m > 1 or unbounded.
+func repeatsMany(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpStar || re.Op == syntax.OpPlus ||
+		re.Op == syntax.OpRepeat && (re.Max == -1 || re.Max > 1)
+}
+
+// repeatsCollected reports whether expandRepeats has a repetition of re to
+// wrap: an outermost repetition holding a named capture, one of which is in
+// collected. inRepeat is set below such a repetition.
+func repeatsCollected(re *syntax.Regexp, collected map[int]bool, inRepeat bool) bool {
+	if !inRepeat && repeatsMany(re) && hasNamedCapture(re.Sub[0]) {
+		inRepeat = true
+	}
+	if inRepeat && re.Op == syntax.OpCapture && collected[re.Cap] {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if repeatsCollected(sub, collected, inRepeat) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatExpansion is the state of expandRepeats' rewrite of a syntax tree.
+type repeatExpansion struct {
+	// collected holds the original capture indexes of the collect fields'
+	// groups.
+	collected map[int]bool
+	// remap maps each original capture index to its index in the copy.
+	remap []int
+	// next is the last capture index assigned in the copy.
+	next int
+	// repeatOf maps each capture index of the copy inside a wrapped
+	// repetition to that repetition.
+	repeatOf map[int]*decodeRepeat
+	// err is the first failure compiling an iteration matcher.
+	err error
+}
+
+// expand returns a copy of re with its captures renumbered in order and every
+// outermost repetition holding a collected capture wrapped in a new unnamed
+// capture, which takes the index before the captures inside it, as a `(`
+// opening just before the repetition would. inRepeat is set below an outermost
+// repetition holding a named capture, where nothing is wrapped.
+func (x *repeatExpansion) expand(re *syntax.Regexp, inRepeat bool) *syntax.Regexp {
+	cp := *re
+	wrap := false
+	if !inRepeat && repeatsMany(re) && hasNamedCapture(re.Sub[0]) {
+		inRepeat = true
+		wrap = repeatsCollected(re, x.collected, false)
+	}
+	var span int
+	if wrap {
+		x.next++
+		span = x.next
+	}
+	if re.Op == syntax.OpCapture {
+		x.next++
+		x.remap[re.Cap] = x.next
+		cp.Cap = x.next
+	}
+	cp.Sub = make([]*syntax.Regexp, len(re.Sub))
+	for i, sub := range re.Sub {
+		cp.Sub[i] = x.expand(sub, inRepeat)
+	}
+	if !wrap {
+		return &cp
+	}
+
+	// iter matches one iteration of the body at the start of the span, marks
+	// where it ends with an empty group, and matches the rest of the span as
+	// further iterations: `\A(?:G)()(?:G)*\z`. The body's captures are
+	// numbered from 1 in it, in the same order as in the copy.
+	body := patternString(re.Sub[0])
+	iter, err := regexp.Compile(`\A(?:` + body + `)()(?:` + body + `)*\z`)
+	if err != nil {
+		if x.err == nil {
+			x.err = err
+		}
+		return &cp
+	}
+	rp := &decodeRepeat{span: span, first: span + 1, iter: iter, mark: x.next - span + 1}
+	for gi := span + 1; gi <= x.next; gi++ {
+		x.repeatOf[gi] = rp
+	}
+	return &syntax.Regexp{Op: syntax.OpCapture, Flags: re.Flags, Cap: span, Sub: []*syntax.Regexp{&cp}}
+}
+
+// patternString returns source text that compiles to re, printing each node
+// directly rather than through re.String(). String folds every rune of a
+// character class to decide whether the class needs a `(?i)`, which takes
+// milliseconds for `\S` or `[^,]`. A parsed class already holds its
+// case-folded runes, so it is printed by its ranges alone, and only a
+// case-folded literal is wrapped in `(?i:…)`. Captures keep their order and
+// names, so the result numbers its groups as re does.
+func patternString(re *syntax.Regexp) string {
+	var b strings.Builder
+	writePattern(&b, re)
+	return b.String()
+}
+
+// writePattern writes re to b as patternString does.
+func writePattern(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		b.WriteString(`[^\x00-\x{10FFFF}]`)
+	case syntax.OpEmptyMatch:
+		b.WriteString(`(?:)`)
+	case syntax.OpLiteral:
+		fold := re.Flags&syntax.FoldCase != 0
+		if fold {
+			b.WriteString(`(?i:`)
+		}
+		for _, r := range re.Rune {
+			writeLiteralRune(b, r)
+		}
+		if fold {
+			b.WriteByte(')')
+		}
+	case syntax.OpCharClass:
+		writeClass(b, re.Rune)
+	case syntax.OpAnyCharNotNL:
+		b.WriteString(`(?-s:.)`)
+	case syntax.OpAnyChar:
+		b.WriteString(`(?s:.)`)
+	case syntax.OpBeginLine:
+		b.WriteString(`(?m:^)`)
+	case syntax.OpEndLine:
+		b.WriteString(`(?m:$)`)
+	case syntax.OpBeginText:
+		b.WriteString(`\A`)
+	case syntax.OpEndText:
+		b.WriteString(`\z`)
+	case syntax.OpWordBoundary:
+		b.WriteString(`\b`)
+	case syntax.OpNoWordBoundary:
+		b.WriteString(`\B`)
+	case syntax.OpCapture:
+		if re.Name != "" {
+			b.WriteString(`(?P<` + re.Name + `>`)
+		} else {
+			b.WriteByte('(')
+		}
+		writePattern(b, re.Sub[0])
+		b.WriteByte(')')
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		b.WriteString(`(?:`)
+		writePattern(b, re.Sub[0])
+		b.WriteByte(')')
+		switch {
+		case re.Op == syntax.OpStar:
+			b.WriteByte('*')
+		case re.Op == syntax.OpPlus:
+			b.WriteByte('+')
+		case re.Op == syntax.OpQuest:
+			b.WriteByte('?')
+		case re.Max == -1:
+			fmt.Fprintf(b, "{%d,}", re.Min)
+		case re.Min == re.Max:
+			fmt.Fprintf(b, "{%d}", re.Min)
+		default:
+			fmt.Fprintf(b, "{%d,%d}", re.Min, re.Max)
+		}
+		if re.Flags&syntax.NonGreedy != 0 {
+			b.WriteByte('?')
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpAlternate {
+				b.WriteString(`(?:`)
+				writePattern(b, sub)
+				b.WriteByte(')')
+			} else {
+				writePattern(b, sub)
+			}
+		}
+	case syntax.OpAlternate:
+		for i, sub := range re.Sub {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			writePattern(b, sub)
+		}
+	}
+}
+
+// writeLiteralRune writes r outside a character class, escaping regexp
+// syntax and runes that are not printable.
+func writeLiteralRune(b *strings.Builder, r rune) {
+	switch {
+	case strings.ContainsRune(`\.+*?()|[]{}^$`, r):
+		b.WriteByte('\\')
+		b.WriteRune(r)
+	case unicode.IsPrint(r):
+		b.WriteRune(r)
+	default:
+		fmt.Fprintf(b, `\x{%x}`, r)
+	}
+}
+
+// writeClass writes the character class with the rune ranges ranges, in the
+// form syntax.Regexp.String uses: a class holding both 0 and the largest rune
+// is printed negated, by its gaps.
+func writeClass(b *strings.Builder, ranges []rune) {
+	b.WriteByte('[')
+	switch {
+	case len(ranges) == 0:
+		b.WriteString(`^\x00-\x{10FFFF}`)
+	case ranges[0] == 0 && ranges[len(ranges)-1] == unicode.MaxRune && len(ranges) > 2:
+		b.WriteByte('^')
+		for i := 1; i < len(ranges)-1; i += 2 {
+			writeClassRange(b, ranges[i]+1, ranges[i+1]-1)
+		}
+	default:
+		for i := 0; i < len(ranges); i += 2 {
+			writeClassRange(b, ranges[i], ranges[i+1])
+		}
+	}
+	b.WriteByte(']')
+}
+
+// writeClassRange writes the range lo-hi of a character class to b, escaping
+// runes that are not printable or would be read as class syntax.
+func writeClassRange(b *strings.Builder, lo, hi rune) {
+	writeClassRune(b, lo)
+	if lo != hi {
+		if hi != lo+1 {
+			b.WriteByte('-')
+		}
+		writeClassRune(b, hi)
+	}
+}
+
+// writeClassRune writes r as writeClassRange does.
+func writeClassRune(b *strings.Builder, r rune) {
+	switch {
+	case unicode.IsPrint(r) && !strings.ContainsRune(`\[]^-`, r):
+		b.WriteRune(r)
+	case unicode.IsPrint(r):
+		b.WriteByte('\\')
+		b.WriteRune(r)
+	default:
+		fmt.Fprintf(b, `\x{%x}`, r)
+	}
+}
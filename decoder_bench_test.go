@@ -2,6 +2,7 @@ package regextra_test
 
 import (
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -89,8 +90,19 @@ var (
 	benchCustomDecoder  = rx.MustCompile[benchCustom](`\[(?P<state>\w+)\]`)
 	benchWideDecoder    = rx.MustCompile[benchWide](benchWidePattern)
 	benchLogDecoder     = rx.MustCompile[benchLogLine](`\[(?P<level>\w+)\] (?P<msg>[^\n]+)`)
+	benchRepeatDecoder  = rx.MustCompile[benchRepeat](`(?P<cmd>\w+)\((?P<arg>\w+)(?:, (?P<arg>\w+))*\)`)
 )
 
+// benchRepeat's Args sits inside a repetition, so decoding it re-splits the
+// repetition's span into iterations — the cost of a list the pattern repeats
+// over, rather than one it declares occurrence by occurrence.
+type benchRepeat struct {
+	Cmd  string   `regex:"cmd"`
+	Args []string `regex:"arg"`
+}
+
+const benchRepeatIn = "exec(alpha, beta, gamma, delta, epsilon, zeta, eta, theta)"
+
 func BenchmarkDecoderOne(b *testing.B) {
 	// simple pairs with BenchmarkUnmarshal/simpleThreeField (identical fixtures).
 	benchCase(b, "simple", func() { _, sinkErr = benchSimpleDecoder.One(benchSimpleInput) })
@@ -100,6 +112,7 @@ func BenchmarkDecoderOne(b *testing.B) {
 	benchCase(b, "withDefault", func() { _, sinkErr = benchDefaultDecoder.One(benchDefaultIn) })
 	benchCase(b, "customType", func() { _, sinkErr = benchCustomDecoder.One(benchCustomIn) })
 	benchCase(b, "manyFields", func() { _, sinkErr = benchWideDecoder.One(benchWideInput) })
+	benchCase(b, "repeatedList", func() { _, sinkErr = benchRepeatDecoder.One(benchRepeatIn) })
 }
 
 func BenchmarkDecoderAll(b *testing.B) {
@@ -144,6 +157,29 @@ func BenchmarkDecoderIter(b *testing.B) {
 	})
 }
 
+func BenchmarkDecoderScan(b *testing.B) {
+	// fullScan reads the same 100-line corpus as DecoderIter/fullIteration
+	// through an io.Reader, one line-record at a time; the gap is the
+	// bufio.Scanner and per-record match cost of bounded-memory streaming.
+	// The strings.Reader is the only per-iteration fixture allocation.
+	benchCase(b, "fullScan", func() {
+		n := 0
+		for _, err := range benchLogDecoder.Scan(strings.NewReader(benchLogIn)) {
+			sinkErr = err
+			n++
+		}
+		sinkInt = n
+	})
+	benchCase(b, "noMatch", func() {
+		n := 0
+		for _, err := range benchLogDecoder.Scan(strings.NewReader(benchNoMatchInput)) {
+			sinkErr = err
+			n++
+		}
+		sinkInt = n
+	})
+}
+
 func BenchmarkDecoderPattern(b *testing.B) {
 	benchCase(b, "pattern", func() { sinkStr = benchSimpleDecoder.Pattern() })
 }
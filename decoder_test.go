@@ -1,13 +1,18 @@
 package regextra_test
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	rx "github.com/jecoms/regextra"
@@ -85,7 +90,7 @@ func TestCompile_fieldNameExactMatch(t *testing.T) {
 }
 
 // Typed-path sibling of TestUnmarshal_unsupportedFieldType: a field whose kind
-// setFieldValue can't convert (a nested struct here; a slice or map behaves the
+// setFieldValue can't convert (a nested struct here; a map behaves the
 // same) and which implements neither RegexUnmarshaler nor encoding.TextUnmarshaler
 // passes Compile (which validates tags, not field kinds) and surfaces the
 // "unsupported field type" arm as a *DecodeError at decode time via One.
@@ -627,6 +632,25 @@ func ExampleDecoder_Iter() {
 	// Bob/25
 }
 
+func ExampleDecoder_Scan() {
+	type Entry struct {
+		Level string `regex:"level"`
+		Msg   string `regex:"msg"`
+	}
+	dec := rx.MustCompile[Entry](`^\[(?P<level>\w+)\] (?P<msg>.+)$`)
+	// Any io.Reader works — an *os.File, a network stream, a gzip reader.
+	r := strings.NewReader("[INFO] started\nnoise\n[WARN] disk low\n")
+	for e, err := range dec.Scan(r) {
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%s: %s\n", e.Level, e.Msg)
+	}
+	// Output:
+	// INFO: started
+	// WARN: disk low
+}
+
 func ExampleDecoder_Pattern() {
 	type Entry struct {
 		Name string `regex:"name"`
@@ -922,6 +946,17 @@ func TestDecoder_tagForwardCompatRules(t *testing.T) {
 	}
 }
 
+// `omitempty` is an encode-side flag: the Decoder parses it without effect.
+func TestDecoder_omitemptyIgnored(t *testing.T) {
+	type P struct {
+		Port int `regex:"port,omitempty,default=80"`
+	}
+	got, err := rx.MustCompile[P](`(?::(?P<port>\d+))?$`).One("host")
+	if err != nil || got.Port != 80 {
+		t.Errorf("One() = %+v, %v; want Port 80 from default=", got, err)
+	}
+}
+
 // TestDecoderDecodeError verifies the typed decode path surfaces an
 // errors.As-able *DecodeError with Field/Group/Value/Type populated, wrapped
 // under each entrypoint's regextra.<Entrypoint>: prefix (asserted loosely per
@@ -1135,3 +1170,923 @@ func TestSharedDecodePlan_AllPathsAgree(t *testing.T) {
 		t.Errorf("UnmarshalAll = %+v, want %+v", ua, want)
 	}
 }
+
+// ── Nested structs: `prefix` and embedding ────────────────────────────────────
+
+type nestEndpoint struct {
+	Host string
+	Port int `regex:"port"`
+}
+
+type nestRequest struct {
+	Method string
+	Path   string
+}
+
+// A `prefix`-flagged struct field resolves its own fields against
+// `<name>_<field>` groups — tagged children exactly, untagged children via the
+// usual field-name fold — and a pointer-to-struct field is allocated only when
+// one of its groups yields a value.
+func TestDecoder_prefixNestedStruct(t *testing.T) {
+	type accessLog struct {
+		Client nestEndpoint  `regex:"client,prefix"`
+		Server *nestEndpoint `regex:"server,prefix"`
+		Proxy  *nestEndpoint `regex:"proxy,prefix"`
+	}
+	dec := rx.MustCompile[accessLog](`(?P<client_host>\S+):(?P<client_port>\d+) -> (?P<server_host>\S+):(?P<server_port>\d+)(?: via (?P<proxy_host>\S+):(?P<proxy_port>\d+))?`)
+
+	got, err := dec.One("10.0.0.1:5123 -> example.com:443")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	want := accessLog{
+		Client: nestEndpoint{Host: "10.0.0.1", Port: 5123},
+		Server: &nestEndpoint{Host: "example.com", Port: 443},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("One() = %+v (server %+v), want %+v", got, got.Server, want)
+	}
+	if got.Proxy != nil {
+		t.Errorf("Proxy = %+v, want nil (no proxy group participated)", got.Proxy)
+	}
+}
+
+// An untagged `prefix` field prefixes with its own field name, and prefixes
+// accumulate through further nesting.
+func TestDecoder_prefixFieldNameAndNesting(t *testing.T) {
+	type hop struct {
+		Src nestEndpoint `regex:",prefix"`
+	}
+	type route struct {
+		First hop `regex:"first,prefix"`
+	}
+	dec := rx.MustCompile[route](`(?P<first_src_host>\w+):(?P<first_src_port>\d+)`)
+	got, err := dec.One("alpha:80")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	want := route{First: hop{Src: nestEndpoint{Host: "alpha", Port: 80}}}
+	if got != want {
+		t.Errorf("One() = %+v, want %+v", got, want)
+	}
+}
+
+// Untagged embedded structs (by value or pointer) promote their fields
+// unprefixed, the way encoding/json does; a nil embedded pointer whose groups
+// never yield a value stays nil.
+func TestDecoder_embeddedStructFlattened(t *testing.T) {
+	type Meta struct {
+		Agent string `regex:"agent"`
+	}
+	type line struct {
+		nestRequest
+		*Meta
+		Status int
+	}
+	dec := rx.MustCompile[line](`(?P<method>[A-Z]+) (?P<path>\S+) (?P<status>\d+)(?: "(?P<agent>[^"]*)")?`)
+
+	got, err := dec.One(`GET /index.html 200 "curl/8"`)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if got.Method != "GET" || got.Path != "/index.html" || got.Status != 200 {
+		t.Errorf("One() = %+v, want GET /index.html 200", got)
+	}
+	if got.Meta == nil || got.Agent != "curl/8" {
+		t.Errorf("embedded *Meta = %+v, want Agent curl/8", got.Meta)
+	}
+
+	got, err = dec.One(`POST /x 201`)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if got.Meta != nil {
+		t.Errorf("embedded *Meta = %+v, want nil (agent group did not participate)", got.Meta)
+	}
+}
+
+// A struct that converts itself (time.Time here) is a leaf even when embedded,
+// and a nested struct without `prefix` is still a leaf that fails conversion.
+func TestDecoder_embeddedSelfConvertingStructIsLeaf(t *testing.T) {
+	type stamped struct {
+		time.Time `regex:"ts"`
+	}
+	dec := rx.MustCompile[stamped](`(?P<ts>\S+)`)
+	got, err := dec.One("2024-01-02T03:04:05Z")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !got.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", got.Time, want)
+	}
+}
+
+// Decode failures inside a nested struct report the Go selector path as Field
+// and the full prefixed group as Group.
+func TestDecoder_nestedErrorsReportPath(t *testing.T) {
+	type rec struct {
+		Client nestEndpoint `regex:"client,prefix"`
+	}
+	dec := rx.MustCompile[rec](`(?P<client_host>\w+):(?P<client_port>\w+)`)
+	_, err := dec.One("host:http")
+	var de *rx.DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("error %v is not a *DecodeError", err)
+	}
+	if de.Field != "Client.Port" || de.Group != "client_port" {
+		t.Errorf("DecodeError{Field:%q, Group:%q}, want {Client.Port, client_port}", de.Field, de.Group)
+	}
+
+	type req struct {
+		Client nestEndpoint `regex:"client,prefix"`
+		Extra  struct {
+			ID string `regex:"id,required"`
+		} `regex:"extra,prefix"`
+	}
+	dec2 := rx.MustCompile[req](`(?P<client_host>\w+):(?P<client_port>\d+)(?:/(?P<extra_id>\w+))?`)
+	_, err = dec2.One("host:80")
+	var rge *rx.RequiredGroupError
+	if !errors.As(err, &rge) {
+		t.Fatalf("error %v is not a *RequiredGroupError", err)
+	}
+	if rge.Field != "Extra.ID" || rge.Group != "extra_id" {
+		t.Errorf("RequiredGroupError{Field:%q, Group:%q}, want {Extra.ID, extra_id}", rge.Field, rge.Group)
+	}
+}
+
+// Strict Compile validates nested fields exactly like top-level ones, and
+// rejects `prefix` on a field that is not a struct.
+func TestCompile_nestedValidation(t *testing.T) {
+	type undeclared struct {
+		Client nestEndpoint `regex:"client,prefix"`
+	}
+	_, err := rx.Compile[undeclared](`(?P<client_host>\w+)`) // no client_port
+	if !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Fatalf("Compile() error = %v, want ErrInvalidStruct", err)
+	}
+	if !strings.Contains(err.Error(), `"client_port"`) || !strings.Contains(err.Error(), "Client.Port") {
+		t.Errorf("Compile() error = %q, want it to name Client.Port and client_port", err)
+	}
+
+	type badPrefix struct {
+		Name string `regex:"name,prefix"`
+	}
+	_, err = rx.Compile[badPrefix](`(?P<name>\w+)`)
+	if !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("Compile() error = %v, want ErrInvalidStruct for prefix on a string field", err)
+	}
+}
+
+// The shared plan means Unmarshal and UnmarshalAll flatten nested structs the
+// same way the Decoder does.
+func TestDecoderUnmarshal_nestedParity(t *testing.T) {
+	type rec struct {
+		nestRequest
+		Upstream nestEndpoint `regex:"up,prefix"`
+	}
+	const pattern = `(?P<method>\w+) (?P<path>\S+) (?P<up_host>\w+):(?P<up_port>\d+)`
+	const input = "GET /a b:1 PUT /c d:2"
+	want := []rec{
+		{nestRequest: nestRequest{Method: "GET", Path: "/a"}, Upstream: nestEndpoint{Host: "b", Port: 1}},
+		{nestRequest: nestRequest{Method: "PUT", Path: "/c"}, Upstream: nestEndpoint{Host: "d", Port: 2}},
+	}
+
+	all, err := rx.MustCompile[rec](pattern).All(input)
+	if err != nil || !reflect.DeepEqual(all, want) {
+		t.Errorf("Decoder.All = %+v, %v; want %+v", all, err, want)
+	}
+	var ua []rec
+	if err := rx.UnmarshalAll(regexp.MustCompile(pattern), input, &ua); err != nil || !reflect.DeepEqual(ua, want) {
+		t.Errorf("UnmarshalAll = %+v, %v; want %+v", ua, err, want)
+	}
+}
+
+// ── Slice and array fields ────────────────────────────────────────────────────
+
+// A slice field collects every participating occurrence of its group, in
+// declaration order, converting each element like a scalar field; empty and
+// non-participating occurrences contribute nothing.
+func TestDecoder_sliceCollectsOccurrences(t *testing.T) {
+	type rec struct {
+		Keys []string `regex:"key"`
+		Nums []int    `regex:"num"`
+	}
+	dec := rx.MustCompile[rec](`(?P<key>\w+)=(?P<num>\d*)(?:, (?P<key>\w+)=(?P<num>\d*))?(?:, (?P<key>\w+)=(?P<num>\d*))?`)
+
+	got, err := dec.One("a=1, b=, c=3")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	want := rec{Keys: []string{"a", "b", "c"}, Nums: []int{1, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("One() = %+v, want %+v", got, want)
+	}
+
+	got, err = dec.One("a=1")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if want := (rec{Keys: []string{"a"}, Nums: []int{1}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("One() = %+v, want %+v", got, want)
+	}
+}
+
+// `split=` splits each captured value into elements; `split=,` spells a comma
+// separator despite the tag grammar's own commas, and named separators cover
+// whitespace the parser would trim.
+func TestDecoder_splitOption(t *testing.T) {
+	type rec struct {
+		Tags  []string  `regex:"tags,split=,"`
+		Ports []uint16  `regex:"ports,split=space"`
+		Rates []float64 `regex:"rates,split=|,required"`
+	}
+	dec := rx.MustCompile[rec](`tags=(?P<tags>\S*) ports=(?P<ports>[\d ]+?) rates=(?P<rates>\S+)`)
+	got, err := dec.One("tags=a,b,,c ports=80 443  8080 rates=0.5|1.5")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	want := rec{
+		Tags:  []string{"a", "b", "c"},
+		Ports: []uint16{80, 443, 8080},
+		Rates: []float64{0.5, 1.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("One() = %+v, want %+v", got, want)
+	}
+}
+
+// An array field is filled from the front; more values than it holds is a
+// *DecodeError. A failing element reports its own raw value.
+func TestDecoder_arrayAndElementErrors(t *testing.T) {
+	type rec struct {
+		Octets [4]uint8 `regex:"ip,split=."`
+	}
+	dec := rx.MustCompile[rec](`(?P<ip>[\d.]+)`)
+
+	got, err := dec.One("10.0.7")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if want := [4]uint8{10, 0, 7, 0}; got.Octets != want {
+		t.Errorf("Octets = %v, want %v", got.Octets, want)
+	}
+
+	_, err = dec.One("1.2.3.4.5")
+	var de *rx.DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("One() error = %v, want a *DecodeError for too many elements", err)
+	}
+	if de.Value != "5" || de.Type != "[4]uint8" {
+		t.Errorf("DecodeError{Value:%q, Type:%q}, want {5, [4]uint8}", de.Value, de.Type)
+	}
+
+	_, err = dec.One("1.300.3")
+	if !errors.As(err, &de) {
+		t.Fatalf("One() error = %v, want a *DecodeError for an overflowing element", err)
+	}
+	if de.Field != "Octets" || de.Group != "ip" || de.Value != "300" {
+		t.Errorf("DecodeError = %+v, want Field=Octets Group=ip Value=300", de)
+	}
+}
+
+// default= supplies a (split) list when no occurrence yields a value, and
+// required fails when nothing does.
+func TestDecoder_sliceDefaultAndRequired(t *testing.T) {
+	type rec struct {
+		Roles []string `regex:"role,split=|,default=guest|viewer"`
+		IDs   []int    `regex:"id,required"`
+	}
+	dec := rx.MustCompile[rec](`(?:role=(?P<role>\w+) )?(?:id=(?P<id>\d+))?;`)
+
+	got, err := dec.One("id=7;")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if want := (rec{Roles: []string{"guest", "viewer"}, IDs: []int{7}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("One() = %+v, want %+v", got, want)
+	}
+
+	_, err = dec.One("role=admin ;")
+	var rge *rx.RequiredGroupError
+	if !errors.As(err, &rge) || rge.Field != "IDs" {
+		t.Errorf("One() error = %v, want a *RequiredGroupError for IDs", err)
+	}
+}
+
+// A slice type that converts itself (net.IP implements
+// encoding.TextUnmarshaler) is decoded as a single value, not a list, and
+// layout= applies to each element of a []time.Time.
+func TestDecoder_selfConvertingSliceAndElementLayout(t *testing.T) {
+	type rec struct {
+		Addr net.IP      `regex:"addr"`
+		Days []time.Time `regex:"day,split=;,layout=2006/01/02"`
+	}
+	dec := rx.MustCompile[rec](`(?P<addr>\S+) (?P<day>\S+)`)
+	got, err := dec.One("192.0.2.1 2024/01/02;2024/03/04")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if !got.Addr.Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("Addr = %v, want 192.0.2.1", got.Addr)
+	}
+	want := []time.Time{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(got.Days, want) {
+		t.Errorf("Days = %v, want %v", got.Days, want)
+	}
+}
+
+// A slice field whose group sits inside a repetition collects every
+// iteration, not just the last one Go's regexp reports, in order with the
+// group's occurrences outside it; scalar fields, other groups' spans, and the
+// whole match are unaffected.
+func TestDecoder_sliceCollectsRepetitions(t *testing.T) {
+	type rec struct {
+		Name  string   `regex:"name"`
+		Items []string `regex:"item"`
+		Last  string   // untagged: not every pattern declares it
+	}
+	tests := []struct {
+		pattern string
+		in      string
+		want    rec
+	}{
+		{`(?P<name>\w+): (?P<item>\w+)(?:,(?P<item>\w+))*(?: (?P<last>\w+))?`, "ls: a,bb,ccc end", rec{"ls", []string{"a", "bb", "ccc"}, "end"}},
+		{`(?P<name>\w+): (?P<item>\w+)(?:,(?P<item>\w+))*`, "ls: a", rec{Name: "ls", Items: []string{"a"}}},
+		{`(?P<name>\w+):(?: (?P<item>\w+))+`, "ls: a b", rec{Name: "ls", Items: []string{"a", "b"}}},
+		{`(?P<name>\w+):(?: (?P<item>\w+)){2,3}(?P<last>.*)`, "ls: a b c d", rec{"ls", []string{"a", "b", "c"}, " d"}},
+		{`(?P<name>\w+): (?P<item>\w)*`, "ls: xyz", rec{Name: "ls", Items: []string{"x", "y", "z"}}},
+		{`(?i)(?P<name>\w+): (?:X(?P<item>\d))*?(?P<last>x9)`, "ls: x1X2x9", rec{"ls", []string{"1", "2"}, "x9"}},
+		{`(?P<name>\w+)(?:\[(?P<item>[^\]()]*)\]|\((?:,?(?P<item>\w+))*\))`, "f(a,b)", rec{Name: "f", Items: []string{"a", "b"}}},
+		{`(?P<name>\w+)=(?:\Q(\E(?P<item>[(\w]+)\))*`, "v=(a)((b)", rec{Name: "v", Items: []string{"a", "(b"}}},
+		{`(?P<name>\w+): (?:(?P<item>a|ab)(?:-))*c`, "ls: ab-a-c", rec{Name: "ls", Items: []string{"ab", "a"}}},
+		// Parentheses in classes, POSIX classes, flag groups, and
+		// supplementary-plane runes, which the expanded pattern prints back.
+		{`(?P<name>\w+):(?:[)(](?P<item>[^()\]]+))*`, "f:(a)b(c", rec{Name: "f", Items: []string{"a", "b", "c"}}},
+		{`(?P<name>\w+)(?i:-(?P<item>[[:alpha:]]+)x)+`, "v-AbX-cdx", rec{Name: "v", Items: []string{"Ab", "cd"}}},
+		{`(?P<name>\w+)𐐀(?:,(?P<item>[^,𐐁]+))*`, "x𐐀,ab,c", rec{Name: "x", Items: []string{"ab", "c"}}},
+		// A class under `(?i)` folds in the expanded pattern as in the
+		// original, and a class beside a folded literal does not.
+		{`(?i)(?P<name>\w+):(?:,(?P<item>[a-c]+))*`, "x:,Ab,CC", rec{Name: "x", Items: []string{"Ab", "CC"}}},
+		{`(?P<name>\w+)(?:;(?i:k)=(?P<item>[a-z]+))*`, "x;K=ab;k=cd;k=EF", rec{Name: "x", Items: []string{"ab", "cd"}}},
+		{`(?P<name>\w+)(?i:(?:;(?-i:[a-z])(?P<item>[a-z]+))*)`, "x;aBC;bcd;Cde", rec{Name: "x", Items: []string{"BC", "cd"}}},
+	}
+	for _, tt := range tests {
+		got, err := rx.MustCompile[rec](tt.pattern).One(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q on %q: One() = %+v, %v; want %+v", tt.pattern, tt.in, got, err, tt.want)
+		}
+	}
+
+	// Every match of All expands its own repetitions.
+	dec := rx.MustCompile[rec](`(?P<name>\w+)\[(?:(?P<item>\d+);)*\]`)
+	got, err := dec.All("a[1;2;] b[] c[3;]")
+	want := []rec{{Name: "a", Items: []string{"1", "2"}}, {Name: "b"}, {Name: "c", Items: []string{"3"}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("All() = %+v, %v; want %+v", got, err, want)
+	}
+	if dec.Regexp().String() != dec.Pattern() {
+		t.Errorf("Regexp() = %q, want the pattern as written", dec.Regexp())
+	}
+}
+
+// Strict Compile rejects split= on a non-list field and validates a list
+// default element by element.
+func TestCompile_sliceValidation(t *testing.T) {
+	type badSplit struct {
+		Name string `regex:"name,split=;"`
+	}
+	if _, err := rx.Compile[badSplit](`(?P<name>\w+)`); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("Compile(split on string) error = %v, want ErrInvalidStruct", err)
+	}
+
+	type badDefault struct {
+		Nums []int `regex:"num,split=;,default=1;x"`
+	}
+	if _, err := rx.Compile[badDefault](`(?P<num>\d+)`); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("Compile(bad list default) error = %v, want ErrInvalidStruct", err)
+	}
+}
+
+// ── Scan ──────────────────────────────────────────────────────────────────────
+
+func TestDecoder_Scan_linesAndErrorLocation(t *testing.T) {
+	type E struct {
+		Name string `regex:"name"`
+		Age  int    `regex:"age"`
+	}
+	dec := rx.MustCompile[E](`(?P<name>\w+)=(?P<age>\S+)`)
+	// Record 2 matches nothing, record 3 carries two matches, record 4 fails
+	// to convert; CRLF endings are stripped like bufio.ScanLines does.
+	input := "alice=30\r\n# comment\nbob=25 carol=40\ndave=old\neve=22"
+	var got []E
+	var se *rx.ScanError
+	for v, err := range dec.Scan(strings.NewReader(input)) {
+		if err != nil {
+			if !errors.As(err, &se) {
+				t.Fatalf("error %v is not a *ScanError", err)
+			}
+			continue
+		}
+		got = append(got, v)
+	}
+	want := []E{{"alice", 30}, {"bob", 25}, {"carol", 40}, {"eve", 22}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan collected %+v, want %+v", got, want)
+	}
+	if se == nil {
+		t.Fatal("Scan yielded no error for the bad record")
+	}
+	if wantOff := int64(strings.Index(input, "dave")); se.Record != 4 || se.Offset != wantOff {
+		t.Errorf("ScanError at record %d offset %d, want record 4 offset %d", se.Record, se.Offset, wantOff)
+	}
+	var de *rx.DecodeError
+	if !errors.As(se, &de) || de.Field != "Age" || de.Value != "old" {
+		t.Errorf("ScanError does not unwrap to the field's DecodeError: %v", se)
+	}
+	if !strings.HasPrefix(se.Error(), "record 4 (offset ") {
+		t.Errorf("ScanError.Error() = %q", se.Error())
+	}
+}
+
+func TestDecoder_Scan_separatorFraming(t *testing.T) {
+	type Block struct {
+		ID   int    `regex:"id"`
+		Body string `regex:"body"`
+	}
+	// (?s) lets the body span the newlines inside one blank-line-separated block.
+	dec := rx.MustCompile[Block](`(?s)^id: (?P<id>\d+)\n(?P<body>.*)$`)
+	input := "id: 1\nfirst\nblock\n\nid: 2\nsecond\n\nid: x\nbad"
+	var got []Block
+	var se *rx.ScanError
+	for v, err := range dec.Scan(strings.NewReader(input), rx.ScanSeparator("\n\n")) {
+		if err != nil {
+			errors.As(err, &se)
+			continue
+		}
+		got = append(got, v)
+	}
+	want := []Block{{1, "first\nblock"}, {2, "second"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan collected %+v, want %+v", got, want)
+	}
+	if se != nil {
+		t.Errorf("unexpected error %v (record 3 should simply not match)", se)
+	}
+
+	// ScanSplit accepts any bufio.SplitFunc.
+	type Num struct {
+		N int `regex:"n"`
+	}
+	words := rx.MustCompile[Num](`^(?P<n>\d+)$`)
+	n := 0
+	for v, err := range words.Scan(strings.NewReader("1 x 2\n3"), rx.ScanSplit(bufio.ScanWords)) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		n += v.N
+	}
+	if n != 6 {
+		t.Errorf("sum of ScanWords records = %d, want 6", n)
+	}
+}
+
+func TestDecoder_Scan_stopsOnReadErrors(t *testing.T) {
+	type E struct {
+		Word string `regex:"word"`
+	}
+	dec := rx.MustCompile[E](`(?P<word>\w+)`)
+
+	// A record over the max token size ends the scan with bufio.ErrTooLong,
+	// located at the start of the oversized record.
+	input := "ok\n" + strings.Repeat("x", 64) + "\nnever\n"
+	var words []string
+	var errs []error
+	for v, err := range dec.Scan(strings.NewReader(input), rx.ScanMaxTokenSize(16)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		words = append(words, v.Word)
+	}
+	if !reflect.DeepEqual(words, []string{"ok"}) || len(errs) != 1 {
+		t.Fatalf("Scan = %v with errors %v, want [ok] and one error", words, errs)
+	}
+	var se *rx.ScanError
+	if !errors.Is(errs[0], bufio.ErrTooLong) || !errors.As(errs[0], &se) || se.Record != 2 || se.Offset != 3 {
+		t.Errorf("oversized record error = %v, want ErrTooLong at record 2 offset 3", errs[0])
+	}
+
+	// The reader's own error surfaces once, after the records read before it.
+	boom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader("a\nb\n"), iotest.ErrReader(boom))
+	words, errs = nil, nil
+	for v, err := range dec.Scan(r) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		words = append(words, v.Word)
+	}
+	if !reflect.DeepEqual(words, []string{"a", "b"}) || len(errs) != 1 || !errors.Is(errs[0], boom) {
+		t.Errorf("Scan = %v with errors %v, want [a b] and one boom error", words, errs)
+	}
+}
+
+func TestDecoder_Scan_breakStopsReading(t *testing.T) {
+	type E struct {
+		Word string `regex:"word"`
+	}
+	dec := rx.MustCompile[E](`(?P<word>\w+)`)
+	// Breaking after the first record must not read on into the failing tail.
+	r := io.MultiReader(strings.NewReader("first\n"), iotest.ErrReader(errors.New("read past break")))
+	for v, err := range dec.Scan(r) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.Word != "first" {
+			t.Errorf("Word = %q, want first", v.Word)
+		}
+		break
+	}
+}
+
+// ── Error locations ───────────────────────────────────────────────────────────
+
+func TestDecoder_errorLocation(t *testing.T) {
+	type rec struct {
+		Key  string `regex:"key"`
+		Port int    `regex:"port"`
+	}
+	dec := rx.MustCompile[rec](`(?P<key>\w+)=(?P<port>\w+)`)
+	const input = "a=1\nb=2 c=x3"
+	var de *rx.DecodeError
+	n := 0
+	for _, err := range dec.Iter(input) {
+		if err == nil {
+			n++
+			continue
+		}
+		if !errors.As(err, &de) {
+			t.Fatalf("Iter error = %v, want a DecodeError", err)
+		}
+		n++
+	}
+	if n != 3 || de == nil {
+		t.Fatalf("Iter yielded %d matches, want 3 with one DecodeError", n)
+	}
+	if input[de.Offset:de.End] != "x3" || de.Match != 2 || de.Line != 2 || de.Column != 7 {
+		t.Errorf("DecodeError location = [%d:%d] match %d, %d:%d; want x3 at match 2, 2:7", de.Offset, de.End, de.Match, de.Line, de.Column)
+	}
+	_, err := dec.All(input)
+	if !errors.As(err, &de) || de.Match != 2 || input[de.Offset:de.End] != "x3" {
+		t.Errorf("All error = %v, want the same location", err)
+	}
+}
+
+func TestDecoder_errorLocationAbsentAndElements(t *testing.T) {
+	type req struct {
+		Name string `regex:"name,required"`
+		Tags []int  `regex:"tags,split=comma"`
+	}
+	dec := rx.MustCompile[req](`(?:name=(?P<name>\w+) )?tags=(?P<tags>\S+)`)
+	const input = "> tags=1,2,x,4"
+	_, err := dec.One(input)
+	var rge *rx.RequiredGroupError
+	if !errors.As(err, &rge) || input[rge.Offset:rge.End] != "tags=1,2,x,4" || rge.Column != 3 {
+		t.Errorf("One error = %v; want a RequiredGroupError spanning the whole match at column 3", err)
+	}
+
+	type list struct {
+		Args []int `regex:"arg"`
+	}
+	rep := rx.MustCompile[list](`\((?P<arg>\w+)(?:, (?P<arg>\w+))*\)`)
+	const call = "f(1, 2, z, 4)"
+	_, err = rep.One(call)
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || call[de.Offset:de.End] != "z" || de.Value != "z" {
+		t.Errorf("One(repetition) error = %v; want the failing iteration's span", err)
+	}
+
+	_, err = dec.One("name=bob tags=1,2,x,4")
+	if !errors.As(err, &de) || de.Offset != 18 || de.End != 19 {
+		t.Errorf("One(split) error = %v; want the failing element's span [18:19]", err)
+	}
+}
+
+func TestDecoder_Scan_errorLocationIsRecordRelative(t *testing.T) {
+	type E struct {
+		N int `regex:"n"`
+	}
+	dec := rx.MustCompile[E](`n=(?P<n>\w+)`)
+	for _, err := range dec.Scan(strings.NewReader("n=1\nn=2 n=q\n")) {
+		if err == nil {
+			continue
+		}
+		var se *rx.ScanError
+		var de *rx.DecodeError
+		if !errors.As(err, &se) || !errors.As(err, &de) || se.Record != 2 || de.Match != 1 || de.Offset != 6 || de.Line != 1 || de.Column != 7 {
+			t.Errorf("Scan error = %v; want record 2, match 1, offset 6 at 1:7 of the record", err)
+		}
+	}
+}
+
+// ── CompileWith: AggregateErrors ──────────────────────────────────────────────
+
+func TestCompileWith_aggregateErrors(t *testing.T) {
+	type rec struct {
+		Port  int           `regex:"port"`
+		User  string        `regex:"user,required"`
+		Retry time.Duration `regex:"retry"`
+		Host  string        `regex:"host"`
+	}
+	const pattern = `(?P<host>\w+):(?P<port>\w+) user=(?P<user>\w*) retry=(?P<retry>\w+)`
+	dec := rx.MustCompileWith[rec](pattern, rx.AggregateErrors())
+
+	got, err := dec.One("db:http user= retry=soon")
+	var errs *rx.DecodeErrors
+	if !errors.As(err, &errs) || len(errs.Errors) != 3 {
+		t.Fatalf("One() error = %v, want DecodeErrors with 3 elements", err)
+	}
+	// Failures come back in field order, each still recoverable by type.
+	var de *rx.DecodeError
+	var rge *rx.RequiredGroupError
+	if !errors.As(errs.Errors[0], &de) || de.Field != "Port" ||
+		!errors.As(errs.Errors[1], &rge) || rge.Field != "User" ||
+		!errors.As(errs.Errors[2], &de) || de.Field != "Retry" {
+		t.Errorf("Errors = %v, want Port, User, Retry failures in order", errs.Errors)
+	}
+	// errors.As through the aggregate finds the first match; errors.Is reaches
+	// the underlying conversion causes.
+	if !errors.As(err, &rge) || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("error %v: want RequiredGroupError and strconv.ErrSyntax reachable", err)
+	}
+	if !strings.HasPrefix(err.Error(), "regextra.Decoder.One: field Port: ") || strings.Count(err.Error(), "; ") != 2 {
+		t.Errorf("Error() = %q, want the three messages joined after the entrypoint prefix", err.Error())
+	}
+	// The fields that did decode are still set.
+	if got.Host != "db" {
+		t.Errorf("Host = %q, want db decoded alongside the failures", got.Host)
+	}
+
+	// Without the option the first failure is returned on its own.
+	_, err = rx.MustCompile[rec](pattern).One("db:http user= retry=soon")
+	if !errors.As(err, &de) || de.Field != "Port" || errors.As(err, &errs) {
+		t.Errorf("Compile One() error = %v, want a lone DecodeError on Port", err)
+	}
+	// A clean match returns nil, not an empty aggregate.
+	if _, err := dec.One("db:5432 user=alice retry=1s"); err != nil {
+		t.Errorf("One(clean) error = %v, want nil", err)
+	}
+}
+
+func TestDecodeErrors_emptyMessage(t *testing.T) {
+	if got := (&rx.DecodeErrors{}).Error(); got != "no decode errors" {
+		t.Errorf("Error() = %q, want %q", got, "no decode errors")
+	}
+}
+
+func TestCompileWith_errorsMatchCompile(t *testing.T) {
+	type rec struct {
+		Name string `regex:"name"`
+	}
+	if _, err := rx.CompileWith[rec](`(`, rx.AggregateErrors()); !errors.Is(err, rx.ErrInvalidPattern) {
+		t.Errorf("CompileWith(bad pattern) error = %v, want ErrInvalidPattern", err)
+	}
+	if _, err := rx.CompileWith[rec](`(?P<other>\w+)`); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("CompileWith(undeclared group) error = %v, want ErrInvalidStruct", err)
+	}
+}
+
+func ExampleAggregateErrors() {
+	type Conn struct {
+		Port    int `regex:"port"`
+		Timeout int `regex:"timeout"`
+	}
+	dec := rx.MustCompileWith[Conn](`port=(?P<port>\w+) timeout=(?P<timeout>\w+)`, rx.AggregateErrors())
+	_, err := dec.One("port=http timeout=never")
+	var errs *rx.DecodeErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs.Errors {
+			fmt.Println(e)
+		}
+	}
+	// Output:
+	// field Port: cannot convert "http" to int: strconv.ParseInt: parsing "http": invalid syntax
+	// field Timeout: cannot convert "never" to int: strconv.ParseInt: parsing "never": invalid syntax
+}
+
+// ── CompileWith: options ──────────────────────────────────────────────────────
+
+func TestCompileWith_tagKey(t *testing.T) {
+	type rec struct {
+		Host string `re:"h" regex:"host"`
+		Port int    `re:"p"`
+	}
+	dec := rx.MustCompileWith[rec](`(?P<h>\w+):(?P<p>\d+)`, rx.TagKey("re"))
+	got, err := dec.One("db:5432")
+	if err != nil || got != (rec{"db", 5432}) {
+		t.Fatalf("One() = %+v, %v; want {db 5432}", got, err)
+	}
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	if s, err := enc.Encode(got); err != nil || s != "db:5432" {
+		t.Errorf("Encode() = %q, %v; want db:5432 through the re key", s, err)
+	}
+	// Under the default key, Host's tag names a group the pattern lacks.
+	if _, err := rx.Compile[rec](`(?P<h>\w+):(?P<p>\d+)`); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("Compile() error = %v, want ErrInvalidStruct for the regex key", err)
+	}
+	if cfg := rx.MustCompileWith[rec](`(?P<host>\w+):(?P<port>\d+)`, rx.TagKey("")).Config(); cfg.TagKey != "regex" {
+		t.Errorf("TagKey(\"\") Config().TagKey = %q, want regex", cfg.TagKey)
+	}
+}
+
+func TestCompileWith_defaultLocation(t *testing.T) {
+	type rec struct {
+		At    time.Time   `regex:"at"`
+		Stamp []time.Time `regex:"stamp,layout=Jan 2 15:04 2006"`
+	}
+	est := time.FixedZone("EST", -5*3600)
+	dec := rx.MustCompileWith[rec](`at=(?P<at>[^;]+);(?: (?P<stamp>\w+ \d+ [\d:]+ \d+))?`, rx.DefaultLocation(est))
+	got, err := dec.One("at=2024-03-01 09:30:00; Mar 1 09:30 2024")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	want := time.Date(2024, 3, 1, 9, 30, 0, 0, est)
+	if !got.At.Equal(want) || got.At.Location() != est || len(got.Stamp) != 1 || !got.Stamp[0].Equal(want) {
+		t.Errorf("One() = %v, %v; want both read as %v", got.At, got.Stamp, want)
+	}
+	// An explicit offset wins over the default location.
+	got, err = dec.One("at=2024-03-01T09:30:00Z;")
+	if err != nil || !got.At.Equal(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("One(explicit Z) = %v, %v; want 09:30 UTC", got.At, err)
+	}
+	// Without the option the same text reads as UTC.
+	plain, _ := rx.MustCompile[rec](`at=(?P<at>[^;]+);(?: (?P<stamp>\w+ \d+ [\d:]+ \d+))?`).One("at=2024-03-01 09:30:00;")
+	if plain.At.Location() != time.UTC {
+		t.Errorf("Compile One().At location = %v, want UTC", plain.At.Location())
+	}
+}
+
+func TestCompileWith_fullMatch(t *testing.T) {
+	type rec struct {
+		N    int
+		Tags []string `regex:"tag"`
+	}
+	dec := rx.MustCompileWith[rec](`(?P<n>\d+)(?:,(?P<tag>\w+))*`, rx.FullMatch())
+	if _, err := dec.One("id 12,a"); !errors.Is(err, rx.ErrNoMatch) {
+		t.Errorf("One(partial) error = %v, want ErrNoMatch", err)
+	}
+	got, err := dec.One("12,a,b")
+	if err != nil || got.N != 12 || !reflect.DeepEqual(got.Tags, []string{"a", "b"}) {
+		t.Errorf("One(whole) = %+v, %v; want {12 [a b]}", got, err)
+	}
+	if all, err := dec.All("1 2"); err != nil || len(all) != 0 {
+		t.Errorf("All(two numbers) = %+v, %v; want no match", all, err)
+	}
+	// The wrapper keeps a top-level alternation inside the anchors.
+	type num struct{ N int }
+	alt := rx.MustCompileWith[num](`x|(?P<n>\d+)`, rx.FullMatch())
+	if got, err := alt.One("7"); err != nil || got.N != 7 {
+		t.Errorf("One(alternation) = %+v, %v; want N 7", got, err)
+	}
+	if dec.Regexp().String() != `(?P<n>\d+)(?:,(?P<tag>\w+))*` {
+		t.Errorf("Regexp() = %q, want the pattern as written", dec.Regexp())
+	}
+}
+
+func TestCompileWith_longestMatch(t *testing.T) {
+	type rec struct {
+		Word string `regex:"w"`
+	}
+	const pattern = `(?P<w>a|ab)`
+	dec := rx.MustCompileWith[rec](pattern, rx.LongestMatch())
+	if got, err := dec.One("ab"); err != nil || got.Word != "ab" {
+		t.Errorf("LongestMatch One() = %+v, %v; want ab", got, err)
+	}
+	if got, _ := rx.MustCompile[rec](pattern).One("ab"); got.Word != "a" {
+		t.Errorf("Compile One() = %+v, want leftmost-first a", got)
+	}
+	// The shared regexp keeps leftmost-first semantics.
+	if s := dec.Regexp().FindString("ab"); s != "a" {
+		t.Errorf("Regexp().FindString() = %q, want a", s)
+	}
+}
+
+func TestCompileWith_lenient(t *testing.T) {
+	type rec struct {
+		Name  string `regex:"name"`
+		Extra string `regex:"extra"`
+		Count int    `regex:"count,default=many"`
+	}
+	if _, err := rx.Compile[rec](`(?P<name>\w+)`); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Fatalf("Compile() error = %v, want ErrInvalidStruct", err)
+	}
+	dec, err := rx.CompileWith[rec](`(?P<name>\w+)(?: (?P<count>\d+))?`, rx.Lenient())
+	if err != nil {
+		t.Fatalf("CompileWith(Lenient) error = %v", err)
+	}
+	if got, err := dec.One("bob 3"); err != nil || got != (rec{Name: "bob", Count: 3}) {
+		t.Errorf("One() = %+v, %v; want Extra skipped", got, err)
+	}
+	// The unconvertible default surfaces at decode time instead.
+	var de *rx.DecodeError
+	if _, err := dec.One("bob"); !errors.As(err, &de) || de.Field != "Count" {
+		t.Errorf("One(default) error = %v, want a DecodeError on Count", err)
+	}
+	if _, err := rx.CompileWith[int](`(?P<n>\d+)`, rx.Lenient()); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("CompileWith[int](Lenient) error = %v, want ErrInvalidStruct", err)
+	}
+}
+
+func TestDecoder_Config(t *testing.T) {
+	type rec struct {
+		N int
+	}
+	if got, want := rx.MustCompile[rec](`(?P<n>\d+)`).Config(), (rx.DecoderConfig{TagKey: "regex"}); got != want {
+		t.Errorf("Compile Config() = %+v, want %+v", got, want)
+	}
+	dec := rx.MustCompileWith[rec](`(?P<n>\d+)`, rx.TagKey("rx"), rx.DefaultLocation(time.Local), rx.FullMatch(), rx.LongestMatch(), rx.Lenient(), rx.AggregateErrors(), rx.StrictTypes())
+	want := rx.DecoderConfig{TagKey: "rx", Location: time.Local, FullMatch: true, LongestMatch: true, Lenient: true, AggregateErrors: true, StrictTypes: true}
+	if got := dec.Config(); got != want {
+		t.Errorf("Config() = %+v, want %+v", got, want)
+	}
+}
+
+// ── CompileWith: StrictTypes ──────────────────────────────────────────────────
+
+type strictLevel int
+
+func (l *strictLevel) UnmarshalRegex(string) error { *l = 1; return nil }
+
+func TestCompileWith_strictTypes(t *testing.T) {
+	// Untagged, so each pattern below may declare just the group under test.
+	type rec struct {
+		Age   int
+		Port  *uint16
+		Ratio float64
+		OK    bool
+		Wait  time.Duration
+		IDs   []uint `regex:",split=,"`
+		Name  string
+		Level strictLevel
+		When  time.Time
+	}
+	const ok = `(?P<age>[-+]?\d+) (?P<port>\d{1,5}) (?P<ratio>-?\d+(?:\.\d+)?(?:e\d+)?|(?i:nan)) (?P<ok>true|false|1|0) ` +
+		`(?P<wait>\d+(?:ms|s|µs)) (?P<ids>\d+(?:,\d+)*) (?P<name>.+) (?P<level>\w+) (?P<when>\S+)`
+	dec, err := rx.CompileWith[rec](ok, rx.StrictTypes())
+	if err != nil {
+		t.Fatalf("CompileWith(StrictTypes) error = %v", err)
+	}
+	got, err := dec.One("-3 80 NaN true 5ms 1,2 a b hi 2024-01-02T03:04:05Z")
+	if err != nil || got.Age != -3 || *got.Port != 80 || got.Wait != 5*time.Millisecond || len(got.IDs) != 2 {
+		t.Errorf("One() = %+v, %v", got, err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		group   string
+		char    string
+	}{
+		{"letters into int", `(?P<age>\w+)`, "age", `"A"`},
+		{"dot into uint", `(?P<port>[\d.]+)`, "port", `"."`},
+		{"any into float", `(?P<ratio>.+)`, "ratio", `"\x00"`},
+		{"case-folded literal into bool", `(?P<ok>(?i)yes|no)`, "ok", `"N"`},
+		{"space into duration", `(?P<wait>[\d ]+s)`, "wait", `" "`},
+		{"separator outside split", `(?P<ids>[\d;]+)`, "ids", `";"`},
+		{"second occurrence", `(?P<age>\d+)|(?P<age>x)`, "age", `"x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rx.CompileWith[rec](tt.pattern, rx.StrictTypes())
+			if !errors.Is(err, rx.ErrInvalidStruct) {
+				t.Fatalf("CompileWith(StrictTypes) error = %v, want ErrInvalidStruct", err)
+			}
+			if msg := err.Error(); !strings.Contains(msg, `group "`+tt.group+`"`) || !strings.Contains(msg, "can match "+tt.char) {
+				t.Errorf("error = %q, want it to name group %q and %s", msg, tt.group, tt.char)
+			}
+			// The check is opt-in.
+			if _, err := rx.Compile[rec](tt.pattern); err != nil {
+				t.Errorf("Compile() error = %v, want nil without StrictTypes", err)
+			}
+			// Lenient skips Compile's validation, this check included.
+			if _, err := rx.CompileWith[rec](tt.pattern, rx.StrictTypes(), rx.Lenient()); err != nil {
+				t.Errorf("CompileWith(StrictTypes, Lenient) error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func ExampleStrictTypes() {
+	type Person struct {
+		Name string
+		Age  int
+	}
+	_, err := rx.CompileWith[Person](`(?P<name>\w+) is (?P<age>\w+)`, rx.StrictTypes())
+	fmt.Println(err)
+	// Output:
+	// regextra: invalid struct: field Age: group "age" can match "A", which is never valid in int
+}
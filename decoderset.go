@@ -0,0 +1,195 @@
+package regextra
+
+import (
+	"fmt"
+	"reflect"
+	"regexp/syntax"
+	"strings"
+)
+
+// DecoderSet is an ordered set of [Decoder]s sharing one destination type T.
+// [DecoderSet.One] tries them in priority order and decodes with the first
+// whose pattern matches, reporting which one it was — the typed replacement
+// for a hand-written loop over several Decoders checking [ErrNoMatch] after
+// each.
+//
+// Each pattern's literal prefix is extracted from its syntax tree at
+// construction time and used as a prefilter: a pattern whose prefix cannot
+// occur in the target (or, when the pattern is anchored with `^` / `\A`, does
+// not start it) is skipped without running the regex. Patterns with no literal
+// prefix are always tried.
+//
+// Every pattern is compiled strictly for the same T, so a tagged field must
+// name a group that every pattern declares. Leave fields that only some
+// formats carry untagged — they bind by field name where a pattern declares a
+// matching group and are skipped elsewhere. When the formats mean different
+// things, give T a discriminator field and branch on it, or on the index
+// [DecoderSet.One] returns.
+//
+// DecoderSets are safe for concurrent use, like the Decoders they hold.
+//
+// Use [CompileSet] / [MustCompileSet] to build one from patterns, or
+// [NewDecoderSet] to group Decoders that already exist.
+type DecoderSet[T any] struct {
+	decoders []*Decoder[T]
+
+	// filters holds the prefilter for decoders[i], computed once by
+	// NewDecoderSet.
+	filters []prefilter
+
+	zero T
+}
+
+// prefilter is a cheap necessary condition for a pattern to match: every match
+// begins with lit, and when anchored the match begins the target. An empty lit
+// admits everything.
+type prefilter struct {
+	lit      string
+	anchored bool
+}
+
+// admits reports whether target can possibly match the prefilter's pattern.
+func (p prefilter) admits(target string) bool {
+	switch {
+	case p.lit == "":
+		return true
+	case p.anchored:
+		return strings.HasPrefix(target, p.lit)
+	default:
+		return strings.Contains(target, p.lit)
+	}
+}
+
+// CompileSet compiles each pattern with [Compile] for T and groups the results
+// into a [DecoderSet], in priority order: patterns[0] is tried first. It fails
+// on the first pattern that does not compile, reporting its index; the error
+// wraps the same [ErrInvalidPattern] / [ErrInvalidStruct] sentinels Compile
+// uses. An empty pattern list is an error — a set that can never match is
+// almost certainly a bug.
+func CompileSet[T any](patterns ...string) (*DecoderSet[T], error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("regextra.CompileSet: no patterns")
+	}
+	decoders := make([]*Decoder[T], len(patterns))
+	for i, pattern := range patterns {
+		d, err := Compile[T](pattern)
+		if err != nil {
+			return nil, fmt.Errorf("regextra.CompileSet: pattern %d: %w", i, err)
+		}
+		decoders[i] = d
+	}
+	return NewDecoderSet(decoders...), nil
+}
+
+// MustCompileSet is like [CompileSet] but panics on error. Intended for
+// package-level var initialization.
+func MustCompileSet[T any](patterns ...string) *DecoderSet[T] {
+	s, err := CompileSet[T](patterns...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewDecoderSet groups already-compiled decoders into a [DecoderSet], in
+// priority order: decoders[0] is tried first. The set keeps the slice's
+// pointers, not a copy of the Decoders; nil entries are not allowed.
+func NewDecoderSet[T any](decoders ...*Decoder[T]) *DecoderSet[T] {
+	s := &DecoderSet[T]{
+		decoders: append([]*Decoder[T](nil), decoders...),
+		filters:  make([]prefilter, len(decoders)),
+	}
+	for i, d := range decoders {
+		s.filters[i] = patternPrefilter(d.pattern)
+	}
+	return s
+}
+
+// patternPrefilter derives a pattern's prefilter from its syntax tree: the run
+// of case-sensitive literals every match must start with, descending through
+// concatenations and capture groups, plus whether a leading `\A` (or `^`
+// outside multi-line mode) anchors it. The walk stops at the first construct
+// that is not a fixed literal — a class, a quantifier, an alternation, a
+// case-folded literal — so the result is always a necessary condition. A
+// pattern that fails to parse (impossible for a compiled Decoder) gets the
+// admit-everything filter.
+func patternPrefilter(pattern string) prefilter {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return prefilter{}
+	}
+	var (
+		b        strings.Builder
+		anchored bool
+	)
+	var walk func(re *syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpBeginText:
+			if b.Len() == 0 {
+				anchored = true
+			}
+			return true
+		case syntax.OpEmptyMatch:
+			return true
+		case syntax.OpLiteral:
+			if re.Flags&syntax.FoldCase != 0 {
+				return false
+			}
+			b.WriteString(string(re.Rune))
+			return true
+		case syntax.OpCapture:
+			return walk(re.Sub[0])
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if !walk(sub) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+	walk(re.Simplify())
+	return prefilter{lit: b.String(), anchored: anchored}
+}
+
+// One decodes target with the first decoder in the set whose pattern matches
+// it, returning the decoded value and that decoder's index (its position in
+// the [CompileSet] / [NewDecoderSet] arguments). Returns a zero T, -1, and
+// [ErrNoMatch] when no pattern matches.
+//
+// Priority is decided by matching alone: once a pattern matches, its decode
+// result is final. A conversion failure (a [DecodeError] or
+// [RequiredGroupError]) is returned with that pattern's index and the
+// partially-decoded T, rather than falling through to a lower-priority
+// pattern — a line that matches the wrong format is a bug worth surfacing.
+func (s *DecoderSet[T]) One(target string) (T, int, error) {
+	for i, d := range s.decoders {
+		if !s.filters[i].admits(target) {
+			continue
+		}
+		matches := d.matcher.FindStringSubmatchIndex(target)
+		if matches == nil {
+			continue
+		}
+		var v T
+		if err := d.decode(reflect.ValueOf(&v).Elem(), target, matches, 0); err != nil {
+			return v, i, fmt.Errorf("regextra.DecoderSet.One: pattern %d: %w", i, err)
+		}
+		return v, i, nil
+	}
+	return s.zero, -1, ErrNoMatch
+}
+
+// Len returns the number of decoders in the set.
+func (s *DecoderSet[T]) Len() int {
+	return len(s.decoders)
+}
+
+// Decoder returns the i'th decoder in priority order — e.g. the one a
+// [DecoderSet.One] index refers to, for its [Decoder.Pattern] in a log line.
+// It panics if i is out of range, like a slice index.
+func (s *DecoderSet[T]) Decoder(i int) *Decoder[T] {
+	return s.decoders[i]
+}
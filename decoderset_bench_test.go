@@ -0,0 +1,63 @@
+package regextra_test
+
+import (
+	"errors"
+	"testing"
+
+	rx "github.com/jecoms/regextra"
+)
+
+// ── DecoderSet.One ────────────────────────────────────────────────────────────
+//
+// A ten-format set in the shape of a real ingest: each format is anchored on a
+// distinct literal lead-in, so the literal-prefix prefilter rejects the
+// non-matching formats with a strings.HasPrefix instead of a regex run.
+// loopBaseline is the hand-written []*Decoder loop DecoderSet replaces, on the
+// same decoders and input; the gap is the prefilter's win.
+
+type benchSetEvent struct {
+	Kind string
+	User string
+	ID   int
+}
+
+var (
+	benchSetPatterns = []string{
+		`^login user=(?P<user>\w+) id=(?P<id>\d+)$`,
+		`^logout user=(?P<user>\w+)$`,
+		`^session (?P<kind>open|close) id=(?P<id>\d+)$`,
+		`^auth fail user=(?P<user>\w+)$`,
+		`^audit (?P<kind>\w+) by (?P<user>\w+)$`,
+		`^cron job=(?P<kind>\w+) id=(?P<id>\d+)$`,
+		`^http (?P<kind>GET|POST) user=(?P<user>\w+)$`,
+		`^db query id=(?P<id>\d+)$`,
+		`^cache miss key=(?P<kind>\w+)$`,
+		`^deploy (?P<kind>\w+) by (?P<user>\w+) id=(?P<id>\d+)$`,
+	}
+	benchSet      = rx.MustCompileSet[benchSetEvent](benchSetPatterns...)
+	benchSetFirst = "login user=alice id=7"
+	benchSetLast  = "deploy api by carol id=42"
+	benchSetNone  = "kernel: eth0 link up"
+)
+
+var benchSetDecoders = func() []*rx.Decoder[benchSetEvent] {
+	out := make([]*rx.Decoder[benchSetEvent], benchSet.Len())
+	for i := range out {
+		out[i] = benchSet.Decoder(i)
+	}
+	return out
+}()
+
+func BenchmarkDecoderSetOne(b *testing.B) {
+	benchCase(b, "firstPattern", func() { _, _, sinkErr = benchSet.One(benchSetFirst) })
+	benchCase(b, "lastPattern", func() { _, _, sinkErr = benchSet.One(benchSetLast) })
+	benchCase(b, "noMatch", func() { _, _, sinkErr = benchSet.One(benchSetNone) })
+	benchCase(b, "loopBaseline", func() {
+		for _, d := range benchSetDecoders {
+			if _, err := d.One(benchSetLast); !errors.Is(err, rx.ErrNoMatch) {
+				sinkErr = err
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	rx "github.com/jecoms/regextra"
+)
+
+// setEvent is one destination shape several line formats decode into; Kind is
+// the discriminator a caller branches on. Its fields are untagged so each
+// format may declare only the groups it carries.
+type setEvent struct {
+	Kind string
+	User string
+	ID   int
+}
+
+func TestDecoderSet_One_priorityOrder(t *testing.T) {
+	set := rx.MustCompileSet[setEvent](
+		`^(?P<kind>login) user=(?P<user>\w+) id=(?P<id>\d+)$`,
+		`^(?P<kind>logout) user=(?P<user>\w+)$`,
+		// Catch-all: lower priority than the specific formats above.
+		`^(?P<kind>\w+)`,
+	)
+	tests := []struct {
+		in      string
+		want    setEvent
+		wantIdx int
+	}{
+		{"login user=alice id=7", setEvent{"login", "alice", 7}, 0},
+		{"logout user=bob", setEvent{Kind: "logout", User: "bob"}, 1},
+		{"heartbeat", setEvent{Kind: "heartbeat"}, 2},
+	}
+	for _, tt := range tests {
+		got, idx, err := set.One(tt.in)
+		if err != nil {
+			t.Fatalf("One(%q) error = %v", tt.in, err)
+		}
+		if got != tt.want || idx != tt.wantIdx {
+			t.Errorf("One(%q) = %+v, %d; want %+v, %d", tt.in, got, idx, tt.want, tt.wantIdx)
+		}
+	}
+	if set.Len() != 3 || set.Decoder(2).Pattern() != `^(?P<kind>\w+)` {
+		t.Errorf("Len() = %d, Decoder(2).Pattern() = %q; want 3 and the catch-all", set.Len(), set.Decoder(2).Pattern())
+	}
+}
+
+func TestDecoderSet_One_noMatch(t *testing.T) {
+	set := rx.MustCompileSet[setEvent](`^login (?P<user>\w+)`, `^logout (?P<user>\w+)`)
+	got, idx, err := set.One("reboot now")
+	if !errors.Is(err, rx.ErrNoMatch) || idx != -1 || got != (setEvent{}) {
+		t.Errorf("One(no match) = %+v, %d, %v; want zero, -1, ErrNoMatch", got, idx, err)
+	}
+}
+
+// A decode failure in the first matching pattern is final: it is reported with
+// that pattern's index instead of falling through to a later pattern.
+func TestDecoderSet_One_decodeErrorDoesNotFallThrough(t *testing.T) {
+	set := rx.MustCompileSet[setEvent](`id=(?P<id>\S+)`, `(?P<user>\w+)`)
+	_, idx, err := set.One("id=abc")
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Field != "ID" || idx != 0 {
+		t.Errorf("One(bad id) = idx %d, err %v; want idx 0 and a DecodeError on ID", idx, err)
+	}
+}
+
+// The literal-prefix prefilter only skips patterns that cannot match, so every
+// shape of literal lead-in still matches wherever the regex itself would.
+func TestDecoderSet_prefilterNeverHidesAMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		in      string
+	}{
+		{`user=(?P<user>\w+)`, "ts=1 user=alice"},           // unanchored: literal mid-target
+		{`^user=(?P<user>\w+)`, "user=alice"},               // anchored literal
+		{`(?P<kind>GET) (?P<user>\w+)`, "> GET alice"},      // literal inside a capture
+		{`(?i)get (?P<user>\w+)`, "GET alice"},              // case-folded: no usable prefix
+		{`(?:a|b)c(?P<user>\w+)`, "bcalice"},                // alternation ends the prefix
+		{`(?m)^user=(?P<user>\w+)`, "ts=1\nuser=alice"},     // multi-line ^ is not a text anchor
+		{`x*user=(?P<user>\w+)`, "user=alice"},              // quantifier ends the prefix
+		{`\Qa.b\E=(?P<user>\w+)`, "a.b=alice"},              // quoted literal
+		{`(?P<user>ab)(?:)cd`, "zabcd"},                     // empty group inside the run
+		{`^(?:user=)(?P<user>\w+)`, "user=alice"},           // non-capturing literal group
+		{`\Auser=(?P<user>\w+)`, "user=alice"},              // explicit \A anchor
+		{`a{2}(?P<user>\w+)`, "aaalice"},                    // repeat simplifies to literals
+		{`(?s)user=(?P<user>.+)`, "user=al\nice"},           // flags without an anchor
+		{`(?P<user>\w+)@example\.com`, "alice@example.com"}, // no leading literal
+	}
+	for _, tt := range tests {
+		set := rx.MustCompileSet[setEvent](tt.pattern)
+		dec := rx.MustCompile[setEvent](tt.pattern)
+		want, wantErr := dec.One(tt.in)
+		got, idx, err := set.One(tt.in)
+		if wantErr != nil || err != nil || got != want || idx != 0 {
+			t.Errorf("pattern %q on %q: set = %+v, %d, %v; decoder = %+v, %v", tt.pattern, tt.in, got, idx, err, want, wantErr)
+		}
+	}
+}
+
+func TestCompileSet_errors(t *testing.T) {
+	if _, err := rx.CompileSet[setEvent](); err == nil {
+		t.Error("CompileSet() with no patterns succeeded, want error")
+	}
+	_, err := rx.CompileSet[setEvent](`(?P<user>\w+)`, `(?P<user>`)
+	if !errors.Is(err, rx.ErrInvalidPattern) || !strings.Contains(err.Error(), "pattern 1") {
+		t.Errorf("CompileSet(bad second pattern) error = %v, want ErrInvalidPattern naming pattern 1", err)
+	}
+	type tagged struct {
+		User string `regex:"user"`
+	}
+	_, err = rx.CompileSet[tagged](`(?P<user>\w+)`, `(?P<name>\w+)`)
+	if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), "pattern 1") {
+		t.Errorf("CompileSet(tag undeclared in pattern 1) error = %v, want ErrInvalidStruct naming pattern 1", err)
+	}
+}
+
+func TestMustCompileSet_panicsOnBadPattern(t *testing.T) {
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok || !errors.Is(err, rx.ErrInvalidPattern) {
+			t.Errorf("MustCompileSet panic = %v, want an ErrInvalidPattern error", r)
+		}
+	}()
+	rx.MustCompileSet[setEvent](`(`)
+}
+
+func TestNewDecoderSet_groupsExistingDecoders(t *testing.T) {
+	login := rx.MustCompile[setEvent](`^(?P<kind>login) (?P<user>\w+)`)
+	logout := rx.MustCompile[setEvent](`^(?P<kind>logout) (?P<user>\w+)`)
+	set := rx.NewDecoderSet(login, logout)
+	got, idx, err := set.One("logout carol")
+	if err != nil || idx != 1 || got.User != "carol" || set.Decoder(idx) != logout {
+		t.Errorf("One(logout) = %+v, %d, %v; want carol from the logout decoder", got, idx, err)
+	}
+}
+
+func ExampleDecoderSet() {
+	type Event struct {
+		Kind string // untagged: bound by name in every format that declares it
+		User string
+	}
+	set := rx.MustCompileSet[Event](
+		`^(?P<kind>login) user=(?P<user>\w+)`,
+		`^(?P<kind>logout) (?P<user>\w+)`,
+	)
+	for _, line := range []string{"login user=alice", "logout bob", "reboot"} {
+		e, i, err := set.One(line)
+		if err != nil {
+			fmt.Printf("%q: %v\n", line, err)
+			continue
+		}
+		fmt.Printf("pattern %d: %s %s\n", i, e.Kind, e.User)
+	}
+	// Output:
+	// pattern 0: login alice
+	// pattern 1: logout bob
+	// "reboot": regextra: no match
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"regexp/syntax"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,18 +15,29 @@ import (
 
 // ErrNotInvertible categorizes a [Decoder.Encoder] failure where the decoder's
 // pattern contains a construct that has no single string to emit when encoding —
-// an alternation (`|`), a quantifier (`*`, `+`, `?`, `{n,m}`), a character class
-// (`[...]`), an any-character wildcard (`.`), or an unnamed group with non-literal
-// content — appearing outside a named capture group. Callers can branch on the
-// failure kind with errors.Is rather than parsing the message. Like [ErrNoMatch]
-// and [ErrInvalidPattern], it carries the bare `regextra:` prefix reserved for
-// package-level sentinels.
+// a quantifier around no named capture, a repetition whose named captures do
+// not all map to list fields, a character class (`[...]`), an any-character
+// wildcard (`.`), or an unnamed group with non-literal content — appearing
+// outside a named capture group, or in any branch of an alternation. Callers
+// can branch on the failure kind with errors.Is rather than parsing the
+// message. Like [ErrNoMatch] and [ErrInvalidPattern], it carries the bare
+// `regextra:` prefix reserved for package-level sentinels.
 //
 // Inside a named capture group such constructs are fine: the struct field's value
 // fills the group, so the sub-pattern describing what the group matches is
-// irrelevant to encoding.
+// irrelevant to encoding. An optional `?` section that itself contains a named
+// capture is fine too — its fields decide whether it is emitted — as are an
+// alternation whose branches are each invertible and a repetition over list
+// fields (see [Encoder]).
 var ErrNotInvertible = errors.New("regextra: pattern is not invertible")
 
+// ErrValueMismatch is wrapped by the [EncodeError] that [Encoder.EncodeStrict]
+// returns when a field's rendered value does not fully match the sub-pattern of
+// the capture group it fills — a value that [Decoder.One] could never parse
+// back into the same field. Compare with errors.Is. Like [ErrNotInvertible], it
+// carries the bare `regextra:` prefix reserved for package-level sentinels.
+var ErrValueMismatch = errors.New("regextra: value does not match its group's pattern")
+
 // Encoder is the typed inverse of [Decoder]: it renders a value of T back into a
 // string so that an Encode followed by an [Unmarshal] / [Decoder.One] on the same
 // pattern round-trips the original struct. Construct one with [Decoder.Encoder],
@@ -43,16 +56,52 @@ var ErrNotInvertible = errors.New("regextra: pattern is not invertible")
 //     resolves to a struct field with the same rules [Decoder] uses — the field's
 //     `regex:"name"` tag matched exactly, otherwise the field's own name matched
 //     exactly then case-insensitively via Unicode simple-fold; a `regex:"-"` field
-//     is excluded. The group's sub-pattern is discarded — the field's value fills
-//     the span.
+//     is excluded, and embedded / `prefix`-flagged structs contribute their
+//     fields under the same names the decoder gives them. The group's
+//     sub-pattern is discarded — the field's value fills the span.
 //   - Anchors and zero-width assertions (`^`, `$`, `\A`, `\z`, `\b`, …) match no
 //     text and are dropped.
 //   - An unnamed group whose body is pure literal text is treated as that literal.
+//   - An optional section — `(?:…)?`, `(?:…)??`, or `(?:…){0,1}` — that contains
+//     a named capture is inverted recursively and emitted only when one of its
+//     fields is present. A field is absent when it is a nil pointer (or sits
+//     behind a nil embedded / `prefix` struct pointer), or when it is tagged
+//     `omitempty` and holds its empty value — zero, or no elements for a slice.
+//     Any other field is always present, so a section holding a plain
+//     non-pointer field without `omitempty` is always emitted. Dropping a
+//     section leaves its fields to decode as absent, so nil pointers and
+//     `omitempty` zeros round-trip.
+//   - An alternation `(?:a|b)` inverts each branch, and Encode emits the first
+//     branch whose fields are all set — present as above and non-zero, with or
+//     without `omitempty`, since a zero value says nothing about which shape
+//     was meant. So `(?:id=(?P<id>\d+)|name=(?P<name>\w+))` emits `id=…` when
+//     ID is set and `name=…` otherwise. A branch with no named capture is
+//     always set: a pure-literal alternation outside any capture, like
+//     `(?:GET|HEAD) `, emits its first branch. When no branch is set, Encode
+//     fails with an [EncodeError] on the first branch's first field.
+//   - A named capture wrapping a literal alternation, like
+//     `(?P<method>GET|POST)`, binds its field to that set: EncodeStrict fails
+//     with an [EncodeError] wrapping [ErrValueMismatch] when the field's value
+//     is not one of the literals, since it could never re-decode.
+//   - A list field — a slice or array without `split=` — fills each occurrence
+//     of its group with its next element, the way [Decoder] collects one
+//     element per occurrence. A `*`, `+`, or `{n,m}` repetition whose named
+//     captures all map to list fields emits its body once per remaining
+//     element, so `(?P<item>\w+)(?:,(?P<item>\w+))*` encodes []string{"a",
+//     "b", "c"} as `a,b,c`. Several list fields in one body advance together
+//     and must have matching lengths. Encode fails with an [EncodeError] when
+//     the count falls outside the repetition's bounds, when an occurrence
+//     outside a repetition finds the list exhausted, or when elements are left
+//     over with no place in the pattern.
 //
-// Any construct with no single string to emit — an alternation, a quantifier, a
+// Any construct with no single string to emit — a quantifier around no named
+// capture, a repetition around a named capture whose field is not a list, a
 // character class, an any-character wildcard, or an unnamed group with
-// non-literal content — appearing outside a named capture group makes the pattern
-// non-invertible, and [Decoder.Encoder] fails fast with [ErrNotInvertible].
+// non-literal content — appearing outside a named capture group (including
+// inside an alternation branch) makes the pattern non-invertible, and
+// [Decoder.Encoder] fails fast with [ErrNotInvertible]. So does an
+// alternation or quantifier holding a named capture inside a repetition,
+// which would need a per-iteration choice the lists cannot express.
 //
 // [Decoder.Encoder] builds the plan once; [Encoder.Encode] walks it and
 // concatenates with a strings.Builder. It still reflects on the value each call
@@ -66,14 +115,21 @@ var ErrNotInvertible = errors.New("regextra: pattern is not invertible")
 // sub-patterns in the decode regex (a captured word wants `\S+`, not `.*`).
 // Values that collide with a surrounding literal delimiter, or two adjacent
 // captures with no literal between them, have no unambiguous decode boundary and
-// are out of scope. (A future option is to re-match each encoded value against
-// its group's sub-pattern at Encode time; that is deliberately not done here.)
+// are out of scope. Encode trusts the caller's pairing; [Encoder.EncodeStrict]
+// checks it, re-matching each rendered value against its group's sub-pattern.
 //
 // Encoders are safe for concurrent use — no shared mutable state after
 // construction.
 type Encoder[T any] struct {
 	rtype    reflect.Type
 	segments []encodeSegment
+	// lists holds one field segment per distinct list field of the plan,
+	// indexed by its encodeSegment.list number, so Encode can report elements
+	// the pattern left no place for.
+	lists []encodeSegment
+	// cfg is the configuration of the Decoder the Encoder was derived from;
+	// its Converters render registered types.
+	cfg DecoderConfig
 }
 
 // encodeSegment is one piece of a derived encode plan: either a literal run
@@ -84,15 +140,56 @@ type encodeSegment struct {
 	// field reports whether this segment substitutes a field (true) or emits
 	// literal text (false).
 	field bool
-	// fieldIndex is the index into T's struct fields (StructField.Index[0]),
-	// valid only when field is true.
-	fieldIndex int
+	// index is the field's index path from T (see fieldDecoder.index), valid
+	// only when field is true.
+	index []int
 	// name is the capture-group name the segment resolved from, retained for
 	// EncodeError.Group. Valid only when field is true.
 	name string
 	// opts is the parsed tag options map for the field (e.g. {"layout": "..."}).
 	// Nil if the field has no options.
 	opts map[string]string
+	// transforms holds the field's value transforms, undone by
+	// reverseTransforms on each encoded value. Nil if the tag has none.
+	transforms []valueTransform
+	// conv is the registered encode function for the field's values (its
+	// elements, for a list), resolved when the Encoder is derived.
+	conv encodeConverter
+	// omitempty is set by the field's `omitempty` flag: inside an optional
+	// section, a zero value counts as absent.
+	omitempty bool
+	// optional holds the sub-plan of a `?`-quantified section containing a
+	// named capture; when non-nil, literal and field are unused. The section is
+	// emitted only when one of its fields is present (see sectionPresent).
+	optional []encodeSegment
+	// alternatives holds one sub-plan per branch of an alternation, in
+	// pattern order; when non-nil, literal and field are unused. Encode emits
+	// the first branch whose fields are all set (see branchSet).
+	alternatives [][]encodeSegment
+	// choices is the finite set of strings a field segment's group can match
+	// when its sub-pattern is a literal alternation such as `GET|POST`;
+	// EncodeStrict rejects a value outside it. Nil otherwise.
+	choices []string
+	// sub is the group's parsed sub-pattern, from which compileGroupMatchers
+	// builds match.
+	sub *syntax.Regexp
+	// match is the field segment's group sub-pattern compiled whole-string
+	// anchored (`\A(?:…)\z`), used by EncodeStrict. Valid only when field is
+	// true.
+	match *regexp.Regexp
+	// element is set on a field segment whose field is a list — a slice or
+	// array without `split=`. Each such segment emits the field's next
+	// element, so a reused group name or a repetition spreads the list across
+	// the pattern. list numbers the field among the plan's list fields (see
+	// encodeState.next).
+	element bool
+	list    int
+	// repeated holds the body of a `*`, `+`, or `{n,m}` repetition whose named
+	// captures all map to list fields; when non-nil, literal and field are
+	// unused. Encode emits the body once per remaining element, which must lie
+	// within min and max (-1 for unbounded).
+	repeated []encodeSegment
+	min, max int
 }
 
 // RegexMarshaler is the interface implemented by types that render themselves
@@ -143,7 +240,8 @@ var (
 // or [encoding.TextMarshaler], or a nil-pointer field) and is reachable via
 // [errors.Is]/[errors.As] through Unwrap.
 type EncodeError struct {
-	// Field is the source struct field name.
+	// Field is the source struct field name, as a selector path for a nested
+	// field (see [DecodeError].Field).
 	Field string
 	// Group is the capture-group name the field resolved from: the field's
 	// `regex:"..."` tag name when set, otherwise the declared group whose name
@@ -168,16 +266,18 @@ func (e *EncodeError) Unwrap() error { return e.Err }
 
 // Encoder derives the typed inverse of d by inverting d's compiled pattern: it
 // parses the pattern's AST and walks the invertible subset (literal runs, named
-// capture groups, anchors, pure-literal unnamed groups) into an ordered encode
-// plan. Named capture groups resolve to struct fields with the same field-mapping
-// rules [Decoder] uses. Write the pattern once and get the encoder for free —
+// capture groups, anchors, pure-literal unnamed groups, `?` sections holding a
+// named capture, alternations of invertible branches, and repetitions over
+// list fields) into an ordered encode plan. Named capture groups resolve to struct fields with the same
+// field-mapping rules [Decoder] uses. Write the pattern once and get the encoder for free —
 // there is no separate template to keep in sync.
 //
 // Returns an error if:
 //   - the pattern contains a construct that is not invertible outside a named
-//     capture group — an alternation (`|`), a quantifier (`*`, `+`, `?`,
-//     `{n,m}`), a character class (`[...]`), an any-character wildcard (`.`), or
-//     an unnamed group with non-literal content — wrapping [ErrNotInvertible]
+//     capture group — a quantifier around no named capture, a repetition
+//     around a named capture whose field is not a list, a character class
+//     (`[...]`), an any-character wildcard (`.`), or an unnamed group with
+//     non-literal content — wrapping [ErrNotInvertible]
 //   - a named capture group maps to no exported, non-excluded field of T
 //   - a mapped field's type cannot be encoded (see [Encoder] for the supported
 //     set)
@@ -185,7 +285,9 @@ func (e *EncodeError) Unwrap() error { return e.Err }
 // The latter two wrap [ErrInvalidStruct], mirroring [Compile]. Once Encoder
 // returns nil, the resulting Encoder is fully validated: the only errors
 // [Encoder.Encode] can then surface are runtime value failures (a custom
-// marshaler returning an error, or a nil pointer field).
+// marshaler returning an error, a nil pointer field, no alternation branch
+// with its fields set, or a list whose length the pattern cannot hold), plus,
+// under [Encoder.EncodeStrict], a value its group rejects.
 func (d *Decoder[T]) Encoder() (*Encoder[T], error) {
 	var zero T
 	rt := reflect.TypeOf(zero)
@@ -200,24 +302,117 @@ func (d *Decoder[T]) Encoder() (*Encoder[T], error) {
 		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
 	}
 
-	var sb encodeSegmentBuilder
+	sb := encodeSegmentBuilder{cfg: &d.cfg}
 	if err := walkEncodeAST(rt, ast, &sb); err != nil {
 		return nil, err
 	}
 	sb.flushLiteral()
+	if err := compileGroupMatchers(sb.segments); err != nil {
+		return nil, err
+	}
 
 	return &Encoder[T]{
 		rtype:    rt,
 		segments: sb.segments,
+		lists:    numberLists(sb.segments, nil),
+		cfg:      d.cfg,
 	}, nil
 }
 
+// ReplaceAll rewrites every match of d's pattern in target in place: it
+// decodes the match into a T, passes it to fn, encodes fn's result with the
+// Encoder derived from d (see [Decoder.Encoder]), and splices the encoding
+// over the match, leaving the text between matches unchanged. It is the
+// decode-tweak-encode loop of a job like "rewrite one field of every log
+// record" in one call:
+//
+//	out, err := dec.ReplaceAll(logText, func(e Entry) (Entry, error) {
+//	    e.User = "redacted"
+//	    return e, nil
+//	})
+//
+// Every match is re-encoded, including one fn returns unchanged, so a value
+// whose encoding differs from its matched text (a zero-padded number, a
+// timestamp in another layout) is normalized.
+//
+// On the first failure ReplaceAll stops and returns target unchanged with the
+// error: a [DecodeError] or [RequiredGroupError] from decoding, fn's own error,
+// or an [EncodeError] from encoding — each wrapped with the match's index — or,
+// before any match is processed, the [ErrNotInvertible] or [ErrInvalidStruct]
+// error from deriving the Encoder. On no match it returns target unchanged
+// and never calls fn.
+func (d *Decoder[T]) ReplaceAll(target string, fn func(T) (T, error)) (string, error) {
+	allMatches := d.matcher.FindAllStringSubmatchIndex(target, -1)
+	if len(allMatches) == 0 {
+		return target, nil
+	}
+	d.encOnce.Do(func() { d.enc, d.encErr = d.Encoder() })
+	if d.encErr != nil {
+		return target, fmt.Errorf("regextra.Decoder.ReplaceAll: %w", d.encErr)
+	}
+	enc := d.enc
+	var b strings.Builder
+	cursor := 0
+	for i, matches := range allMatches {
+		var v T
+		if err := d.decode(reflect.ValueOf(&v).Elem(), target, matches, i); err != nil {
+			return target, fmt.Errorf("regextra.Decoder.ReplaceAll: match %d: %w", i, err)
+		}
+		v, err := fn(v)
+		if err != nil {
+			return target, fmt.Errorf("regextra.Decoder.ReplaceAll: match %d: %w", i, err)
+		}
+		s, err := enc.encode(v, false)
+		if err != nil {
+			return target, fmt.Errorf("regextra.Decoder.ReplaceAll: match %d: %w", i, err)
+		}
+		b.WriteString(target[cursor:matches[0]])
+		b.WriteString(s)
+		cursor = matches[1]
+	}
+	b.WriteString(target[cursor:])
+	return b.String(), nil
+}
+
+// numberLists assigns each list-field segment of a plan (recursing like
+// compileGroupMatchers) the number of its field among the plan's list fields,
+// so every occurrence of one field shares a cursor. It returns lists extended
+// with the first segment of each newly numbered field.
+func numberLists(segments []encodeSegment, lists []encodeSegment) []encodeSegment {
+	for i := range segments {
+		seg := &segments[i]
+		switch {
+		case seg.optional != nil:
+			lists = numberLists(seg.optional, lists)
+		case seg.repeated != nil:
+			lists = numberLists(seg.repeated, lists)
+		case seg.alternatives != nil:
+			for _, branch := range seg.alternatives {
+				lists = numberLists(branch, lists)
+			}
+		case seg.element:
+			seg.list = slices.IndexFunc(lists, func(l encodeSegment) bool { return slices.Equal(l.index, seg.index) })
+			if seg.list < 0 {
+				seg.list = len(lists)
+				lists = append(lists, *seg)
+			}
+		}
+	}
+	return lists
+}
+
 // encodeSegmentBuilder accumulates the derived encode plan, coalescing adjacent
 // literal runs into one segment (an anchor dropped between two literals, for
 // example, leaves them contiguous) so Encode walks the minimal segment list.
 type encodeSegmentBuilder struct {
 	segments []encodeSegment
 	lit      strings.Builder
+	// inRepeat is set while building the body of a repetition, where every
+	// named capture must map to a list field and nothing inside may need a
+	// per-iteration presence decision.
+	inRepeat bool
+	// cfg is the Decoder's configuration: its tag key and converters.
+	cfg *DecoderConfig
 }
 
 func (sb *encodeSegmentBuilder) writeLiteral(s string) { sb.lit.WriteString(s) }
@@ -234,6 +429,21 @@ func (sb *encodeSegmentBuilder) addField(seg encodeSegment) {
 	sb.segments = append(sb.segments, seg)
 }
 
+func (sb *encodeSegmentBuilder) addOptional(sub []encodeSegment) {
+	sb.flushLiteral()
+	sb.segments = append(sb.segments, encodeSegment{optional: sub})
+}
+
+func (sb *encodeSegmentBuilder) addAlternatives(alts [][]encodeSegment) {
+	sb.flushLiteral()
+	sb.segments = append(sb.segments, encodeSegment{alternatives: alts})
+}
+
+func (sb *encodeSegmentBuilder) addRepeated(body []encodeSegment, minN, maxN int) {
+	sb.flushLiteral()
+	sb.segments = append(sb.segments, encodeSegment{repeated: body, min: minN, max: maxN})
+}
+
 // walkEncodeAST inverts one node of a regexp/syntax AST into the encode plan,
 // recursing over concatenations. It drops anchors and zero-width assertions,
 // emits literals verbatim, turns named captures into field substitutions, and
@@ -262,9 +472,61 @@ func walkEncodeAST(rt reflect.Type, re *syntax.Regexp, sb *encodeSegmentBuilder)
 		// Anchors and zero-width assertions match no text — nothing to emit.
 		return nil
 	case syntax.OpAlternate:
-		return notInvertibleError("an alternation (`|`)")
-	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
-		return notInvertibleError("a quantifier (`*`, `+`, `?`, or `{n,m}`)")
+		// Each branch is inverted on its own; Encode picks the first whose
+		// fields are all set. A branch with no named capture is always set,
+		// so a pure-literal alternation emits its first branch — a valid
+		// match that carries no field data. Every branch must be invertible.
+		if sb.inRepeat && hasNamedCapture(re) {
+			return notInvertibleError("an alternation holding a named capture inside a repetition")
+		}
+		alts := make([][]encodeSegment, len(re.Sub))
+		for i, sub := range re.Sub {
+			bsb := encodeSegmentBuilder{inRepeat: sb.inRepeat, cfg: sb.cfg}
+			if err := walkEncodeAST(rt, sub, &bsb); err != nil {
+				return err
+			}
+			bsb.flushLiteral()
+			alts[i] = bsb.segments
+		}
+		sb.addAlternatives(alts)
+		return nil
+	case syntax.OpQuest, syntax.OpRepeat, syntax.OpStar, syntax.OpPlus:
+		// Without a named capture there is no field to decide by, so any
+		// quantifier is rejected.
+		if !hasNamedCapture(re.Sub[0]) {
+			return notInvertibleError("a quantifier (`*`, `+`, `?`, or `{n,m}`)")
+		}
+		if sb.inRepeat {
+			return notInvertibleError("a quantifier holding a named capture inside a repetition")
+		}
+		// An optional section (`?`, `??`, or `{0,1}`) is emitted or dropped
+		// by whether its fields are present; dropping it is always a valid
+		// match of the quantifier.
+		if re.Op == syntax.OpQuest || (re.Op == syntax.OpRepeat && re.Min == 0 && re.Max == 1) {
+			sub := encodeSegmentBuilder{cfg: sb.cfg}
+			if err := walkEncodeAST(rt, re.Sub[0], &sub); err != nil {
+				return err
+			}
+			sub.flushLiteral()
+			sb.addOptional(sub.segments)
+			return nil
+		}
+		// Any other repetition emits its body once per element of the list
+		// fields it fills.
+		minN, maxN := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			minN, maxN = 0, -1
+		case syntax.OpPlus:
+			minN, maxN = 1, -1
+		}
+		body := encodeSegmentBuilder{inRepeat: true, cfg: sb.cfg}
+		if err := walkEncodeAST(rt, re.Sub[0], &body); err != nil {
+			return err
+		}
+		body.flushLiteral()
+		sb.addRepeated(body.segments, minN, maxN)
+		return nil
 	case syntax.OpCharClass:
 		return notInvertibleError("a character class (`[...]`)")
 	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
@@ -287,22 +549,168 @@ func walkCapture(rt reflect.Type, re *syntax.Regexp, sb *encodeSegmentBuilder) e
 		sb.writeLiteral(s)
 		return nil
 	}
-	idx, opts, ok := resolveEncodeField(rt, re.Name)
+	lf, ok := resolveEncodeField(rt, sb.cfg, re.Name)
 	if !ok {
 		return fmt.Errorf("%w: capture group %q maps to no exported field of %v", ErrInvalidStruct, re.Name, rt)
 	}
-	if err := validateEncodeField(rt.Field(idx)); err != nil {
+	if err := validateEncodeField(rt, lf, sb.cfg); err != nil {
 		return err
 	}
-	sb.addField(encodeSegment{
+	seg := encodeSegment{
 		field:      true,
-		fieldIndex: idx,
+		index:      lf.index,
 		name:       re.Name,
-		opts:       opts,
-	})
+		opts:       lf.tag.opts,
+		transforms: lf.tag.transforms,
+		omitempty:  lf.tag.omitempty,
+		sub:        re.Sub[0],
+		element:    isListField(lf, sb.cfg),
+	}
+	if seg.element {
+		seg.conv = sb.cfg.resolveEncodeConverter(lf.field.Type.Elem(), nil)
+	} else {
+		seg.conv = sb.cfg.resolveEncodeConverter(lf.field.Type, lf.tag.opts)
+	}
+	if sb.inRepeat && !seg.element {
+		return notInvertibleError(fmt.Sprintf("a repetition around group %q (field %s is not a list: a slice or array without `split=`)", re.Name, fieldPath(rt, lf.index)))
+	}
+	// A named wrapper around a literal alternation — `(?P<method>GET|POST)` —
+	// binds the field to that set of literals, so EncodeStrict rejects a value
+	// outside it, which could never re-decode. Only a parsed alternation
+	// binds: a character class such as `\d` is left to the group's matcher.
+	if sub := re.Sub[0]; sub.Op == syntax.OpAlternate {
+		if choices, ok := literalChoices(sub, maxLiteralChoices); ok {
+			seg.choices = choices
+		}
+	}
+	sb.addField(seg)
+	return nil
+}
+
+// compileGroupMatchers compiles, for every field segment of a plan (recursing
+// into optional sections, repetitions, and alternation branches), the
+// whole-string-anchored matcher EncodeStrict checks values against, from the
+// group's parsed sub-pattern printed back to source by patternString. The
+// printed form carries the parse flags in effect at the group — an outer
+// `(?i)`, say — so the copy matches exactly what the group would.
+func compileGroupMatchers(segments []encodeSegment) error {
+	for i := range segments {
+		seg := &segments[i]
+		switch {
+		case seg.optional != nil:
+			if err := compileGroupMatchers(seg.optional); err != nil {
+				return err
+			}
+		case seg.repeated != nil:
+			if err := compileGroupMatchers(seg.repeated); err != nil {
+				return err
+			}
+		case seg.alternatives != nil:
+			for _, branch := range seg.alternatives {
+				if err := compileGroupMatchers(branch); err != nil {
+					return err
+				}
+			}
+		case seg.field:
+			match, err := regexp.Compile(`\A(?:` + patternString(seg.sub) + `)\z`)
+			if err != nil {
+				// Unreachable for a sub-pattern of a pattern that compiled.
+				return fmt.Errorf("%w: group %q: %w", ErrInvalidPattern, seg.name, err)
+			}
+			seg.match = match
+		}
+	}
 	return nil
 }
 
+// maxLiteralChoices caps the literal set literalChoices enumerates; a larger
+// language is treated as unrestricted rather than materialized.
+const maxLiteralChoices = 64
+
+// literalChoices reports whether re matches only a finite set of fixed strings
+// — literals combined by concatenation, alternation, `?`, small character
+// classes (which the parser makes of single-rune alternatives like `a|b`), and
+// unnamed groups — returning that set when it has at most limit members.
+// Case-folded literals and any unbounded construct make it unrestricted.
+func literalChoices(re *syntax.Regexp, limit int) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+	case syntax.OpEmptyMatch,
+		syntax.OpBeginLine, syntax.OpBeginText,
+		syntax.OpEndLine, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return []string{""}, true
+	case syntax.OpCharClass:
+		var out []string
+		for i := 0; i < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(out) == limit {
+					return nil, false
+				}
+				out = append(out, string(r))
+			}
+		}
+		return out, true
+	case syntax.OpCapture:
+		if re.Name != "" {
+			return nil, false
+		}
+		return literalChoices(re.Sub[0], limit)
+	case syntax.OpQuest:
+		sub, ok := literalChoices(re.Sub[0], limit-1)
+		if !ok {
+			return nil, false
+		}
+		return append([]string{""}, sub...), true
+	case syntax.OpAlternate:
+		var out []string
+		for _, sub := range re.Sub {
+			c, ok := literalChoices(sub, limit-len(out))
+			if !ok {
+				return nil, false
+			}
+			out = append(out, c...)
+		}
+		return out, true
+	case syntax.OpConcat:
+		out := []string{""}
+		for _, sub := range re.Sub {
+			c, ok := literalChoices(sub, limit)
+			if !ok || len(out)*len(c) > limit {
+				return nil, false
+			}
+			next := make([]string, 0, len(out)*len(c))
+			for _, head := range out {
+				for _, tail := range c {
+					next = append(next, head+tail)
+				}
+			}
+			out = next
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+// hasNamedCapture reports whether re contains a named capture group anywhere
+// in its subtree.
+func hasNamedCapture(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpCapture && re.Name != "" {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasNamedCapture(sub) {
+			return true
+		}
+	}
+	return false
+}
+
 // literalString reports whether re reduces to a fixed literal string with no
 // variable-matching content, returning that string. Literals concatenate,
 // zero-width assertions contribute nothing, and a nested unnamed group recurses;
@@ -344,65 +752,45 @@ func notInvertibleError(construct string) error {
 	return fmt.Errorf("%w: contains %s outside a named capture group", ErrNotInvertible, construct)
 }
 
-// resolveEncodeField maps a capture-group name to an exported, non-excluded field
-// of rt, using the same field-mapping rules the decode side applies: a field's
-// `regex:"name"` tag matched exactly, otherwise the field's own name matched
-// exactly first and then case-insensitively via Unicode simple-fold (mirroring
-// matchGroupName). Returns the field index, its parsed tag options, and true on
-// a match; ("", nil, false) when no field resolves.
-func resolveEncodeField(rt reflect.Type, name string) (int, map[string]string, bool) {
-	// Exact pass first so an exact name never loses to an earlier fold sibling.
-	for i := range rt.NumField() {
-		sf := rt.Field(i)
-		if !sf.IsExported() {
-			continue
-		}
-		candidate, opts, _, skip := fieldCandidateName(sf)
-		if skip {
-			continue
-		}
-		if candidate == name {
-			return i, opts, true
-		}
-	}
-	// Fold pass: only untagged fields fold. The decode side folds solely the
-	// field-name fallback (matchGroupName); an explicit `regex:` tag is matched
-	// exactly (subexpIndexes). Folding a tag here would bind a group that the
-	// decoder maps back to nothing, silently corrupting the round-trip — e.g. a
-	// field `regex:"ID,default=x"` against a `(?P<id>…)` group would Encode via
-	// the fold yet Decode to the default. See buildDecodePlan in decoder.go.
-	for i := range rt.NumField() {
-		sf := rt.Field(i)
-		if !sf.IsExported() {
-			continue
-		}
-		candidate, opts, tagged, skip := fieldCandidateName(sf)
-		if skip || tagged {
-			continue
-		}
-		if strings.EqualFold(candidate, name) {
-			return i, opts, true
+// resolveEncodeField maps a capture-group name to an exported, non-excluded
+// leaf field of rt, using the same field-mapping rules the decode side applies:
+// fields are reached through visitStructFields (so embedded structs promote
+// their fields and `prefix`-flagged structs prefix theirs), a field's
+// `regex:"name"` tag is matched exactly, otherwise the field's own name is
+// matched exactly first and then case-insensitively via Unicode simple-fold
+// (mirroring matchGroupName). When several fields resolve, the first in
+// declaration order wins. Returns the resolved leaf and true on a match;
+// false when no field resolves.
+func resolveEncodeField(rt reflect.Type, cfg *DecoderConfig, name string) (structLeaf, bool) {
+	var exact, folded structLeaf
+	var haveExact, haveFolded bool
+	// The walk is lenient: a misplaced `prefix` flag was already rejected by
+	// Compile, which every Decoder passed through.
+	_ = visitStructFields(rt, cfg, false, func(lf structLeaf) error {
+		candidate := lf.name()
+		switch {
+		case candidate == name:
+			if !haveExact {
+				exact, haveExact = lf, true
+			}
+		case !lf.tagged() && !haveFolded && strings.EqualFold(candidate, name):
+			// Fold pass: only untagged fields fold. The decode side folds
+			// solely the field-name fallback (matchGroupName); an explicit
+			// `regex:` tag is matched exactly (subexpIndexes). Folding a tag
+			// here would bind a group that the decoder maps back to nothing,
+			// silently corrupting the round-trip — e.g. a field
+			// `regex:"ID,default=x"` against a `(?P<id>…)` group would Encode
+			// via the fold yet Decode to the default. See buildDecodePlan in
+			// decoder.go.
+			folded, haveFolded = lf, true
 		}
+		return nil
+	})
+	// An exact name never loses to an earlier fold sibling.
+	if haveExact {
+		return exact, true
 	}
-	return 0, nil, false
-}
-
-// fieldCandidateName returns the name a field is addressable by — its
-// `regex:"name"` tag name when set, otherwise its own field name — plus the
-// parsed tag options, whether the name came from an explicit tag (tagged), and
-// whether the field is excluded (`regex:"-"`). Callers fold only untagged
-// candidates, mirroring the decoder's exact-tag / fold-field-name split.
-func fieldCandidateName(sf reflect.StructField) (name string, opts map[string]string, tagged, skip bool) {
-	// required is a decode-side presence flag; encoding always emits the field's
-	// actual value, so it is irrelevant here.
-	tagName, opts, _, skip := parseFieldTag(sf)
-	if skip {
-		return "", nil, false, true
-	}
-	if tagName == "" {
-		return sf.Name, opts, false, false
-	}
-	return tagName, opts, true, false
+	return folded, haveFolded
 }
 
 // validateEncodeField rejects, at construction time, a mapped field whose type
@@ -413,19 +801,45 @@ func fieldCandidateName(sf reflect.StructField) (name string, opts map[string]st
 // It does not re-check `layout=` placement: [Compile] already rejects `layout=`
 // on a non-time.Time field, so any field reaching here through a compiled
 // [Decoder] has a valid layout option.
-func validateEncodeField(sf reflect.StructField) error {
-	if !encodableType(sf.Type) {
-		return fmt.Errorf("%w: field %s has unsupported type %v", ErrInvalidStruct, sf.Name, sf.Type)
+//
+// A slice or array field encodes either as a `split=` list, its elements
+// joined with the separator into one group, or as a list field, its elements
+// filling successive occurrences of its group (see isListField). Either way
+// only its element type needs checking.
+func validateEncodeField(rt reflect.Type, lf structLeaf, cfg *DecoderConfig) error {
+	if t := lf.field.Type; isCollectionType(t, cfg) {
+		if !encodableType(t.Elem(), cfg) {
+			return fmt.Errorf("%w: field %s has unsupported element type %v", ErrInvalidStruct, fieldPath(rt, lf.index), t.Elem())
+		}
+		return nil
+	}
+	if !encodableType(lf.field.Type, cfg) {
+		return fmt.Errorf("%w: field %s has unsupported type %v", ErrInvalidStruct, fieldPath(rt, lf.index), lf.field.Type)
 	}
 	return nil
 }
 
+// isListField reports whether lf is a list field: a slice or array without
+// `split=`, which decodes one element per occurrence (or repetition
+// iteration) of its group and so encodes the same way.
+func isListField(lf structLeaf, cfg *DecoderConfig) bool {
+	if !isCollectionType(lf.field.Type, cfg) {
+		return false
+	}
+	_, split := splitSeparator(lf.tag.opts)
+	return !split
+}
+
 // encodableType reports whether a field of type t can be rendered by
-// encodeFieldValue: a type implementing [RegexMarshaler] or
+// encodeFieldValue: a type with an encode function registered in cfg's
+// registries (see [Converters]), a type implementing [RegexMarshaler] or
 // [encoding.TextMarshaler] (directly or via its pointer), the time special
 // cases, one of the supported scalar kinds, or a single-level pointer to any of
 // these.
-func encodableType(t reflect.Type) bool {
+func encodableType(t reflect.Type, cfg *DecoderConfig) bool {
+	if cfg.encodeFunc(t) != nil {
+		return true
+	}
 	if t.Implements(regexMarshalerType) || reflect.PointerTo(t).Implements(regexMarshalerType) {
 		return true
 	}
@@ -443,7 +857,7 @@ func encodableType(t reflect.Type) bool {
 		reflect.Bool:
 		return true
 	case reflect.Ptr:
-		return encodableType(t.Elem())
+		return encodableType(t.Elem(), cfg)
 	default:
 		return false
 	}
@@ -455,51 +869,378 @@ func encodableType(t reflect.Type) bool {
 //
 // Returns an [EncodeError] (wrapped with the entrypoint prefix) if a field
 // cannot be rendered at runtime — a custom [RegexMarshaler] / [encoding.TextMarshaler]
-// returning an error, or a nil pointer field, which has no string form.
+// returning an error, or a nil pointer field (or a nil embedded / `prefix`
+// struct pointer on the way to a field), which has no string form. Inside an
+// optional `?` section a nil pointer is not an error: it marks the field
+// absent, and the section is dropped when none of its fields is present. An
+// alternation with no branch whose fields are all set and a list field with
+// too few or too many elements for the pattern (a repetition's bounds
+// included) are EncodeErrors too.
 //
 // The `default=` tag option does not affect encoding: it is a decode-side
 // substitution for an absent group, whereas Encode always emits the field's
 // actual value. `layout=` is honored so a time.Time re-parses under [Decoder]'s
 // exclusive-layout rule.
 func (e *Encoder[T]) Encode(v T) (string, error) {
+	s, err := e.encode(v, false)
+	if err != nil {
+		return "", fmt.Errorf("regextra.Encoder.Encode: %w", err)
+	}
+	return s, nil
+}
+
+// EncodeStrict is like [Encoder.Encode] but also guarantees each substituted
+// value re-parses: every rendered field value must fully match the sub-pattern
+// of the capture group it fills, compiled once by [Decoder.Encoder]. A value
+// that does not — a name with a space for `(?P<name>\S+)`, a negative number
+// for `(?P<n>\d+)`, PUT for `(?P<method>GET|POST)` — fails with an [EncodeError] wrapping [ErrValueMismatch]
+// rather than producing a line [Decoder.One] would reject or misread.
+//
+// The check is per group: it does not catch a value that matches its own
+// group but swallows a following literal delimiter (`a b` for `(?P<x>.+) `).
+// Fields in a dropped optional section or an unchosen alternation branch are
+// not rendered, so they are not checked.
+func (e *Encoder[T]) EncodeStrict(v T) (string, error) {
+	s, err := e.encode(v, true)
+	if err != nil {
+		return "", fmt.Errorf("regextra.Encoder.EncodeStrict: %w", err)
+	}
+	return s, nil
+}
+
+// encodeState is the per-call state of one Encode: the value being rendered,
+// whether values are checked against their groups (EncodeStrict), and how
+// many elements of each list field (by encodeSegment.list) have been emitted.
+type encodeState struct {
+	rv     reflect.Value
+	strict bool
+	next   []int
+}
+
+// remaining returns how many elements of a list-field segment's field are
+// still to be emitted; 0 when a nil pointer on its path leaves no field.
+func (st *encodeState) remaining(seg encodeSegment) int {
+	field, err := st.rv.FieldByIndexErr(seg.index)
+	if err != nil {
+		return 0
+	}
+	return field.Len() - st.next[seg.list]
+}
+
+// encode renders v for Encode and EncodeStrict, returning the unwrapped
+// *EncodeError on failure. After the plan is walked, every element of every
+// list field must have been emitted: an element the pattern had no place for
+// would be lost on the way back through the decoder.
+func (e *Encoder[T]) encode(v T, strict bool) (string, error) {
 	// An addressable copy of v so fields with pointer-receiver marshalers
 	// dispatch via Addr() — the same reason setFieldValue relies on
 	// addressability on the decode side. reflect.ValueOf(v) alone is not
 	// addressable.
-	rv := reflect.New(e.rtype).Elem()
-	rv.Set(reflect.ValueOf(v))
+	st := &encodeState{rv: reflect.New(e.rtype).Elem(), strict: strict}
+	st.rv.Set(reflect.ValueOf(v))
+	if len(e.lists) > 0 {
+		st.next = make([]int, len(e.lists))
+	}
 
 	var b strings.Builder
-	for _, seg := range e.segments {
+	if err := e.encodeSegments(&b, st, e.segments); err != nil {
+		return "", err
+	}
+	for _, seg := range e.lists {
+		if n := st.remaining(seg); n > 0 {
+			return "", e.segmentError(seg, fmt.Errorf("%d of %d elements have no place in the pattern", n, n+st.next[seg.list]))
+		}
+	}
+	return b.String(), nil
+}
+
+// segmentError builds the *EncodeError for a field segment of e's plan.
+func (e *Encoder[T]) segmentError(seg encodeSegment, err error) *EncodeError {
+	return &EncodeError{
+		Field: fieldPath(e.rtype, seg.index),
+		Group: seg.name,
+		Type:  e.rtype.FieldByIndex(seg.index).Type.String(),
+		Err:   err,
+	}
+}
+
+// encodeSegments renders one level of the encode plan into b, recursing into
+// each optional section that sectionPresent admits, the chosen branch of each
+// alternation, and each iteration of a repetition. A field failure is
+// returned as an *EncodeError for Encode to wrap.
+func (e *Encoder[T]) encodeSegments(b *strings.Builder, st *encodeState, segments []encodeSegment) error {
+	for _, seg := range segments {
+		if seg.optional != nil {
+			if sectionPresent(st, seg.optional) {
+				if err := e.encodeSegments(b, st, seg.optional); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if seg.alternatives != nil {
+			branch, err := e.chooseBranch(st, seg.alternatives)
+			if err != nil {
+				return err
+			}
+			if err := e.encodeSegments(b, st, branch); err != nil {
+				return err
+			}
+			continue
+		}
+		if seg.repeated != nil {
+			n, err := e.repeatCount(st, seg)
+			if err != nil {
+				return err
+			}
+			for range n {
+				if err := e.encodeSegments(b, st, seg.repeated); err != nil {
+					return err
+				}
+			}
+			continue
+		}
 		if !seg.field {
 			b.WriteString(seg.literal)
 			continue
 		}
-		field := rv.Field(seg.fieldIndex)
-		s, err := encodeFieldValue(field, seg.opts)
+		field, err := st.rv.FieldByIndexErr(seg.index)
+		if err != nil {
+			// A nil embedded or `prefix`-flagged struct pointer on the path has
+			// no fields to read — the same no-string-form failure as a nil
+			// pointer leaf.
+			return e.segmentError(seg, err)
+		}
+		if seg.element {
+			// A list field fills each occurrence of its group with its next
+			// element.
+			i := st.next[seg.list]
+			if i >= field.Len() {
+				return e.segmentError(seg, fmt.Errorf("list has %d elements, the pattern needs more", field.Len()))
+			}
+			field = field.Index(i)
+			st.next[seg.list]++
+		}
+		s, err := encodeTransformed(field, seg.opts, seg.transforms, seg.conv, &e.cfg)
 		if err != nil {
-			sf := e.rtype.Field(seg.fieldIndex)
-			return "", fmt.Errorf("regextra.Encoder.Encode: %w", &EncodeError{
-				Field: sf.Name,
-				Group: seg.name,
-				Type:  field.Type().String(),
-				Err:   err,
-			})
+			return e.segmentError(seg, err)
+		}
+		if st.strict {
+			if seg.choices != nil && !slices.Contains(seg.choices, s) {
+				return e.segmentError(seg, fmt.Errorf("%w: %q is not one of the group's alternatives %q", ErrValueMismatch, s, seg.choices))
+			}
+			if !seg.match.MatchString(s) {
+				return e.segmentError(seg, fmt.Errorf("%w: %q does not match `%s`", ErrValueMismatch, s, seg.match))
+			}
 		}
 		b.WriteString(s)
 	}
-	return b.String(), nil
+	return nil
+}
+
+// repeatCount returns how many times a repetition's body is emitted: the
+// number of elements its list fields have left, divided by how often the body
+// uses each. Every list field in the body must call for the same count, and
+// it must lie within the repetition's bounds — otherwise no decodable line
+// holds exactly these elements.
+func (e *Encoder[T]) repeatCount(st *encodeState, rep encodeSegment) (int, error) {
+	uses := make(map[int]int)
+	var order []encodeSegment
+	for _, seg := range rep.repeated {
+		if seg.element {
+			if uses[seg.list] == 0 {
+				order = append(order, seg)
+			}
+			uses[seg.list]++
+		}
+	}
+	first := order[0]
+	left := st.remaining(first)
+	n := left / uses[first.list]
+	if left%uses[first.list] != 0 {
+		return 0, e.segmentError(first, fmt.Errorf("%d elements left do not divide into repetitions of %d", left, uses[first.list]))
+	}
+	for _, seg := range order[1:] {
+		if got := st.remaining(seg); got != n*uses[seg.list] {
+			return 0, e.segmentError(seg, fmt.Errorf("%d elements left, want %d to repeat alongside %s", got, n*uses[seg.list], fieldPath(e.rtype, first.index)))
+		}
+	}
+	switch {
+	case n < rep.min:
+		return 0, e.segmentError(first, fmt.Errorf("%d repetitions, the pattern needs at least %d", n, rep.min))
+	case rep.max >= 0 && n > rep.max:
+		return 0, e.segmentError(first, fmt.Errorf("%d repetitions, the pattern allows at most %d", n, rep.max))
+	}
+	return n, nil
+}
+
+// errNoAlternative is the EncodeError cause when no alternation branch has all
+// of its fields set.
+var errNoAlternative = errors.New("no alternative of the pattern has all of its fields set")
+
+// chooseBranch picks the first alternation branch whose fields are all set
+// (see branchSet). When none is, it reports an *EncodeError naming the first
+// field of the first branch — the branch a caller most likely meant to fill.
+func (e *Encoder[T]) chooseBranch(st *encodeState, alts [][]encodeSegment) ([]encodeSegment, error) {
+	for _, branch := range alts {
+		if branchSet(st, branch) {
+			return branch, nil
+		}
+	}
+	seg, _ := firstFieldSegment(alts[0])
+	return nil, e.segmentError(seg, errNoAlternative)
+}
+
+// branchSet reports whether every field an alternation branch fills is set:
+// present (see fieldPresent) and, unlike in an optional section, non-zero even
+// without `omitempty` — a zero ID says nothing about which shape the value
+// takes. A nested alternation is set when any of its branches is, and a
+// repetition when it may repeat zero times or its body is set; fields of a
+// nested optional section are not required. A branch with no fields is always
+// set.
+func branchSet(st *encodeState, segments []encodeSegment) bool {
+	for _, seg := range segments {
+		switch {
+		case seg.alternatives != nil:
+			if !slices.ContainsFunc(seg.alternatives, func(b []encodeSegment) bool { return branchSet(st, b) }) {
+				return false
+			}
+		case seg.repeated != nil:
+			if seg.min > 0 && !branchSet(st, seg.repeated) {
+				return false
+			}
+		case seg.field:
+			if !fieldPresent(st, seg, true) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// firstFieldSegment returns the first field segment of a plan in pattern
+// order, descending into the first branch of an alternation, into optional
+// sections, and into repetitions. A branch reaching chooseBranch's error path
+// always has one, since a fieldless branch is always set.
+func firstFieldSegment(segments []encodeSegment) (encodeSegment, bool) {
+	for _, seg := range segments {
+		switch {
+		case seg.field:
+			return seg, true
+		case seg.optional != nil:
+			if f, ok := firstFieldSegment(seg.optional); ok {
+				return f, true
+			}
+		case seg.repeated != nil:
+			if f, ok := firstFieldSegment(seg.repeated); ok {
+				return f, true
+			}
+		case seg.alternatives != nil:
+			if f, ok := firstFieldSegment(seg.alternatives[0]); ok {
+				return f, true
+			}
+		}
+	}
+	return encodeSegment{}, false
+}
+
+// sectionPresent reports whether an optional section should be emitted: true
+// when any field it — or a nested optional section, repetition, or
+// alternation branch — fills is present (see fieldPresent). A section holding
+// a plain non-pointer field without `omitempty` is therefore always emitted.
+func sectionPresent(st *encodeState, segments []encodeSegment) bool {
+	for _, seg := range segments {
+		switch {
+		case seg.optional != nil:
+			if sectionPresent(st, seg.optional) {
+				return true
+			}
+		case seg.repeated != nil:
+			if sectionPresent(st, seg.repeated) {
+				return true
+			}
+		case seg.alternatives != nil:
+			if slices.ContainsFunc(seg.alternatives, func(b []encodeSegment) bool { return sectionPresent(st, b) }) {
+				return true
+			}
+		case seg.field:
+			if fieldPresent(st, seg, seg.omitempty) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldPresent reports whether a field segment holds a value to emit. A field
+// is absent when a pointer on its path — the leaf itself or an embedded /
+// `prefix` struct pointer — is nil (likewise a nil interface), or, when
+// emptyIsAbsent, when it holds its empty value (zero, or no elements for a
+// slice or array — the encoding/json `omitempty` notion). A list field is
+// present exactly while it has elements left to emit.
+func fieldPresent(st *encodeState, seg encodeSegment, emptyIsAbsent bool) bool {
+	if seg.element {
+		return st.remaining(seg) > 0
+	}
+	field, err := st.rv.FieldByIndexErr(seg.index)
+	if err != nil {
+		return false
+	}
+	if (field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface) && field.IsNil() {
+		return false
+	}
+	return !emptyIsAbsent || !isEmptyValue(field)
+}
+
+// isEmptyValue reports whether v holds its empty value for `omitempty`: no
+// elements for a slice, array, map, or string, otherwise the type's zero value.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
 }
 
 // encodeFieldValue renders one struct field to its string form — the inverse of
 // setFieldValue, dispatching in the same precedence order so a type round-trips
 // symmetrically: custom [RegexMarshaler] first, then the time.Time /
 // time.Duration special cases, then [encoding.TextMarshaler], then the built-in
-// kind switch. `opts` carries the field's parsed tag options; only `layout` (for
-// time.Time) is consulted.
-func encodeFieldValue(field reflect.Value, opts map[string]string) (string, error) {
-	// 0. Pointer fields: a nil pointer has no string form (every derived slot is
-	//    required), so it is an error; otherwise dispatch on the pointer's own
+// kind switch. A converter registered for the field's exact type (see
+// [Converters]) comes before all of them; conv holds it, resolved when the
+// Encoder was derived (see DecoderConfig.resolveEncodeConverter). `opts`
+// carries the field's parsed tag options; `layout` (for time.Time), `split`
+// (for slices and arrays), and the numeric format options `base`, `sep`,
+// `percent`, `unit`, `prec`, and `unitbase` are consulted.
+func encodeFieldValue(field reflect.Value, opts map[string]string, conv encodeConverter, cfg *DecoderConfig) (string, error) {
+	// A `split=` list joins its encoded elements with the separator — the
+	// inverse of setCollectionValue.
+	if conv.list {
+		sep, _ := splitSeparator(opts)
+		conv.list = false
+		parts := make([]string, field.Len())
+		for i := range parts {
+			s, err := encodeFieldValue(field.Index(i), opts, conv, cfg)
+			if err != nil {
+				return "", fmt.Errorf("element %d: %w", i, err)
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, sep), nil
+	}
+
+	if conv.encode != nil && conv.derefs == 0 {
+		s, err := conv.encode(field.Interface(), opts)
+		if err != nil {
+			return "", fmt.Errorf("cannot encode %s: %w", field.Type(), err)
+		}
+		return s, nil
+	}
+
+	// 0. Pointer fields: a nil pointer has no string form (a slot reached here
+	//    must be filled — an absent optional section is skipped before its
+	//    fields are rendered), so it is an error; otherwise dispatch on the pointer's own
 	//    RegexMarshaler or recurse into the pointee. Single-level handling
 	//    mirrors setFieldValue; deeper indirection recurses.
 	if field.Kind() == reflect.Ptr {
@@ -509,7 +1250,8 @@ func encodeFieldValue(field reflect.Value, opts map[string]string) (string, erro
 		if m, ok := field.Interface().(RegexMarshaler); ok {
 			return m.MarshalRegex()
 		}
-		return encodeFieldValue(field.Elem(), opts)
+		conv.derefs--
+		return encodeFieldValue(field.Elem(), opts, conv, cfg)
 	}
 
 	// 0b. Interface fields: a nil interface (e.g. a field statically typed as
@@ -547,12 +1289,7 @@ func encodeFieldValue(field reflect.Value, opts map[string]string) (string, erro
 	//    sub-second precision that a plain RFC3339 would drop.
 	switch field.Type() {
 	case timeTimeType:
-		t := field.Interface().(time.Time)
-		layout := time.RFC3339Nano
-		if l, ok := opts["layout"]; ok && l != "" {
-			layout = l
-		}
-		return t.Format(layout), nil
+		return formatTimeField(field.Interface().(time.Time), opts, cfg)
 	case timeDurationType:
 		return field.Interface().(time.Duration).String(), nil
 	}
@@ -585,13 +1322,11 @@ func encodeFieldValue(field reflect.Value, opts map[string]string) (string, erro
 	case reflect.String:
 		return field.String(), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return strconv.FormatInt(field.Int(), 10), nil
+		return formatIntOption(field.Int(), opts)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return strconv.FormatUint(field.Uint(), 10), nil
-	case reflect.Float32:
-		return strconv.FormatFloat(field.Float(), 'g', -1, 32), nil
-	case reflect.Float64:
-		return strconv.FormatFloat(field.Float(), 'g', -1, 64), nil
+		return formatUintOption(field.Uint(), opts)
+	case reflect.Float32, reflect.Float64:
+		return formatFloatOption(field.Float(), opts, field.Type().Bits())
 	case reflect.Bool:
 		return strconv.FormatBool(field.Bool()), nil
 	default:
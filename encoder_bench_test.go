@@ -20,10 +20,11 @@ func mustDeriveEncoder[T any](d *rx.Decoder[T]) *rx.Encoder[T] {
 // ── Decoder.Encoder ────────────────────────────────────────────────────────────
 //
 // Cost model: a one-time AST parse of the decoder's pattern plus, per named
-// capture group, a field resolve (parseFieldTag over T's fields) and an
-// encodability check. This is the cold derivation path — callers derive one
-// Encoder from a Decoder and reuse it — so it is measured only to keep the walk
-// allocation-aware, not because it sits on the hot path.
+// capture group, a field resolve (parseFieldTag over T's fields), an
+// encodability check, and a regexp compile of its sub-pattern for EncodeStrict.
+// This is the cold derivation path — callers derive one Encoder from a Decoder
+// and reuse it — so it is measured only to keep the walk allocation-aware, not
+// because it sits on the hot path.
 
 var (
 	benchEncSimpleDecoder = rx.MustCompile[benchSimple](benchSimplePattern)
@@ -86,3 +87,11 @@ func BenchmarkEncode(b *testing.B) {
 	benchCase(b, "withTime", func() { sinkStr, sinkErr = benchEncTimeEncoder.Encode(benchEncTimeVal) })
 	benchCase(b, "manyFields", func() { sinkStr, sinkErr = benchEncWideEncoder.Encode(benchEncWideVal) })
 }
+
+// EncodeStrict adds one anchored sub-pattern match per rendered field; the
+// difference from BenchmarkEncode on the same fixtures is the re-parse
+// guarantee's cost.
+func BenchmarkEncodeStrict(b *testing.B) {
+	benchCase(b, "simple", func() { sinkStr, sinkErr = benchEncSimpleEncoder.EncodeStrict(benchEncSimpleVal) })
+	benchCase(b, "manyFields", func() { sinkStr, sinkErr = benchEncWideEncoder.EncodeStrict(benchEncWideVal) })
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/netip"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -248,6 +250,29 @@ func TestEncode_timeLayoutSymmetry(t *testing.T) {
 	}
 }
 
+// A DefaultLocation decoder reads offset-less values in its zone, so its
+// Encoder writes them there: a UTC value round-trips to the same instant.
+func TestEncode_timeDefaultLocation(t *testing.T) {
+	type Ev struct {
+		At time.Time `regex:"at,layout=2006-01-02 15:04"`
+	}
+	ny := mustLoad(t, "America/New_York")
+	dec := rx.MustCompileWith[Ev](`at=(?P<at>[\d :-]+)`, rx.DefaultLocation(ny))
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() returned %v", err)
+	}
+	want := Ev{At: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s, err := enc.Encode(want)
+	if err != nil || s != "at=2024-01-01 07:00" {
+		t.Fatalf("Encode = %q, %v; want %q", s, err, "at=2024-01-01 07:00")
+	}
+	got, err := dec.One(s)
+	if err != nil || !got.At.Equal(want.At) {
+		t.Errorf("round-trip time = %v, %v; want %v (via %q)", got.At, err, want.At, s)
+	}
+}
+
 func TestEncode_duration(t *testing.T) {
 	type D struct {
 		Took time.Duration `regex:"took"`
@@ -469,11 +494,12 @@ func TestEncoder_nonInvertibleRejected(t *testing.T) {
 	for _, tc := range []struct {
 		name, pattern, wantSub string
 	}{
-		{"alternation", `(?P<v>\w+)|x`, "alternation"},
+		{"alternationBranch", `(?P<v>\w+)|x+`, "quantifier"},
 		{"quantifierStar", `x*(?P<v>\w+)`, "quantifier"},
 		{"quantifierPlus", `x+(?P<v>\w+)`, "quantifier"},
 		{"quantifierQuest", `x?(?P<v>\w+)`, "quantifier"},
 		{"quantifierRepeat", `x{2,3}(?P<v>\w+)`, "quantifier"},
+		{"repetitionOfScalar", `(?:,(?P<v>\w+))*`, "repetition"},
 		{"charClass", `[0-9](?P<v>\w+)`, "character class"},
 		{"anyChar", `(?P<v>\w+).end`, "any-character"},
 		{"unnamedGroup", `(\d+)(?P<v>\w+)`, "unnamed capturing group"},
@@ -548,7 +574,7 @@ func TestEncoder_explicitTagMatchesExactly(t *testing.T) {
 
 func TestEncoder_unsupportedType(t *testing.T) {
 	type P struct {
-		Data []byte `regex:"data"` // slice is not a supported scalar
+		Data map[string]string `regex:"data"` // a map has no string form
 	}
 	_, err := rx.MustCompile[P](`(?P<data>\S+)`).Encoder()
 	if err == nil {
@@ -676,3 +702,622 @@ func ExampleDecoder_Encoder_roundTrip() {
 	fmt.Printf("%q -> %+v\n", s, back)
 	// Output: "Alice is 30" -> {Name:Alice Age:30}
 }
+
+// ── Nested structs ────────────────────────────────────────────────────────────
+
+// The Encoder resolves groups to fields of `prefix`-flagged and embedded
+// structs with the same rules as the Decoder, so nested shapes round-trip.
+func TestEncode_nestedRoundTrip(t *testing.T) {
+	type endpoint struct {
+		Host string
+		Port int
+	}
+	type request struct {
+		Method string
+	}
+	type line struct {
+		request
+		Client endpoint  `regex:"client,prefix"`
+		Server *endpoint `regex:"server,prefix"`
+	}
+	dec := rx.MustCompile[line](`(?P<method>[A-Z]+) (?P<client_host>\S+):(?P<client_port>\d+) > (?P<server_host>\S+):(?P<server_port>\d+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+
+	in := line{
+		request: request{Method: "GET"},
+		Client:  endpoint{Host: "10.0.0.1", Port: 5123},
+		Server:  &endpoint{Host: "example.com", Port: 443},
+	}
+	s, err := enc.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if want := "GET 10.0.0.1:5123 > example.com:443"; s != want {
+		t.Errorf("Encode() = %q, want %q", s, want)
+	}
+	back, err := dec.One(s)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Errorf("round-trip = %+v, want %+v", back, in)
+	}
+
+	// A nil prefix pointer has no fields to read.
+	_, err = enc.Encode(line{request: request{Method: "GET"}})
+	var ee *rx.EncodeError
+	if !errors.As(err, &ee) {
+		t.Fatalf("Encode(nil Server) error = %v, want an *EncodeError", err)
+	}
+	if ee.Field != "Server.Host" || ee.Group != "server_host" {
+		t.Errorf("EncodeError{Field:%q, Group:%q}, want {Server.Host, server_host}", ee.Field, ee.Group)
+	}
+}
+
+// ── split= lists ──────────────────────────────────────────────────────────────
+
+// A `split=` slice encodes as its elements joined with the separator, the
+// inverse of the decode-side split, so it round-trips.
+func TestEncode_splitListRoundTrip(t *testing.T) {
+	type P struct {
+		Name  string   `regex:"name"`
+		Tags  []string `regex:"tags,split=,"`
+		Ports [2]int   `regex:"ports,split=:"`
+	}
+	dec := rx.MustCompile[P](`(?P<name>\w+) \[(?P<tags>[^\]]*)\] (?P<ports>[\d:]+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	in := P{Name: "svc", Tags: []string{"a", "b"}, Ports: [2]int{80, 443}}
+	s, err := enc.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if want := "svc [a,b] 80:443"; s != want {
+		t.Errorf("Encode() = %q, want %q", s, want)
+	}
+	back, err := dec.One(s)
+	if err != nil || !reflect.DeepEqual(back, in) {
+		t.Errorf("round-trip = %+v, %v; want %+v", back, err, in)
+	}
+}
+
+// ── Optional sections ─────────────────────────────────────────────────────────
+
+// A `?` section holding a named capture is emitted when its pointer field is
+// non-nil and dropped when it is nil, and both shapes round-trip.
+func TestEncode_optionalPointerSection(t *testing.T) {
+	type P struct {
+		Host string  `regex:"host"`
+		User *string `regex:"user"`
+	}
+	dec := rx.MustCompile[P](`^(?P<host>\S+)(?: user=(?P<user>\S+))?$`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	alice := "alice"
+	for _, tc := range []struct {
+		in   P
+		want string
+	}{
+		{P{Host: "db1", User: &alice}, "db1 user=alice"},
+		{P{Host: "db1"}, "db1"},
+	} {
+		s, err := enc.Encode(tc.in)
+		if err != nil || s != tc.want {
+			t.Errorf("Encode(%+v) = %q, %v; want %q", tc.in, s, err, tc.want)
+			continue
+		}
+		back, err := dec.One(s)
+		if err != nil || !reflect.DeepEqual(back, tc.in) {
+			t.Errorf("round-trip of %q = %+v, %v; want %+v", s, back, err, tc.in)
+		}
+	}
+}
+
+// `omitempty` drops a section whose field holds its empty value; a plain field
+// without it keeps its section unconditionally.
+func TestEncode_optionalOmitemptySection(t *testing.T) {
+	type P struct {
+		Name  string   `regex:"name"`
+		Port  int      `regex:"port,omitempty"`
+		Tags  []string `regex:"tags,split=comma,omitempty"`
+		Level int      `regex:"level"`
+	}
+	dec := rx.MustCompile[P](`(?P<name>\w+)(?::(?P<port>\d+))?(?: \[(?P<tags>[\w,]+)\])?(?: L(?P<level>\d))?`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	for _, tc := range []struct {
+		in   P
+		want string
+	}{
+		{P{Name: "api", Port: 8080, Tags: []string{"a", "b"}, Level: 2}, "api:8080 [a,b] L2"},
+		{P{Name: "api", Tags: []string{}}, "api L0"},
+	} {
+		s, err := enc.Encode(tc.in)
+		if err != nil || s != tc.want {
+			t.Errorf("Encode(%+v) = %q, %v; want %q", tc.in, s, err, tc.want)
+		}
+	}
+}
+
+// Nested optional sections, the `??` and `{0,1}` spellings, and a `prefix`
+// struct pointer all decide presence the same way; a section holding one set
+// and one nil pointer field is emitted, and the nil field is an EncodeError.
+func TestEncode_optionalSectionShapes(t *testing.T) {
+	type endpoint struct {
+		Host string
+		Port int
+	}
+	type P struct {
+		ID   int       `regex:"id"`
+		Peer *endpoint `regex:"peer,prefix"`
+		A    *int      `regex:"a"`
+		B    *int      `regex:"b"`
+	}
+	dec := rx.MustCompile[P](`id=(?P<id>\d+)(?: peer=(?P<peer_host>[\w.]+)(?::(?P<peer_port>\d+)){0,1})??(?: (?P<a>\d+)-(?P<b>\d+))?`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	one, two := 1, 2
+	for _, tc := range []struct {
+		in   P
+		want string
+	}{
+		{P{ID: 7, Peer: &endpoint{Host: "h", Port: 9}, A: &one, B: &two}, "id=7 peer=h:9 1-2"},
+		{P{ID: 7}, "id=7"},
+	} {
+		s, err := enc.Encode(tc.in)
+		if err != nil || s != tc.want {
+			t.Errorf("Encode(%+v) = %q, %v; want %q", tc.in, s, err, tc.want)
+		}
+	}
+
+	_, err = enc.Encode(P{ID: 7, A: &one})
+	var ee *rx.EncodeError
+	if !errors.As(err, &ee) || ee.Field != "B" {
+		t.Errorf("Encode(A set, B nil) error = %v, want an EncodeError on B", err)
+	}
+}
+
+// ── Alternations ──────────────────────────────────────────────────────────────
+
+// An alternation encodes the first branch whose fields are all set, so a
+// message whose shape depends on which field is filled round-trips.
+func TestEncode_alternationByFieldsSet(t *testing.T) {
+	type Lookup struct {
+		Op   string `regex:"op"`
+		ID   int    `regex:"id"`
+		Name string `regex:"name"`
+		Zone *string
+	}
+	dec := rx.MustCompile[Lookup](`^(?P<op>\w+) (?:id=(?P<id>\d+)|name=(?P<name>\w+)(?:@(?P<zone>\w+))?)$`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	eu := "eu"
+	for _, tc := range []struct {
+		in   Lookup
+		want string
+	}{
+		{Lookup{Op: "get", ID: 42}, "get id=42"},
+		{Lookup{Op: "get", Name: "alice"}, "get name=alice"},
+		{Lookup{Op: "get", Name: "alice", Zone: &eu}, "get name=alice@eu"},
+		// Both set: the first branch wins.
+		{Lookup{Op: "get", ID: 1, Name: "alice"}, "get id=1"},
+	} {
+		s, err := enc.Encode(tc.in)
+		if err != nil || s != tc.want {
+			t.Errorf("Encode(%+v) = %q, %v; want %q", tc.in, s, err, tc.want)
+			continue
+		}
+		if tc.in.ID != 0 && tc.in.Name != "" {
+			continue // the dropped branch's field cannot round-trip
+		}
+		back, err := dec.One(s)
+		if err != nil || !reflect.DeepEqual(back, tc.in) {
+			t.Errorf("round-trip of %q = %+v, %v; want %+v", s, back, err, tc.in)
+		}
+	}
+
+	// No branch set: an EncodeError on the first branch's field.
+	_, err = enc.Encode(Lookup{Op: "get"})
+	var ee *rx.EncodeError
+	if !errors.As(err, &ee) || ee.Field != "ID" || ee.Group != "id" {
+		t.Errorf("Encode(no branch set) error = %v, want an EncodeError on ID", err)
+	}
+}
+
+// A pure-literal alternation outside a capture emits its first branch; wrapped
+// in a named group it binds the field to its literals under EncodeStrict.
+func TestEncode_literalAlternation(t *testing.T) {
+	type Req struct {
+		Method string `regex:"method"`
+		Path   string `regex:"path"`
+	}
+	dec := rx.MustCompile[Req](`(?:>>|->) (?P<method>GET|POST|PUT) (?P<path>\S+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	in := Req{Method: "PUT", Path: "/a"}
+	s, err := enc.Encode(in)
+	if err != nil || s != ">> PUT /a" {
+		t.Fatalf("Encode() = %q, %v; want %q", s, err, ">> PUT /a")
+	}
+	if back, err := dec.One(s); err != nil || back != in {
+		t.Errorf("round-trip = %+v, %v; want %+v", back, err, in)
+	}
+
+	// Encode trusts the caller; EncodeStrict rejects a value outside the set.
+	if s, err := enc.Encode(Req{Method: "PATCH", Path: "/a"}); err != nil || s != ">> PATCH /a" {
+		t.Errorf("Encode(PATCH) = %q, %v; want %q", s, err, ">> PATCH /a")
+	}
+	_, err = enc.EncodeStrict(Req{Method: "PATCH", Path: "/a"})
+	var ee *rx.EncodeError
+	if !errors.As(err, &ee) || ee.Field != "Method" || !errors.Is(err, rx.ErrValueMismatch) || !strings.Contains(err.Error(), "PATCH") {
+		t.Errorf("EncodeStrict(PATCH) error = %v, want an EncodeError on Method wrapping ErrValueMismatch", err)
+	}
+
+	// A case-folded or unbounded wrapper does not restrict the value, and a
+	// character class is not an alternation: Encode emits any value.
+	type Word struct {
+		W string `regex:"w"`
+	}
+	for _, pattern := range []string{`(?P<w>(?i)get|post)`, `(?P<w>GET|\w+)`, `(?P<w>\d)`, `(?P<w>a|b)`} {
+		if s, err := mustEncoder[Word](t, pattern).Encode(Word{W: "12"}); err != nil || s != "12" {
+			t.Errorf("%q: Encode() = %q, %v; want unrestricted %q", pattern, s, err, "12")
+		}
+	}
+}
+
+// ── EncodeStrict ──────────────────────────────────────────────────────────────
+
+// EncodeStrict re-matches every rendered value against its group's
+// sub-pattern and reports a value that could not re-parse as ErrValueMismatch.
+func TestEncodeStrict_valueMismatch(t *testing.T) {
+	type P struct {
+		Name string `regex:"name"`
+		Port int    `regex:"port"`
+	}
+	enc := mustEncoder[P](t, `(?P<name>\S+):(?P<port>\d{1,5})`)
+
+	s, err := enc.EncodeStrict(P{Name: "db", Port: 5432})
+	if err != nil || s != "db:5432" {
+		t.Fatalf("EncodeStrict(valid) = %q, %v; want %q", s, err, "db:5432")
+	}
+
+	for _, tc := range []struct {
+		in        P
+		wantField string
+	}{
+		{P{Name: "my db", Port: 1}, "Name"},
+		{P{Name: "db", Port: -1}, "Port"},
+		{P{Name: "db", Port: 123456}, "Port"},
+	} {
+		// Plain Encode trusts the caller and emits the unparseable line.
+		if _, err := enc.Encode(tc.in); err != nil {
+			t.Errorf("Encode(%+v) error = %v, want nil", tc.in, err)
+		}
+		_, err := enc.EncodeStrict(tc.in)
+		var ee *rx.EncodeError
+		if !errors.Is(err, rx.ErrValueMismatch) || !errors.As(err, &ee) || ee.Field != tc.wantField {
+			t.Errorf("EncodeStrict(%+v) error = %v, want ErrValueMismatch on %s", tc.in, err, tc.wantField)
+		}
+	}
+}
+
+// The per-group matcher keeps the flags in effect at the group and copes with
+// parentheses that are literal — escaped, quoted, or inside a class.
+func TestEncodeStrict_groupSubPatterns(t *testing.T) {
+	type W struct {
+		W string `regex:"w"`
+		X string `regex:"x"`
+	}
+	for _, tc := range []struct {
+		pattern string
+		ok, bad W
+	}{
+		{`(?i)(?P<w>abc)-(?P<x>x)`, W{"ABC", "X"}, W{"abd", "x"}},
+		{`(?P<w>[()]+)\((?P<x>\w)\)`, W{"()(", "a"}, W{"(x", "a"}},
+		{`\Q((\E(?P<w>a|b)(?P<x>(?:c)d)`, W{"b", "cd"}, W{"b", "c"}},
+		{`(?P<w>[[:digit:]\]]+) (?P<x>(z))`, W{"1]2", "z"}, W{"1a", "z"}},
+		{`(?m)(?P<w>^a$)(?s:(?P<x>.))`, W{"a", "\n"}, W{"a", "ab"}},
+	} {
+		enc := mustEncoder[W](t, tc.pattern)
+		if _, err := enc.EncodeStrict(tc.ok); err != nil {
+			t.Errorf("%q: EncodeStrict(%+v) error = %v, want nil", tc.pattern, tc.ok, err)
+		}
+		if _, err := enc.EncodeStrict(tc.bad); !errors.Is(err, rx.ErrValueMismatch) {
+			t.Errorf("%q: EncodeStrict(%+v) error = %v, want ErrValueMismatch", tc.pattern, tc.bad, err)
+		}
+	}
+}
+
+// Only rendered fields are checked: a dropped optional section or an unchosen
+// alternation branch is not validated, and a split= list is checked joined.
+func TestEncodeStrict_onlyRenderedFields(t *testing.T) {
+	type P struct {
+		ID   int      `regex:"id"`
+		Name string   `regex:"name"`
+		Tags []string `regex:"tags,split=comma,omitempty"`
+	}
+	enc := mustEncoder[P](t, `(?:id=(?P<id>\d+)|name=(?P<name>[a-z]+))(?: \[(?P<tags>[a-z]+(?:,[a-z]+)*)\])?`)
+	if s, err := enc.EncodeStrict(P{ID: 1, Name: "NOT LOWER"}); err != nil || s != "id=1" {
+		t.Errorf("EncodeStrict(unchosen bad branch) = %q, %v; want %q", s, err, "id=1")
+	}
+	if s, err := enc.EncodeStrict(P{Name: "a", Tags: []string{"x", "y"}}); err != nil || s != "name=a [x,y]" {
+		t.Errorf("EncodeStrict(list) = %q, %v; want %q", s, err, "name=a [x,y]")
+	}
+	if _, err := enc.EncodeStrict(P{Name: "a", Tags: []string{"x", "Y"}}); !errors.Is(err, rx.ErrValueMismatch) {
+		t.Errorf("EncodeStrict(bad list) error = %v, want ErrValueMismatch", err)
+	}
+}
+
+// ── Repetitions ───────────────────────────────────────────────────────────────
+
+// A repetition over a list field emits its body once per remaining element,
+// after any occurrence of the group outside it takes its own element, and the
+// output decodes back to the same list.
+func TestEncode_repetitionRoundTrip(t *testing.T) {
+	type P struct {
+		Cmd  string   `regex:"cmd"`
+		Args []string `regex:"arg"`
+	}
+	dec := rx.MustCompile[P](`^(?P<cmd>\w+)\((?P<arg>\w+)(?:, (?P<arg>\w+))*\)$`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	for _, tc := range []struct {
+		in   P
+		want string
+	}{
+		{P{"f", []string{"a"}}, "f(a)"},
+		{P{"f", []string{"a", "b", "c"}}, "f(a, b, c)"},
+	} {
+		s, err := enc.Encode(tc.in)
+		if err != nil || s != tc.want {
+			t.Errorf("Encode(%+v) = %q, %v; want %q", tc.in, s, err, tc.want)
+			continue
+		}
+		back, err := dec.One(s)
+		if err != nil || !reflect.DeepEqual(back, tc.in) {
+			t.Errorf("round-trip of %q = %+v, %v; want %+v", s, back, err, tc.in)
+		}
+	}
+
+	// The occurrence outside the repetition needs an element of its own.
+	_, err = enc.Encode(P{Cmd: "f"})
+	var ee *rx.EncodeError
+	if !errors.As(err, &ee) || ee.Field != "Args" || ee.Group != "arg" {
+		t.Errorf("Encode(no args) error = %v, want an *EncodeError on Args", err)
+	}
+}
+
+// List fields sharing one repetition body advance together: each iteration
+// takes the next element of every one, so their lengths must agree.
+func TestEncode_repetitionParallelLists(t *testing.T) {
+	type P struct {
+		Keys []string `regex:"k"`
+		Vals []int    `regex:"v"`
+	}
+	dec := rx.MustCompile[P](`\{(?:(?P<k>\w+)=(?P<v>\d+);)*\}`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	in := P{Keys: []string{"a", "b"}, Vals: []int{1, 2}}
+	s, err := enc.Encode(in)
+	if err != nil || s != "{a=1;b=2;}" {
+		t.Fatalf("Encode(%+v) = %q, %v; want %q", in, s, err, "{a=1;b=2;}")
+	}
+	if back, err := dec.One(s); err != nil || !reflect.DeepEqual(back, in) {
+		t.Errorf("round-trip of %q = %+v, %v; want %+v", s, back, err, in)
+	}
+	if s, err := enc.Encode(P{}); err != nil || s != "{}" {
+		t.Errorf("Encode(empty) = %q, %v; want %q", s, err, "{}")
+	}
+
+	_, err = enc.Encode(P{Keys: []string{"a", "b"}, Vals: []int{1}})
+	var ee *rx.EncodeError
+	if !errors.As(err, &ee) || ee.Field != "Vals" {
+		t.Errorf("Encode(uneven lists) error = %v, want an *EncodeError on Vals", err)
+	}
+}
+
+// The repetition's bounds, and the number of places the pattern has for a
+// list, are enforced: a list Encode cannot fit fails instead of emitting a
+// line that decodes to a different list.
+func TestEncode_repetitionCounts(t *testing.T) {
+	type P struct {
+		N []int `regex:"n"`
+	}
+	for _, tc := range []struct {
+		pattern string
+		in      []int
+		want    string // "" means Encode must fail
+	}{
+		{`(?: (?P<n>\d+)){2,3}`, []int{1, 2}, " 1 2"},
+		{`(?: (?P<n>\d+)){2,3}`, []int{1, 2, 3}, " 1 2 3"},
+		{`(?: (?P<n>\d+)){2,3}`, []int{1}, ""},
+		{`(?: (?P<n>\d+)){2,3}`, []int{1, 2, 3, 4}, ""},
+		{`(?:(?P<n>\d+);)+`, nil, ""},
+		{`(?:(?P<n>\d+)-(?P<n>\d+);)*`, []int{1, 2, 3, 4}, "1-2;3-4;"},
+		{`(?:(?P<n>\d+)-(?P<n>\d+);)*`, []int{1, 2, 3}, ""},
+		{`n=(?P<n>\d+)`, []int{7}, "n=7"},
+		{`n=(?P<n>\d+)`, []int{7, 8}, ""},
+		{`x(?: \[(?P<n>\d+)(?:,(?P<n>\d+))*\])?`, nil, "x"},
+		{`x(?: \[(?P<n>\d+)(?:,(?P<n>\d+))*\])?`, []int{1, 2}, "x [1,2]"},
+	} {
+		s, err := mustEncoder[P](t, tc.pattern).Encode(P{N: tc.in})
+		var ee *rx.EncodeError
+		switch {
+		case tc.want == "" && !errors.As(err, &ee):
+			t.Errorf("%q: Encode(%v) = %q, %v; want an *EncodeError", tc.pattern, tc.in, s, err)
+		case tc.want != "" && (err != nil || s != tc.want):
+			t.Errorf("%q: Encode(%v) = %q, %v; want %q", tc.pattern, tc.in, s, err, tc.want)
+		}
+	}
+}
+
+// A repetition can only be inverted when every named capture in its body is a
+// list field and nothing in it needs a per-iteration choice.
+func TestEncoder_repetitionRejected(t *testing.T) {
+	type P struct {
+		Items []string `regex:"item"`
+		Tags  []string `regex:"tags,split=;"`
+		Name  string   `regex:"name"`
+	}
+	for _, pattern := range []string{
+		`(?P<tags>\S+)(?:,(?P<item>\w+)|(?P<name>x))*`,
+		`(?P<name>\w+)(?: (?P<tags>\S+))+(?P<item>\w+)`,
+		`(?P<name>\w+)(?: (?P<item>\w+)(?:=(?P<tags>\S+))?)*`,
+		`(?P<tags>\S+) (?P<name>\w+)(?: (?P<item>\w+)(?:,(?P<item>\w+))*)*`,
+	} {
+		_, err := rx.MustCompile[P](pattern).Encoder()
+		if !errors.Is(err, rx.ErrNotInvertible) {
+			t.Errorf("Encoder() from %q error = %v, want ErrNotInvertible", pattern, err)
+		}
+	}
+}
+
+// EncodeStrict checks each element against the group it fills.
+func TestEncodeStrict_repetitionElements(t *testing.T) {
+	type P struct {
+		N []string `regex:"n"`
+	}
+	enc := mustEncoder[P](t, `(?:(?P<n>\d+),)*`)
+	if s, err := enc.EncodeStrict(P{N: []string{"1", "22"}}); err != nil || s != "1,22," {
+		t.Errorf("EncodeStrict(digits) = %q, %v; want %q", s, err, "1,22,")
+	}
+	if _, err := enc.EncodeStrict(P{N: []string{"1", "x"}}); !errors.Is(err, rx.ErrValueMismatch) {
+		t.Errorf("EncodeStrict(non-digit element) error = %v, want ErrValueMismatch", err)
+	}
+}
+
+// ── Decoder.ReplaceAll ─────────────────────────────────────────────────────────
+
+func TestDecoder_ReplaceAll(t *testing.T) {
+	type entry struct {
+		User   string `regex:"user"`
+		Status int    `regex:"status"`
+	}
+	dec := rx.MustCompile[entry](`user=(?P<user>\w+) status=(?P<status>\d+)`)
+	const input = "10:00 user=ada status=200\n10:01 user=bob status=007\n"
+
+	var seen []string
+	got, err := dec.ReplaceAll(input, func(e entry) (entry, error) {
+		seen = append(seen, e.User)
+		e.User = strings.ToUpper(e.User)
+		return e, nil
+	})
+	// Every match is re-encoded, so bob's zero-padded status is normalized.
+	want := "10:00 user=ADA status=200\n10:01 user=BOB status=7\n"
+	if err != nil || got != want {
+		t.Errorf("ReplaceAll() = %q, %v; want %q", got, err, want)
+	}
+	if !reflect.DeepEqual(seen, []string{"ada", "bob"}) {
+		t.Errorf("fn saw %v, want [ada bob]", seen)
+	}
+
+	called := false
+	if got, err := dec.ReplaceAll("no records", func(e entry) (entry, error) {
+		called = true
+		return e, nil
+	}); got != "no records" || err != nil || called {
+		t.Errorf("ReplaceAll(no match) = %q, %v, called = %v; want target unchanged", got, err, called)
+	}
+
+	// The Encoder is derived on first use and shared by concurrent calls.
+	fresh := rx.MustCompile[entry](`user=(?P<user>\w+) status=(?P<status>\d+)`)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := fresh.ReplaceAll(input, func(e entry) (entry, error) { return e, nil }); err != nil || got != "10:00 user=ada status=200\n10:01 user=bob status=7\n" {
+				t.Errorf("concurrent ReplaceAll() = %q, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDecoder_ReplaceAllErrors(t *testing.T) {
+	type entry struct {
+		User   string `regex:"user"`
+		Status int    `regex:"status"`
+	}
+	keep := func(e entry) (entry, error) { return e, nil }
+	const input = "user=ada status=200 user=bob status=x"
+
+	dec := rx.MustCompile[entry](`user=(?P<user>\w+) status=(?P<status>\w+)`)
+	got, err := dec.ReplaceAll(input, keep)
+	var de *rx.DecodeError
+	if got != input || !errors.As(err, &de) || de.Match != 1 || !strings.Contains(err.Error(), "regextra.Decoder.ReplaceAll: match 1:") {
+		t.Errorf("ReplaceAll(bad status) = %q, %v; want the target and a DecodeError for match 1", got, err)
+	}
+
+	errStop := errors.New("stop")
+	got, err = dec.ReplaceAll("user=ada status=200", func(entry) (entry, error) { return entry{}, errStop })
+	if got != "user=ada status=200" || !errors.Is(err, errStop) {
+		t.Errorf("ReplaceAll(fn error) = %q, %v; want the target and fn's error", got, err)
+	}
+
+	type choice struct {
+		Method string `regex:"method"`
+	}
+	methods := rx.MustCompile[choice](`(?P<method>GET|POST) /`)
+	got, err = methods.ReplaceAll("GET /", func(c choice) (choice, error) {
+		c.Method = "PUT"
+		return c, nil
+	})
+	if got != "PUT /" || err != nil {
+		t.Errorf("ReplaceAll(value outside alternatives) = %q, %v; want %q", got, err, "PUT /")
+	}
+
+	type word struct {
+		W string `regex:"w"`
+	}
+	// The derivation error is cached with the Encoder and returned every call.
+	nonInvertible := rx.MustCompile[word](`[a-z]+(?P<w>\d)`)
+	for range 2 {
+		got, err = nonInvertible.ReplaceAll("ab1", func(w word) (word, error) { return w, nil })
+		if got != "ab1" || !errors.Is(err, rx.ErrNotInvertible) {
+			t.Errorf("ReplaceAll(non-invertible) = %q, %v; want the target and ErrNotInvertible", got, err)
+		}
+	}
+}
+
+func ExampleDecoder_ReplaceAll() {
+	type Entry struct {
+		Time   string `regex:"time"`
+		User   string `regex:"user"`
+		Status int    `regex:"status"`
+	}
+	dec := rx.MustCompile[Entry](`(?P<time>\d\d:\d\d) (?P<user>\w+) (?P<status>\d+)`)
+	logText := "# access log\n10:00 ada 200\n10:01 bob 404\n"
+	out, err := dec.ReplaceAll(logText, func(e Entry) (Entry, error) {
+		e.User = "redacted"
+		return e, nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(out)
+	// Output:
+	// # access log
+	// 10:00 redacted 200
+	// 10:01 redacted 404
+}
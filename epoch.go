@@ -0,0 +1,56 @@
+package regextra
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// epochLayouts maps each pseudo-layout a time.Time field's `layout=` option
+// may name in place of a Go layout to the nanoseconds in its unit: the value
+// is a count of those units since the Unix epoch, as nginx, HAProxy, and
+// many JSON logs write timestamps.
+var epochLayouts = map[string]int64{
+	"unix":      int64(time.Second),
+	"unixmilli": int64(time.Millisecond),
+	"unixmicro": int64(time.Microsecond),
+	"unixnano":  1,
+}
+
+// parseEpoch parses value — an optionally signed decimal count of the units
+// of an epoch pseudo-layout, with an optional fraction: "1718000000" or
+// "1718000000.123" under unix — as the instant it names, in loc (UTC when
+// nil). Digits past the nanosecond are truncated, as time.Parse truncates
+// them.
+func parseEpoch(value string, unit int64, loc *time.Location) (time.Time, error) {
+	num := value
+	if num != "" && (num[0] == '+' || num[0] == '-') {
+		num = num[1:]
+	}
+	whole, frac, _ := strings.Cut(num, ".")
+	if whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return time.Time{}, strconv.ErrSyntax
+	}
+	r, _ := new(big.Rat).SetString(value)
+	r.Mul(r, new(big.Rat).SetInt64(unit))
+	ns := new(big.Int).Quo(r.Num(), r.Denom())
+	sec, nsec := ns.QuoRem(ns, big.NewInt(int64(time.Second)), new(big.Int))
+	if !sec.IsInt64() {
+		return time.Time{}, strconv.ErrRange
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Unix(sec.Int64(), nsec.Int64()).In(loc), nil
+}
+
+// formatEpoch renders t as a count of the units of an epoch pseudo-layout,
+// the inverse of parseEpoch: whole units as an integer, and any remainder as
+// the exact fraction, trailing zeros dropped.
+func formatEpoch(t time.Time, unit int64) string {
+	ns := new(big.Int).Mul(big.NewInt(t.Unix()), big.NewInt(int64(time.Second)))
+	ns.Add(ns, big.NewInt(int64(t.Nanosecond())))
+	digits := len(strconv.FormatInt(unit, 10)) - 1
+	return trimFraction(new(big.Rat).SetFrac(ns, big.NewInt(unit)).FloatString(digits))
+}
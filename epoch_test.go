@@ -0,0 +1,145 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestEpochLayouts(t *testing.T) {
+	type rec struct {
+		S  time.Time `regex:"v,layout=unix"`
+		Ms time.Time `regex:"v,layout=unixmilli"`
+		Us time.Time `regex:"v,layout=unixmicro"`
+		Ns time.Time `regex:"v,layout=unixnano"`
+	}
+	dec := rx.MustCompile[rec](`(?P<v>\S+)`)
+	tests := []struct {
+		input         string
+		s, ms, us, ns time.Time
+	}{
+		{"1718000000", time.Unix(1718000000, 0), time.UnixMilli(1718000000), time.UnixMicro(1718000000), time.Unix(0, 1718000000)},
+		{"0", time.Unix(0, 0), time.Unix(0, 0), time.Unix(0, 0), time.Unix(0, 0)},
+		{"1718000000.123", time.Unix(1718000000, 123e6), time.Unix(1718000, 123000), time.Unix(1718, 123), time.Unix(1, 718000000)},
+		{"+1.5", time.Unix(1, 5e8), time.Unix(0, 1500000), time.Unix(0, 1500), time.Unix(0, 1)},
+		{"-1.5", time.Unix(-2, 5e8), time.Unix(0, -1500000), time.Unix(0, -1500), time.Unix(0, -1)},
+		{".000000000999", time.Unix(0, 0), time.Unix(0, 0), time.Unix(0, 0), time.Unix(0, 0)},
+		{"2.", time.Unix(2, 0), time.UnixMilli(2), time.UnixMicro(2), time.Unix(0, 2)},
+	}
+	for _, tt := range tests {
+		got, err := dec.One(tt.input)
+		if err != nil {
+			t.Errorf("One(%q) error = %v", tt.input, err)
+			continue
+		}
+		for i, pair := range [][2]time.Time{{got.S, tt.s}, {got.Ms, tt.ms}, {got.Us, tt.us}, {got.Ns, tt.ns}} {
+			if !pair[0].Equal(pair[1]) || pair[0].Location() != time.UTC {
+				t.Errorf("One(%q) field %d = %v, want %v in UTC", tt.input, i, pair[0], pair[1].UTC())
+			}
+		}
+	}
+
+	for _, input := range []string{"x", "1e9", "0x10", "1.2.3", "--1", "+", ".", "1_000", "99999999999999999999999"} {
+		var de *rx.DecodeError
+		if _, err := dec.One(input); !errors.As(err, &de) || de.Field != "S" || !strings.Contains(err.Error(), `layout "unix"`) {
+			t.Errorf("One(%q) error = %v, want a DecodeError for S naming the layout", input, err)
+		}
+	}
+}
+
+func TestEpochLayouts_location(t *testing.T) {
+	type rec struct {
+		At    time.Time   `regex:"at,layout=unixmilli"`
+		Def   time.Time   `regex:"none,layout=unix,default=60"`
+		Times []time.Time `regex:"list,split=comma,layout=unix"`
+	}
+	berlin := time.FixedZone("CEST", 2*60*60)
+	dec := rx.MustCompileWith[rec](`(?P<at>\d+) (?P<list>\S+)(?P<none>x)?`, rx.DefaultLocation(berlin))
+	got, err := dec.One("1718000000456 1,2")
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if !got.At.Equal(time.UnixMilli(1718000000456)) || got.At.Location() != berlin {
+		t.Errorf("At = %v, want the instant shown in CEST", got.At)
+	}
+	if !got.Def.Equal(time.Unix(60, 0)) || got.Def.Location() != berlin {
+		t.Errorf("Def = %v, want the default shown in CEST", got.Def)
+	}
+	if !slices.EqualFunc(got.Times, []time.Time{time.Unix(1, 0), time.Unix(2, 0)}, time.Time.Equal) {
+		t.Errorf("Times = %v", got.Times)
+	}
+}
+
+func TestEpochLayouts_encode(t *testing.T) {
+	type rec struct {
+		S  time.Time  `regex:"s,layout=unix"`
+		Ms time.Time  `regex:"ms,layout=unixmilli"`
+		Us *time.Time `regex:"us,layout=unixmicro"`
+		Ns time.Time  `regex:"ns,layout=unixnano"`
+	}
+	dec := rx.MustCompile[rec](`(?P<s>\S+) (?P<ms>\S+) (?P<us>\S+) (?P<ns>\S+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	at := time.Date(2024, 6, 10, 8, 13, 20, 123456789, time.FixedZone("", 2*60*60))
+	before := time.Unix(-1, 250e6)
+	tests := []struct {
+		v    rec
+		want string
+	}{
+		{rec{at, at, &at, at}, "1718000000.123456789 1718000000123.456789 1718000000123456.789 1718000000123456789"},
+		{rec{at.Truncate(time.Second), at.Truncate(time.Millisecond), &before, before}, "1718000000 1718000000123 -750000 -750000000"},
+		{rec{before, before, &before, time.Unix(0, 0)}, "-0.75 -750 -750000 0"},
+	}
+	for _, tt := range tests {
+		s, err := enc.EncodeStrict(tt.v)
+		if err != nil || s != tt.want {
+			t.Errorf("EncodeStrict() = %q, %v; want %q", s, err, tt.want)
+			continue
+		}
+		got, err := dec.One(s)
+		if err != nil || !got.S.Equal(tt.v.S) || !got.Ms.Equal(tt.v.Ms) || !got.Us.Equal(*tt.v.Us) || !got.Ns.Equal(tt.v.Ns) {
+			t.Errorf("One(%q) = %+v, %v; want %+v", s, got, err, tt.v)
+		}
+	}
+}
+
+func TestEpochLayouts_compile(t *testing.T) {
+	_, err := rx.Compile[struct {
+		A int64 `regex:"a,layout=unix"`
+	}](`(?P<a>\d+)`)
+	if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), "field A has `layout=` option but is int64, not time.Time") {
+		t.Errorf("Compile() error = %v, want the layout= check", err)
+	}
+	_, err = rx.Compile[struct {
+		A time.Time `regex:"a,layout=unix,default=soon"`
+	}](`(?P<a>\d+)`)
+	if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), `default "soon" does not convert`) {
+		t.Errorf("Compile() error = %v, want the default check", err)
+	}
+}
+
+func Example_epochTimestamps() {
+	type Access struct {
+		At    time.Time `regex:"at,layout=unix"`
+		Start time.Time `regex:"start,layout=unixmilli"`
+	}
+	dec := rx.MustCompile[Access](`(?P<at>[\d.]+) (?P<start>\d+)`)
+	a, _ := dec.One("1718000000.123 1718000000456")
+	fmt.Println(a.At)
+	fmt.Println(a.Start)
+
+	enc, _ := dec.Encoder()
+	out, _ := enc.Encode(a)
+	fmt.Println(out)
+	// Output:
+	// 2024-06-10 06:13:20.123 +0000 UTC
+	// 2024-06-10 06:13:20.456 +0000 UTC
+	// 1718000000.123 1718000000456
+}
@@ -0,0 +1,89 @@
+package regextra
+
+import "regexp"
+
+// Match is one match of a regular expression in a target: the matched text
+// and its byte span, plus every named capture group with its own span. It is
+// the positional counterpart to the [NamedGroups] map, for callers that need
+// to know where each value sits — to highlight it, or to splice the target —
+// without going back to FindStringSubmatchIndex and SubexpIndex.
+type Match struct {
+	// Value is the text of the whole match, target[Start:End].
+	Value string
+	// Start and End are the byte offsets of the whole match in the target.
+	Start, End int
+	// Groups holds every named group declared on the pattern, by name. A
+	// group that did not participate in the match is present with
+	// Participated false. When the pattern reuses a name, the last
+	// occurrence that participated wins, as in [NamedGroups].
+	Groups map[string]Group
+}
+
+// Group is one named capture group of a [Match].
+type Group struct {
+	// Value is the text the group matched, target[Start:End], or "" when it
+	// did not participate.
+	Value string
+	// Start and End are the group's byte offsets in the target, or -1 when
+	// it did not participate.
+	Start, End int
+	// Participated reports whether the group took part in the match. It
+	// tells a group that matched an empty span (true, with Start == End)
+	// from one that was skipped (false), which Value alone cannot.
+	Participated bool
+}
+
+// FindMatch returns the first match of re in target with the span of every
+// named group, and true; or nil and false if re does not match.
+//
+// Example:
+//
+//	re := regexp.MustCompile(`(?P<user>\w+)@(?P<domain>[\w.]+)`)
+//	m, _ := regextra.FindMatch(re, "mail alice@example.com")
+//	// m.Start = 5, m.Groups["domain"] = Group{Value: "example.com", Start: 11, End: 22, Participated: true}
+func FindMatch(re *regexp.Regexp, target string) (*Match, bool) {
+	loc := re.FindStringSubmatchIndex(target)
+	if loc == nil {
+		return nil, false
+	}
+	return newMatch(re.SubexpNames(), target, loc), true
+}
+
+// FindAllMatches returns every match of re in target, in order, each as
+// [FindMatch] reports it. On no match, it returns an empty (non-nil) slice.
+func FindAllMatches(re *regexp.Regexp, target string) []*Match {
+	locs := re.FindAllStringSubmatchIndex(target, -1)
+	names := re.SubexpNames()
+	out := make([]*Match, len(locs))
+	for i, loc := range locs {
+		out[i] = newMatch(names, target, loc)
+	}
+	return out
+}
+
+// newMatch builds the Match for one match's index pairs. names is
+// re.SubexpNames(). Reused names resolve as in fillNamedGroupValues: a
+// participating occurrence always wins over a non-participating one, and the
+// last participating occurrence wins over earlier ones.
+func newMatch(names []string, target string, loc []int) *Match {
+	m := &Match{
+		Value:  target[loc[0]:loc[1]],
+		Start:  loc[0],
+		End:    loc[1],
+		Groups: make(map[string]Group, len(names)-1),
+	}
+	for i, name := range names {
+		if i == 0 || name == "" {
+			continue
+		}
+		start, end := loc[2*i], loc[2*i+1]
+		if start < 0 {
+			if _, ok := m.Groups[name]; !ok {
+				m.Groups[name] = Group{Start: -1, End: -1}
+			}
+			continue
+		}
+		m.Groups[name] = Group{Value: target[start:end], Start: start, End: end, Participated: true}
+	}
+	return m
+}
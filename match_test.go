@@ -0,0 +1,132 @@
+package regextra_test
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestFindMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		want    *rx.Match
+	}{
+		{
+			name:    "spans of every group",
+			pattern: `(?P<user>\w+)@(?P<domain>[\w.]+)`,
+			target:  "mail alice@example.com now",
+			want: &rx.Match{Value: "alice@example.com", Start: 5, End: 22, Groups: map[string]rx.Group{
+				"user":   {Value: "alice", Start: 5, End: 10, Participated: true},
+				"domain": {Value: "example.com", Start: 11, End: 22, Participated: true},
+			}},
+		},
+		{
+			name:    "non-participating group is present",
+			pattern: `(?P<key>\w+)(?:=(?P<value>\w+))?`,
+			target:  "flag",
+			want: &rx.Match{Value: "flag", Start: 0, End: 4, Groups: map[string]rx.Group{
+				"key":   {Value: "flag", Start: 0, End: 4, Participated: true},
+				"value": {Start: -1, End: -1},
+			}},
+		},
+		{
+			name:    "empty span participates",
+			pattern: `(?P<key>\w+)=(?P<value>\w*)`,
+			target:  "k=",
+			want: &rx.Match{Value: "k=", Start: 0, End: 2, Groups: map[string]rx.Group{
+				"key":   {Value: "k", Start: 0, End: 1, Participated: true},
+				"value": {Start: 2, End: 2, Participated: true},
+			}},
+		},
+		{
+			name:    "reused name: participating branch wins",
+			pattern: `(?:id=(?P<ref>\d+)|name=(?P<ref>\w+))`,
+			target:  "name=bob",
+			want: &rx.Match{Value: "name=bob", Start: 0, End: 8, Groups: map[string]rx.Group{
+				"ref": {Value: "bob", Start: 5, End: 8, Participated: true},
+			}},
+		},
+		{
+			name:    "reused name: last participating occurrence wins",
+			pattern: `(?P<w>\w+) (?P<w>\w+)`,
+			target:  "hello world",
+			want: &rx.Match{Value: "hello world", Start: 0, End: 11, Groups: map[string]rx.Group{
+				"w": {Value: "world", Start: 6, End: 11, Participated: true},
+			}},
+		},
+		{
+			name:    "unnamed groups are left out",
+			pattern: `(\w+)-(?P<n>\d+)`,
+			target:  "a-1",
+			want: &rx.Match{Value: "a-1", Start: 0, End: 3, Groups: map[string]rx.Group{
+				"n": {Value: "1", Start: 2, End: 3, Participated: true},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rx.FindMatch(regexp.MustCompile(tt.pattern), tt.target)
+			if !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindMatch() = %+v, %v; want %+v", got, ok, tt.want)
+			}
+		})
+	}
+
+	if got, ok := rx.FindMatch(regexp.MustCompile(`(?P<n>\d+)`), "none"); got != nil || ok {
+		t.Errorf("FindMatch(no match) = %+v, %v; want nil, false", got, ok)
+	}
+}
+
+func TestFindAllMatches(t *testing.T) {
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\w+)`)
+	got := rx.FindAllMatches(re, "a=1 bb=22")
+	if len(got) != 2 {
+		t.Fatalf("FindAllMatches() returned %d matches, want 2", len(got))
+	}
+	want := rx.Group{Value: "22", Start: 7, End: 9, Participated: true}
+	if got[1].Start != 4 || got[1].Value != "bb=22" || got[1].Groups["value"] != want {
+		t.Errorf("FindAllMatches()[1] = %+v, want the span of bb=22", got[1])
+	}
+	// Spans index the target, as the values do.
+	for _, m := range got {
+		for name, g := range m.Groups {
+			if "a=1 bb=22"[g.Start:g.End] != g.Value {
+				t.Errorf("group %s span [%d:%d] does not hold %q", name, g.Start, g.End, g.Value)
+			}
+		}
+	}
+
+	if got := rx.FindAllMatches(re, "none"); got == nil || len(got) != 0 {
+		t.Errorf("FindAllMatches(no match) = %#v, want an empty non-nil slice", got)
+	}
+}
+
+func ExampleFindMatch() {
+	re := regexp.MustCompile(`(?P<level>ERROR|WARN) (?P<msg>.+)`)
+	line := "12:00 ERROR disk full"
+	m, _ := rx.FindMatch(re, line)
+	level := m.Groups["level"]
+	// Underline the level, as a highlighting UI would.
+	fmt.Println(line)
+	fmt.Println(strings.Repeat(" ", level.Start) + strings.Repeat("^", level.End-level.Start))
+	// Output:
+	// 12:00 ERROR disk full
+	//       ^^^^^
+}
+
+func ExampleFindAllMatches() {
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\w*)`)
+	for _, m := range rx.FindAllMatches(re, "a=1 b=") {
+		v := m.Groups["value"]
+		fmt.Printf("%s at %d: %q [%d:%d]\n", m.Groups["key"].Value, m.Start, v.Value, v.Start, v.End)
+	}
+	// Output:
+	// a at 0: "1" [2:3]
+	// b at 4: "" [6:6]
+}
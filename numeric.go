@@ -0,0 +1,345 @@
+package regextra
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// numberBase returns the base a `base=` option names for an integer field: 10
+// when the option is absent, otherwise 0 — the base comes from the value's
+// prefix, as in Go literals (0x, 0o or a leading 0, 0b) — or 2 through 36.
+func numberBase(opts map[string]string) (int, error) {
+	s, ok := opts["base"]
+	if !ok {
+		return 10, nil
+	}
+	base, err := strconv.Atoi(s)
+	if err != nil || base < 0 || base == 1 || base > 36 {
+		return 0, fmt.Errorf("invalid base %q: want 0 or 2 through 36", s)
+	}
+	return base, nil
+}
+
+// groupSeparator returns the digit-grouping separator a `sep=` option names,
+// spelled as `split=` spells its separator (see splitSeparator), or "" when
+// the option is absent.
+func groupSeparator(opts map[string]string) string {
+	sep, _ := separatorOption(opts, "sep")
+	return sep
+}
+
+// parseIntOption parses an integer field's value under its `base=`, `sep=`,
+// and `unit=` options: the separator is removed wherever it appears, then the
+// rest is parsed in the base or, with a unit, as a quantity (see parseUnit).
+func parseIntOption(value string, opts map[string]string, bitSize int) (int64, error) {
+	base, err := numberBase(opts)
+	if err != nil {
+		return 0, err
+	}
+	unit, err := unitOption(opts)
+	if err != nil {
+		return 0, err
+	}
+	if sep := groupSeparator(opts); sep != "" {
+		value = strings.ReplaceAll(value, sep, "")
+	}
+	if unit != "" {
+		return parseUnitInt(value, unit, bitSize)
+	}
+	return strconv.ParseInt(value, base, bitSize)
+}
+
+// parseUintOption is parseIntOption for an unsigned integer field.
+func parseUintOption(value string, opts map[string]string, bitSize int) (uint64, error) {
+	base, err := numberBase(opts)
+	if err != nil {
+		return 0, err
+	}
+	unit, err := unitOption(opts)
+	if err != nil {
+		return 0, err
+	}
+	if sep := groupSeparator(opts); sep != "" {
+		value = strings.ReplaceAll(value, sep, "")
+	}
+	if unit != "" {
+		return parseUnitUint(value, unit, bitSize)
+	}
+	return strconv.ParseUint(value, base, bitSize)
+}
+
+// parseFloatOption parses a float field's value under its `sep=`, `percent`,
+// and `unit=` options.
+func parseFloatOption(value string, opts map[string]string, bitSize int) (float64, error) {
+	unit, err := unitOption(opts)
+	if err != nil {
+		return 0, err
+	}
+	if sep := groupSeparator(opts); sep != "" {
+		value = strings.ReplaceAll(value, sep, "")
+	}
+	if unit != "" {
+		return parseUnitFloat(value, unit, bitSize)
+	}
+	if _, ok := opts["percent"]; ok {
+		return parsePercent(value, bitSize)
+	}
+	return strconv.ParseFloat(value, bitSize)
+}
+
+// parsePercent parses a percentage, with or without its trailing %, as the
+// fraction it stands for: "12.5%" is 0.125. It moves the decimal point in the
+// text rather than dividing by 100, so the result is the float nearest the
+// exact fraction — what ParseFloat("0.125") returns — with no second
+// rounding.
+func parsePercent(value string, bitSize int) (float64, error) {
+	num := strings.TrimSuffix(value, "%")
+	mant, exp := num, 0
+	if i := strings.IndexAny(num, "eE"); i >= 0 {
+		e, err := strconv.Atoi(num[i+1:])
+		if err != nil {
+			return 0, &strconv.NumError{Func: "ParseFloat", Num: value, Err: strconv.ErrSyntax}
+		}
+		mant, exp = num[:i], e
+	}
+	f, err := strconv.ParseFloat(mant+"e"+strconv.Itoa(exp-2), bitSize)
+	if ne, ok := err.(*strconv.NumError); ok {
+		ne.Num = value
+	}
+	return f, err
+}
+
+// formatIntOption renders an integer field's value under its `base=`,
+// `sep=`, and `unit=` options, the inverse of parseIntOption. Base 0 renders
+// in decimal, which reads back under any prefix rule.
+func formatIntOption(v int64, opts map[string]string) (string, error) {
+	base, err := numberBase(opts)
+	if err != nil {
+		return "", err
+	}
+	unit, err := unitOption(opts)
+	if err != nil {
+		return "", err
+	}
+	if unit != "" {
+		return formatUnitInt(big.NewInt(v), unit, opts)
+	}
+	if base == 0 {
+		base = 10
+	}
+	return groupDigits(strconv.FormatInt(v, base), groupSeparator(opts), base), nil
+}
+
+// formatUintOption is formatIntOption for an unsigned integer field.
+func formatUintOption(v uint64, opts map[string]string) (string, error) {
+	base, err := numberBase(opts)
+	if err != nil {
+		return "", err
+	}
+	unit, err := unitOption(opts)
+	if err != nil {
+		return "", err
+	}
+	if unit != "" {
+		return formatUnitInt(new(big.Int).SetUint64(v), unit, opts)
+	}
+	if base == 0 {
+		base = 10
+	}
+	return groupDigits(strconv.FormatUint(v, base), groupSeparator(opts), base), nil
+}
+
+// formatFloatOption renders a float field's value under its `sep=`,
+// `percent`, and `unit=` options, the inverse of parseFloatOption. With none
+// it is strconv's shortest form, exponent and all; otherwise the value is
+// written out in positional notation, so the separator has an integer part to
+// group.
+func formatFloatOption(f float64, opts map[string]string, bitSize int) (string, error) {
+	unit, err := unitOption(opts)
+	if err != nil {
+		return "", err
+	}
+	_, percent := opts["percent"]
+	sep := groupSeparator(opts)
+	switch {
+	case unit != "":
+		return formatUnitFloat(f, bitSize, unit, opts)
+	case percent:
+		return groupDigits(shiftDecimal(f, bitSize, 2), sep, 10) + "%", nil
+	case sep != "":
+		return groupDigits(strconv.FormatFloat(f, 'f', -1, bitSize), sep, 10), nil
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize), nil
+}
+
+// shiftDecimal renders f times 10^places in positional notation by moving
+// the decimal point of f's shortest decimal form, with no rounding: as a
+// percentage, 0.125 shifted two places is "12.5", which parsePercent reads
+// back as exactly f.
+func shiftDecimal(f float64, bitSize, places int) string {
+	if f == 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	mant, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, bitSize), "e")
+	e, _ := strconv.Atoi(exp)
+	sign := ""
+	if mant[0] == '-' {
+		sign, mant = "-", mant[1:]
+	}
+	digits := strings.Replace(mant, ".", "", 1)
+	// The first digit sits at 10^e; shifted, at 10^(e+places), so
+	// e+places+1 digits come before the point.
+	switch n := e + places + 1; {
+	case n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	case n >= len(digits):
+		return sign + digits + strings.Repeat("0", n-len(digits))
+	default:
+		return sign + digits[:n] + "." + digits[n:]
+	}
+}
+
+// groupDigits inserts sep between groups of digits in the integer part of the
+// number s, counting from the point (or the end): every four digits in base 2
+// and 16, every three otherwise. An empty sep returns s unchanged.
+func groupDigits(s, sep string, base int) string {
+	if sep == "" {
+		return s
+	}
+	size := 3
+	if base == 2 || base == 16 {
+		size = 4
+	}
+	start := 0
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		start = 1
+	}
+	end := strings.IndexByte(s, '.')
+	if end < 0 {
+		end = len(s)
+	}
+	var b strings.Builder
+	b.WriteString(s[:start])
+	for i := start; i < end; i++ {
+		if i > start && (end-i)%size == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteString(s[end:])
+	return b.String()
+}
+
+// checkNumberOptions implements Compile's validation of the numeric format
+// options on a field of type t (or its elements, for a slice or array): base=
+// only on integers, and with a valid base; sep= only on integers and floats;
+// percent only on floats; unit= on integers and floats, alone of base= and
+// percent, with its prec= and unitbase= valid and never without it. A type
+// that converts itself, or time.Duration, never reaches the numeric
+// conversions, so it takes none of them.
+func checkNumberOptions(t reflect.Type, opts map[string]string, cfg *DecoderConfig) error {
+	ft := t
+	if isCollectionType(ft, cfg) {
+		ft = ft.Elem()
+	}
+	if ft.Kind() == reflect.Ptr && !cfg.registered(ft) {
+		ft = ft.Elem()
+	}
+	var isInt, isFloat bool
+	if ft != timeDurationType && !convertsItself(ft, cfg) {
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			isInt = true
+		case reflect.Float32, reflect.Float64:
+			isFloat = true
+		}
+	}
+	if _, ok := opts["base"]; ok {
+		if !isInt {
+			return fmt.Errorf("has `base=` option but is %v, not an integer", t)
+		}
+		if _, err := numberBase(opts); err != nil {
+			return err
+		}
+	}
+	if _, ok := opts["sep"]; ok && !isInt && !isFloat {
+		return fmt.Errorf("has `sep=` option but is %v, not an integer or float", t)
+	}
+	if _, ok := opts["percent"]; ok && !isFloat {
+		return fmt.Errorf("has `percent` option but is %v, not a float", t)
+	}
+
+	unit, err := unitOption(opts)
+	if err != nil {
+		return err
+	}
+	if unit == "" {
+		for _, key := range []string{"prec", "unitbase"} {
+			if _, ok := opts[key]; ok {
+				return fmt.Errorf("has `%s=` option but no `unit=`", key)
+			}
+		}
+		return nil
+	}
+	if !isInt && !isFloat {
+		return fmt.Errorf("has `unit=` option but is %v, not an integer or float", t)
+	}
+	for _, key := range []string{"base", "percent"} {
+		if _, ok := opts[key]; ok {
+			return fmt.Errorf("has `unit=` option, which does not combine with `%s`", key)
+		}
+	}
+	if _, ok := opts["unitbase"]; ok && unit != "bytes" {
+		return errors.New("has `unitbase=` option, which applies only to `unit=bytes`")
+	}
+	if _, err := unitStep(unit, opts); err != nil {
+		return err
+	}
+	_, err = unitPrecision(opts)
+	return err
+}
+
+// numberOptionRunes extends the characters the [StrictTypes] check accepts
+// for a numeric field by what its format options admit: the digits of a base
+// past 10 and, for base 0, the prefix letters and digit underscores; the
+// grouping separator; the percent sign; and a unit's point, white space, and
+// suffix letters.
+func numberOptionRunes(accepted []rune, opts map[string]string) []rune {
+	var extra []rune
+	if base, err := numberBase(opts); err == nil {
+		switch {
+		case base == 0:
+			extra = append(extra, 'A', 'F', 'O', 'O', 'X', 'X', '_', '_', 'a', 'f', 'o', 'o', 'x', 'x')
+		case base > 10:
+			last := rune('a' + base - 11)
+			extra = append(extra, 'A', last-'a'+'A', 'a', last)
+		}
+	}
+	for _, r := range groupSeparator(opts) {
+		extra = append(extra, r, r)
+	}
+	if _, ok := opts["percent"]; ok {
+		extra = append(extra, '%', '%')
+	}
+	switch unit, _ := unitOption(opts); unit {
+	case "bytes":
+		for _, r := range "\t .BEGIKMPTbegikmpt" {
+			extra = append(extra, r, r)
+		}
+	case "si":
+		for _, r := range "\t .EGKMPTk" {
+			extra = append(extra, r, r)
+		}
+	}
+	if extra == nil {
+		return accepted
+	}
+	return append(slices.Clip(accepted), extra...)
+}
@@ -0,0 +1,223 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestNumericOptions(t *testing.T) {
+	type rec struct {
+		Hex   uint64  `regex:"hex,base=16"`
+		Oct   uint32  `regex:"oct,base=8"`
+		Bin   int8    `regex:"bin,base=2"`
+		Auto  int     `regex:"auto,base=0"`
+		Count int64   `regex:"count,sep=,"`
+		Dots  uint    `regex:"dots,sep=."`
+		Float float64 `regex:"float,sep=comma"`
+		Pct   float32 `regex:"pct,percent"`
+	}
+	dec := rx.MustCompile[rec](`(?P<hex>\S+) (?P<oct>\S+) (?P<bin>\S+) (?P<auto>\S+) (?P<count>\S+) (?P<dots>\S+) (?P<float>\S+) (?P<pct>\S+)`)
+	tests := []struct {
+		input string
+		want  rec
+	}{
+		{"1F 755 -101 0x1f 1,234,567 1.000.000 12,345.5 12.5%", rec{31, 493, -5, 31, 1234567, 1000000, 12345.5, 0.125}},
+		{"ff 0 1 0o17 -1,000 7 0.25 50", rec{255, 0, 1, 15, -1000, 7, 0.25, 0.5}},
+		{"0 1 0 0b11 12 1 -1,2 1e2%", rec{0, 1, 0, 3, 12, 1, -12, 1}},
+	}
+	for _, tt := range tests {
+		got, err := dec.One(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("One(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+		}
+	}
+
+	// sep= only removes its separator; an underscore is still invalid
+	// outside base 0.
+	const underscore = "0 1 0 017 1_2 1 0 -0.5%"
+	var de *rx.DecodeError
+	if _, err := dec.One(underscore); !errors.As(err, &de) || de.Field != "Count" {
+		t.Errorf("One(%q) error = %v, want a DecodeError for Count", underscore, err)
+	}
+	for _, input := range []string{
+		"1g 0 0 0 0 0 0 0",
+		"0 8 0 0 0 0 0 0",
+		"0 0 10000000 0 0 0 0 0",
+		"0 0 0 0 0 0 0 5%%",
+		"0 0 0 0 0 0 0 %",
+		"0 0 0 0 0 0 0 1e%",
+	} {
+		if _, err := dec.One(input); !errors.As(err, &de) {
+			t.Errorf("One(%q) error = %v, want a DecodeError", input, err)
+		} else if strings.Contains(de.Error(), "e-2") {
+			t.Errorf("One(%q) error = %v, reports the rewritten percentage", input, err)
+		}
+	}
+}
+
+func TestNumericOptions_percentExact(t *testing.T) {
+	type rec struct {
+		P float64 `regex:"p,percent"`
+	}
+	dec := rx.MustCompile[rec](`(?P<p>\S+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	for _, f := range []float64{0.07, 0.125, 1, 1.5, 123.456, 1e-9, 1e20, -0.0033, 0.1 + 0.2, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		s, err := enc.Encode(rec{f})
+		if err != nil {
+			t.Fatalf("Encode(%v) error = %v", f, err)
+		}
+		got, err := dec.One(s)
+		if err != nil || got.P != f || strings.ContainsAny(s, "eE") || !strings.HasSuffix(s, "%") {
+			t.Errorf("Encode(%v) = %q, decoded as %v, %v", f, s, got.P, err)
+		}
+	}
+	if got, _ := dec.One("7%"); got.P != 0.07 {
+		t.Errorf(`One("7%%") = %v, want exactly 0.07`, got.P)
+	}
+}
+
+func TestNumericOptions_encode(t *testing.T) {
+	type rec struct {
+		ID    uint64    `regex:"id,base=16,trimprefix=0x"`
+		Mode  int       `regex:"mode,base=8"`
+		Auto  int       `regex:"auto,base=0"`
+		Flags uint8     `regex:"flags,base=2,sep=_"`
+		Count int64     `regex:"count,sep=,"`
+		Amt   float64   `regex:"amt,sep=,"`
+		Pct   float64   `regex:"pct,sep=,,percent"`
+		Small float32   `regex:"small"`
+		List  []int     `regex:"list,split=;,sep=,"`
+		Ptr   *uint16   `regex:"ptr,base=16"`
+		Many  []float64 `regex:"many,percent"`
+	}
+	dec := rx.MustCompile[rec](`(?P<id>\S+) (?P<mode>\S+) (?P<auto>\S+) (?P<flags>\S+) (?P<count>\S+) (?P<amt>\S+) (?P<pct>\S+) (?P<small>\S+) (?P<list>\S+) (?P<ptr>\S+) (?P<many>\S+)(?: (?P<many>\S+))?`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	ptr := uint16(0xbeef)
+	want := rec{
+		ID: 0x1f, Mode: 0o755, Auto: -42, Flags: 0b1010_0101, Count: -1234567, Amt: 1234567.25,
+		Pct: 12.345, Small: 1e-7, List: []int{1000, 25, -999999}, Ptr: &ptr, Many: []float64{0.5, 1},
+	}
+	s, err := enc.EncodeStrict(want)
+	if err != nil {
+		t.Fatalf("EncodeStrict() error = %v", err)
+	}
+	const wantS = "0x1f 755 -42 1010_0101 -1,234,567 1,234,567.25 1,234.5% 1e-07 1,000;25;-999,999 beef 50% 100%"
+	if s != wantS {
+		t.Errorf("EncodeStrict() = %q, want %q", s, wantS)
+	}
+	got, err := dec.One(s)
+	if err != nil || got.ID != want.ID || got.Mode != want.Mode || got.Auto != want.Auto || got.Flags != want.Flags ||
+		got.Count != want.Count || got.Amt != want.Amt || got.Pct != want.Pct || got.Small != want.Small ||
+		!slices.Equal(got.List, want.List) || *got.Ptr != ptr || !slices.Equal(got.Many, want.Many) {
+		t.Errorf("One(Encode()) = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestNumericOptions_compile(t *testing.T) {
+	tests := []struct {
+		name string
+		try  func() error
+		want string
+	}{
+		{"base on float", func() error {
+			_, err := rx.Compile[struct {
+				A float64 `regex:"a,base=16"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `base=` option but is float64, not an integer"},
+		{"base on a type that converts itself", func() error {
+			_, err := rx.Compile[struct {
+				A []textInt `regex:"a,base=16"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `base=` option but is []regextra_test.textInt, not an integer"},
+		{"sep on duration", func() error {
+			_, err := rx.Compile[struct {
+				A time.Duration `regex:"a,sep=,"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `sep=` option but is time.Duration, not an integer or float"},
+		{"bad base", func() error {
+			_, err := rx.Compile[struct {
+				A int `regex:"a,base=1"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, `field A invalid base "1": want 0 or 2 through 36`},
+		{"sep on string", func() error {
+			_, err := rx.Compile[struct {
+				A string `regex:"a,sep=,"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `sep=` option but is string, not an integer or float"},
+		{"percent on int", func() error {
+			_, err := rx.Compile[struct {
+				A *int `regex:"a,percent"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `percent` option but is *int, not a float"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.try()
+			if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() error = %v, want ErrInvalidStruct containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// textInt is an integer type that converts itself, so it takes no numeric
+// format options.
+type textInt int
+
+func (t *textInt) UnmarshalText([]byte) error { return nil }
+
+func TestNumericOptions_strictTypes(t *testing.T) {
+	type rec struct {
+		Hex  int     `regex:"hex,base=16"`
+		Auto uint    `regex:"auto,base=0"`
+		N    int     `regex:"n,sep=,"`
+		Pct  float64 `regex:"pct,percent,sep='"`
+	}
+	if _, err := rx.CompileWith[rec](`(?P<hex>-?[0-9a-fA-F]+) (?P<auto>0[xob][0-9a-f_]+) (?P<n>[\d,]+) (?P<pct>[\d.']+%)`, rx.StrictTypes()); err != nil {
+		t.Errorf("CompileWith(StrictTypes) error = %v", err)
+	}
+	_, err := rx.CompileWith[rec](`(?P<hex>[0-9a-g]+) (?P<auto>\d+) (?P<n>\d+) (?P<pct>\d+)`, rx.StrictTypes())
+	if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), `can match "g"`) {
+		t.Errorf("CompileWith(StrictTypes) error = %v, want one naming \"g\"", err)
+	}
+}
+
+func Example_numericFormats() {
+	type Stats struct {
+		ReqID uint64  `regex:"req,base=16,trimprefix=0x"`
+		Mode  uint32  `regex:"mode,base=8"`
+		Bytes int64   `regex:"bytes,sep=,"`
+		CPU   float64 `regex:"cpu,percent"`
+	}
+	dec := rx.MustCompile[Stats](`req=(?P<req>\S+) mode=(?P<mode>\d+) bytes=(?P<bytes>[\d,]+) cpu=(?P<cpu>[\d.]+%)`)
+	s, _ := dec.One("req=0x1f mode=0755 bytes=1,234,567 cpu=12.5%")
+	fmt.Printf("%+v\n", s)
+
+	s.Bytes *= 2
+	s.CPU = 0.07
+	enc, _ := dec.Encoder()
+	out, _ := enc.Encode(s)
+	fmt.Println(out)
+	// Output:
+	// {ReqID:31 Mode:493 Bytes:1234567 CPU:0.125}
+	// req=0x1f mode=755 bytes=2,469,134 cpu=7%
+}
@@ -0,0 +1,299 @@
+package regextra
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// Patterns is a registry of named sub-patterns in the style of Logstash grok,
+// so a pattern can be written as
+//
+//	%{IPORHOST:client} %{USER:ident} \[%{HTTPDATE:time}\] "%{HTTPMETHOD:method} %{NOTSPACE:path}" %{INT:status:int}
+//
+// instead of hand-expanding each piece. A reference takes one of three forms:
+//
+//   - %{NAME} inserts NAME's definition as a non-capturing group.
+//   - %{NAME:group} inserts it as the named capture group `(?P<group>...)`.
+//   - %{NAME:group:type} does the same and declares the group's type, "int"
+//     or "float": [CompileWith] then fails with [ErrInvalidStruct] unless the
+//     field the group decodes into is an integer or a float, respectively
+//     (or a pointer, slice, or array of one, or a type that converts itself).
+//
+// NAME starts with a letter or underscore, so `a%{2}` stays a quantifier.
+// Definitions may themselves hold references, which expand recursively; a
+// reference cycle is an error. Pass a registry to [CompileWith] with
+// [UsePatterns], or expand a pattern yourself with [Patterns.Expand].
+//
+// [NewPatterns] returns a registry preloaded with common definitions, in
+// RE2 syntax: numbers (INT, POSINT, NONNEGINT, NUMBER), words and text
+// (WORD, NOTSPACE, SPACE, DATA, GREEDYDATA, QUOTEDSTRING, QS, UUID,
+// USERNAME, USER, LOGLEVEL), networks (IPV4, IPV6, IP, HOSTNAME, IPORHOST,
+// HOSTPORT, URIPATH, HTTPMETHOD), and dates (YEAR, MONTH, MONTHNUM,
+// MONTHDAY, DAY, HOUR, MINUTE, SECOND, TIME, ISO8601_TIMEZONE,
+// TIMESTAMP_ISO8601, SYSLOGTIMESTAMP, HTTPDATE). Grok definitions that use
+// lookaround or atomic groups, which Go's regexp does not support, must be
+// rewritten when ported.
+//
+// A Patterns is safe for concurrent use; lookups take no lock. The zero value
+// is an empty registry ready to use.
+type Patterns struct {
+	mu sync.Mutex // serializes Define
+	m  atomic.Pointer[map[string]string]
+}
+
+// builtinPatterns are the definitions [NewPatterns] preloads.
+var builtinPatterns = map[string]string{
+	"USERNAME":   `[a-zA-Z0-9._-]+`,
+	"USER":       `%{USERNAME}`,
+	"INT":        `[+-]?[0-9]+`,
+	"POSINT":     `\b[1-9][0-9]*\b`,
+	"NONNEGINT":  `\b[0-9]+\b`,
+	"NUMBER":     `[+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)`,
+	"WORD":       `\b\w+\b`,
+	"NOTSPACE":   `\S+`,
+	"SPACE":      `\s*`,
+	"DATA":       `.*?`,
+	"GREEDYDATA": `.*`,
+	// QUOTEDSTRING keeps its quotes, as grok's does.
+	"QUOTEDSTRING": `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`(?:[^`\\\\]|\\\\.)*`",
+	"QS":           `%{QUOTEDSTRING}`,
+	"UUID":         `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"LOGLEVEL":     `(?i:trace|debug|info|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?|alert)`,
+
+	"IPV4": `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	// IPV6 lists the embedded-IPv4 and longer forms first, so leftmost-first
+	// matching prefers the longest reading of an address.
+	"IPV6": `(?:(?:[0-9A-Fa-f]{1,4}:){6}%{IPV4}` +
+		`|::(?:[fF]{4}(?::0{1,4})?:)?%{IPV4}` +
+		`|(?:[0-9A-Fa-f]{1,4}:){1,4}:%{IPV4}` +
+		`|(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}` +
+		`|(?:[0-9A-Fa-f]{1,4}:){1,6}(?::[0-9A-Fa-f]{1,4}){1,6}` +
+		`|(?:[0-9A-Fa-f]{1,4}:){1,7}:` +
+		`|:(?::[0-9A-Fa-f]{1,4}){1,7}` +
+		`|::)`,
+	"IP":         `%{IPV6}|%{IPV4}`,
+	"HOSTNAME":   `[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?`,
+	"IPORHOST":   `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT":   `%{IPORHOST}:%{POSINT}`,
+	"URIPATH":    `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"HTTPMETHOD": `GET|HEAD|POST|PUT|DELETE|CONNECT|OPTIONS|TRACE|PATCH`,
+
+	"YEAR":              `[0-9]{4}`,
+	"MONTH":             `\b(?:Jan(?:uary)?|Feb(?:ruary)?|Mar(?:ch)?|Apr(?:il)?|May|Jun(?:e)?|Jul(?:y)?|Aug(?:ust)?|Sep(?:tember)?|Oct(?:ober)?|Nov(?:ember)?|Dec(?:ember)?)\b`,
+	"MONTHNUM":          `0?[1-9]|1[0-2]`,
+	"MONTHDAY":          `0[1-9]|[12][0-9]|3[01]|[1-9]`,
+	"DAY":               `\b(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)\b`,
+	"HOUR":              `2[0-3]|[01]?[0-9]`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} [+-][0-9]{4}`,
+}
+
+// NewPatterns returns a registry preloaded with the built-in definitions
+// listed on [Patterns].
+func NewPatterns() *Patterns {
+	p := &Patterns{}
+	m := maps.Clone(builtinPatterns)
+	p.m.Store(&m)
+	return p
+}
+
+// Define sets the definition of name, replacing any earlier one, built-in
+// definitions included. The definition may reference other names, which need
+// not be defined yet: references are resolved when a pattern is expanded.
+// Define fails if name is not a letter or underscore followed by letters,
+// digits, and underscores.
+func (p *Patterns) Define(name, definition string) error {
+	if !patternName.MatchString(name) {
+		return fmt.Errorf("regextra: invalid pattern name %q", name)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var next map[string]string
+	if cur := p.m.Load(); cur != nil {
+		next = maps.Clone(*cur)
+	} else {
+		next = make(map[string]string, 1)
+	}
+	next[name] = definition
+	p.m.Store(&next)
+	return nil
+}
+
+// Expand returns pattern with every %{...} reference replaced by its
+// definition, recursively, ready for [regexp.Compile] or [Compile]. It fails,
+// wrapping [ErrInvalidPattern], on a reference to an undefined name, a
+// reference cycle, a group name Go's regexp rejects, or a type other than
+// "int" or "float". Expand does not check types against fields; compile with
+// [UsePatterns] for that.
+func (p *Patterns) Expand(pattern string) (string, error) {
+	expanded, _, err := p.expand(pattern)
+	return expanded, err
+}
+
+// patternRef matches one %{NAME}, %{NAME:group}, or %{NAME:group:type}
+// reference.
+var patternRef = regexp.MustCompile(`%\{([A-Za-z_][A-Za-z0-9_]*)(?::([^:}]*))?(?::([^:}]*))?\}`)
+
+// expand is Expand, also returning each typed group's declared type.
+func (p *Patterns) expand(pattern string) (string, map[string]string, error) {
+	var defs map[string]string
+	if m := p.m.Load(); m != nil {
+		defs = *m
+	}
+	var types map[string]string
+	var stack []string // names being expanded, outermost first
+	var walk func(src string) (string, error)
+	walk = func(src string) (string, error) {
+		var b strings.Builder
+		last := 0
+		for _, m := range patternRef.FindAllStringSubmatchIndex(src, -1) {
+			b.WriteString(src[last:m[0]])
+			last = m[1]
+			ref, name := src[m[0]:m[1]], src[m[2]:m[3]]
+			var group, typ string
+			if m[4] >= 0 {
+				group = src[m[4]:m[5]]
+			}
+			if m[6] >= 0 {
+				typ = src[m[6]:m[7]]
+			}
+
+			def, ok := defs[name]
+			if !ok {
+				return "", fmt.Errorf("%w: %s: no pattern named %s", ErrInvalidPattern, ref, name)
+			}
+			if i := slices.Index(stack, name); i >= 0 {
+				return "", fmt.Errorf("%w: pattern cycle %s", ErrInvalidPattern, strings.Join(append(stack[i:], name), " -> "))
+			}
+			stack = append(stack, name)
+			body, err := walk(def)
+			stack = stack[:len(stack)-1]
+			if err != nil {
+				return "", err
+			}
+
+			if group == "" {
+				if typ != "" {
+					return "", fmt.Errorf("%w: %s: a type needs a group name", ErrInvalidPattern, ref)
+				}
+				b.WriteString("(?:" + body + ")")
+				continue
+			}
+			if !isCaptureName(group) {
+				return "", fmt.Errorf("%w: %s: invalid group name %q", ErrInvalidPattern, ref, group)
+			}
+			if typ != "" {
+				if typ != "int" && typ != "float" {
+					return "", fmt.Errorf("%w: %s: unknown type %q, want int or float", ErrInvalidPattern, ref, typ)
+				}
+				if prev, ok := types[group]; ok && prev != typ {
+					return "", fmt.Errorf("%w: %s: group %q is also typed %s", ErrInvalidPattern, ref, group, prev)
+				}
+				if types == nil {
+					types = make(map[string]string)
+				}
+				types[group] = typ
+			}
+			b.WriteString("(?P<" + group + ">" + body + ")")
+		}
+		b.WriteString(src[last:])
+		return b.String(), nil
+	}
+	expanded, err := walk(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	return expanded, types, nil
+}
+
+// patternName matches a valid registry name, as patternRef reads it.
+var patternName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// isCaptureName reports whether Go's regexp accepts name as a capture group
+// name: letters, digits, and underscores.
+func isCaptureName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultPatterns backs UsePatterns(nil).
+var defaultPatterns = NewPatterns()
+
+// UsePatterns makes [CompileWith] expand the %{...} references in its pattern
+// against p (see [Patterns]) before compiling, and check each typed group
+// against its field. A nil p expands with the built-in definitions alone.
+// [Decoder.Pattern] and [Decoder.Regexp] report the expanded pattern. The
+// option has no effect on [UnmarshalWith], whose pattern is already compiled:
+// expand it with [Patterns.Expand] first.
+func UsePatterns(p *Patterns) DecoderOption {
+	if p == nil {
+		p = defaultPatterns
+	}
+	return func(cfg *DecoderConfig) { cfg.Patterns = p }
+}
+
+// checkTypeHints fails, wrapping [ErrInvalidStruct], when a field of rt in the
+// decode plan fields reads a group types declares as "int" or "float" but
+// cannot hold that type (see typeHintAccepts).
+func checkTypeHints(rt reflect.Type, re *regexp.Regexp, fields []fieldDecoder, types map[string]string, cfg *DecoderConfig) error {
+	if len(types) == 0 {
+		return nil
+	}
+	names := re.SubexpNames()
+	for _, fd := range fields {
+		for _, gi := range fd.groupIndexes {
+			hint, ok := types[names[gi]]
+			if !ok {
+				continue
+			}
+			if ft := rt.FieldByIndex(fd.path()).Type; !typeHintAccepts(hint, ft, cfg) {
+				return fmt.Errorf("%w: field %s is %v, but group %q is declared %s", ErrInvalidStruct, fieldPath(rt, fd.path()), ft, names[gi], hint)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// typeHintAccepts reports whether a field of type t can hold the values of a
+// group declared with the %{NAME:group:hint} type: an integer field for "int",
+// a float field for "float", looking through a pointer and a list's elements.
+// A type that converts itself is trusted with either.
+func typeHintAccepts(hint string, t reflect.Type, cfg *DecoderConfig) bool {
+	if isCollectionType(t, cfg) {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if convertsItself(t, cfg) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return hint == "int" && t != timeDurationType
+	case reflect.Float32, reflect.Float64:
+		return hint == "float"
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestPatterns_expand(t *testing.T) {
+	p := &rx.Patterns{}
+	for name, def := range map[string]string{
+		"DIGITS": `\d+`,
+		"PAIR":   `%{DIGITS:a}-%{DIGITS:b}`,
+		"EITHER": `x|y`,
+	} {
+		if err := p.Define(name, def); err != nil {
+			t.Fatalf("Define(%s) error = %v", name, err)
+		}
+	}
+	tests := []struct {
+		pattern, want string
+	}{
+		{`%{DIGITS}`, `(?:\d+)`},
+		{`n=%{DIGITS:n}`, `n=(?P<n>\d+)`},
+		{`%{DIGITS:n:int}`, `(?P<n>\d+)`},
+		{`%{PAIR:pair}`, `(?P<pair>(?P<a>\d+)-(?P<b>\d+))`},
+		// A definition holding an alternation stays grouped.
+		{`a%{EITHER}b`, `a(?:x|y)b`},
+		// Quantifiers and text without references are untouched.
+		{`a%{2}b%{2,3}`, `a%{2}b%{2,3}`},
+	}
+	for _, tt := range tests {
+		got, err := p.Expand(tt.pattern)
+		if err != nil || got != tt.want {
+			t.Errorf("Expand(%q) = %q, %v; want %q", tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestPatterns_errors(t *testing.T) {
+	p := &rx.Patterns{}
+	for name, def := range map[string]string{
+		"A":    `a%{B}`,
+		"B":    `b%{C}`,
+		"C":    `c%{A}`,
+		"SELF": `%{SELF}`,
+		"D":    `\d`,
+	} {
+		if err := p.Define(name, def); err != nil {
+			t.Fatalf("Define(%s) error = %v", name, err)
+		}
+	}
+	tests := []struct {
+		pattern, want string
+	}{
+		{`%{NOPE:x}`, `%{NOPE:x}: no pattern named NOPE`},
+		{`x %{A}`, `pattern cycle A -> B -> C -> A`},
+		{`%{SELF}`, `pattern cycle SELF -> SELF`},
+		{`%{D:a.b}`, `%{D:a.b}: invalid group name "a.b"`},
+		{`%{D:n:long}`, `%{D:n:long}: unknown type "long", want int or float`},
+		{`%{D::int}`, `%{D::int}: a type needs a group name`},
+		{`%{D:n:int} %{D:n:float}`, `%{D:n:float}: group "n" is also typed int`},
+	}
+	for _, tt := range tests {
+		_, err := p.Expand(tt.pattern)
+		if !errors.Is(err, rx.ErrInvalidPattern) || !strings.Contains(fmt.Sprint(err), tt.want) {
+			t.Errorf("Expand(%q) error = %v, want ErrInvalidPattern containing %q", tt.pattern, err, tt.want)
+		}
+	}
+
+	for _, name := range []string{"", "9LIVES", "HAS-DASH", "a:b"} {
+		if err := p.Define(name, `x`); err == nil {
+			t.Errorf("Define(%q) succeeded, want an invalid-name error", name)
+		}
+	}
+}
+
+func TestPatterns_builtins(t *testing.T) {
+	p := rx.NewPatterns()
+	tests := []struct {
+		name     string
+		match    []string
+		mismatch []string
+	}{
+		{"INT", []string{"0", "-12", "+7"}, []string{"1.5", "x"}},
+		{"NUMBER", []string{"3", "-3.25", ".5", "10."}, []string{"1e3", "--1"}},
+		{"WORD", []string{"hello_1"}, []string{"two words"}},
+		{"QUOTEDSTRING", []string{`"a \"b\""`, `'it'`, "`x`"}, []string{`"open`}},
+		{"UUID", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567-e89b-12d3-a456"}},
+		{"IPV4", []string{"10.0.0.7", "255.255.255.255"}, []string{"256.1.1.1", "1.2.3"}},
+		{"IPV6", []string{"::1", "fe80::1", "2001:db8::8a2e:370:7334", "1:2:3:4:5:6:7:8", "::ffff:10.0.0.1", "::"}, []string{"1:2:3:4:5:6:7:8:9", "10.0.0.1"}},
+		{"IP", []string{"10.0.0.7", "::1"}, []string{"example.com"}},
+		{"HOSTNAME", []string{"example.com", "a-b.c1.", "localhost"}, []string{"-bad.com"}},
+		{"IPORHOST", []string{"example.com", "192.168.0.1"}, []string{"a b"}},
+		{"HOSTPORT", []string{"example.com:8080", "10.0.0.1:22"}, []string{"example.com:0"}},
+		{"HTTPMETHOD", []string{"GET", "PATCH"}, []string{"get", "FETCH"}},
+		{"LOGLEVEL", []string{"INFO", "warning", "Error"}, []string{"verbose"}},
+		{"TIMESTAMP_ISO8601", []string{"2024-03-01T12:00:00Z", "2024-03-01 12:00:00.123+01:00", "2024-03-01T12:00"}, []string{"2024-13-01T12:00:00Z"}},
+		{"SYSLOGTIMESTAMP", []string{"Mar  1 12:00:00", "Dec 31 23:59:59"}, []string{"Mar 1 25:00:00"}},
+		{"HTTPDATE", []string{"01/Mar/2024:12:00:00 +0000"}, []string{"01/03/2024:12:00:00 +0000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, err := p.Expand(`^(?:%{` + tt.name + `})$`)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			re := regexp.MustCompile(expanded)
+			for _, s := range tt.match {
+				if !re.MatchString(s) {
+					t.Errorf("%s does not match %q", tt.name, s)
+				}
+			}
+			for _, s := range tt.mismatch {
+				if re.MatchString(s) {
+					t.Errorf("%s matches %q", tt.name, s)
+				}
+			}
+		})
+	}
+}
+
+func TestPatterns_compileWith(t *testing.T) {
+	type request struct {
+		Client  netip.Addr
+		Time    time.Time `regex:"time,layout=02/Jan/2006:15:04:05 -0700"`
+		Method  string
+		Path    string
+		Status  int
+		Latency *float64
+	}
+	const pattern = `%{IP:client} \[%{HTTPDATE:time}\] "%{HTTPMETHOD:method} %{URIPATH:path}" %{INT:status:int} %{NUMBER:latency:float}`
+	dec, err := rx.CompileWith[request](pattern, rx.UsePatterns(nil))
+	if err != nil {
+		t.Fatalf("CompileWith() error = %v", err)
+	}
+	got, err := dec.One(`::1 [01/Mar/2024:12:00:00 +0000] "GET /api/items" 200 0.25`)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if got.Client.String() != "::1" || got.Method != "GET" || got.Path != "/api/items" || got.Status != 200 || got.Latency == nil || *got.Latency != 0.25 || got.Time.Day() != 1 {
+		t.Errorf("One() = %+v", got)
+	}
+	if strings.Contains(dec.Pattern(), "%{") || dec.Regexp().String() != dec.Pattern() {
+		t.Errorf("Pattern() = %q, want the expanded pattern", dec.Pattern())
+	}
+	if dec.Config().Patterns == nil {
+		t.Error("Config().Patterns = nil, want the registry")
+	}
+
+	// Without the option the references stay literal text.
+	if _, err := rx.Compile[request](pattern); err == nil {
+		t.Error("Compile() without UsePatterns succeeded, want an error")
+	}
+}
+
+func TestPatterns_typeChecks(t *testing.T) {
+	type wrongInt struct {
+		Status string
+	}
+	_, err := rx.CompileWith[wrongInt](`%{INT:status:int}`, rx.UsePatterns(nil))
+	if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), `field Status is string, but group "status" is declared int`) {
+		t.Errorf("CompileWith(string field) error = %v, want ErrInvalidStruct naming the field", err)
+	}
+
+	type wrongFloat struct {
+		Latency time.Duration
+	}
+	if _, err := rx.CompileWith[wrongFloat](`%{NUMBER:latency:float}`, rx.UsePatterns(nil)); !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("CompileWith(Duration field) error = %v, want ErrInvalidStruct", err)
+	}
+	// Lenient skips the check.
+	if _, err := rx.CompileWith[wrongInt](`%{INT:status:int}`, rx.UsePatterns(nil), rx.Lenient()); err != nil {
+		t.Errorf("CompileWith(Lenient) error = %v", err)
+	}
+
+	type accepted struct {
+		Codes []uint16    `regex:"code"`
+		Ratio *float32    `regex:"ratio"`
+		Level strictLevel `regex:"level"`
+	}
+	if _, err := rx.CompileWith[accepted](`%{INT:code:int},%{INT:code:int} %{NUMBER:ratio:float} %{INT:level:int}`, rx.UsePatterns(nil)); err != nil {
+		t.Errorf("CompileWith(accepted) error = %v", err)
+	}
+
+	// An expansion error surfaces from CompileWith unchanged.
+	if _, err := rx.CompileWith[wrongInt](`%{NOPE:status}`, rx.UsePatterns(nil)); !errors.Is(err, rx.ErrInvalidPattern) {
+		t.Errorf("CompileWith(undefined name) error = %v, want ErrInvalidPattern", err)
+	}
+}
+
+func ExamplePatterns() {
+	p := rx.NewPatterns()
+	_ = p.Define("SESSION", `[a-f0-9]{8}`)
+
+	type Login struct {
+		User    string
+		Session string
+		Port    int
+	}
+	dec := rx.MustCompileWith[Login](
+		`login %{USERNAME:user} session=%{SESSION:session} port=%{POSINT:port:int}`,
+		rx.UsePatterns(p),
+	)
+	l, _ := dec.One("login ada.l session=deadbeef port=2222")
+	fmt.Printf("%+v\n", l)
+	// Output:
+	// {User:ada.l Session:deadbeef Port:2222}
+}
+
+func ExamplePatterns_Expand() {
+	expanded, _ := rx.NewPatterns().Expand(`%{HOSTPORT:addr}`)
+	re := regexp.MustCompile(expanded)
+	fmt.Println(re.FindString("dial tcp db.internal:5432: refused"))
+	// Output:
+	// db.internal:5432
+}
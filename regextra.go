@@ -44,18 +44,48 @@ By use case:
     name is reused inside the pattern (map of slices): [AllNamedGroups]
   - Pull every named group across all matches (one map per match):
     [NamedGroupsPerMatch], or lazily [NamedGroupsPerMatchSeq]
+  - Pull every named group from one match, or from every match, with the
+    byte span of each: [FindMatch], [FindAllMatches], [Match], [Group]
+  - Read []byte input without converting it to a string, optionally decoding
+    without copying: [FindNamedBytes], [FindAllNamedBytes],
+    [NamedGroupsBytes], [AllNamedGroupsBytes], [NamedGroupsPerMatchBytes],
+    [NamedGroupsPerMatchSeqBytes], [ReplaceBytes], [ReplaceFirstBytes],
+    [ReplaceFuncBytes], [Decoder.OneBytes], [Decoder.AllBytes],
+    [Decoder.IterBytes], [ZeroCopy]
   - Substitute named-group spans by name: [Replace]
   - Substitute named-group spans in the first match only: [ReplaceFirst]
   - Substitute named-group spans with a callback over the matched value: [ReplaceFunc]
+  - Substitute named-group spans with a callback over the whole match, so one
+    group's replacement can depend on another's value: [ReplaceMatchFunc]
+  - Rewrite each whole match from a template of ${group|func:arg}
+    references: [ReplaceTemplate], [CompileTemplate], [Replacer]
   - Assert at startup that required groups are declared: [Validate]
   - Decode one match into a struct: [Unmarshal]
   - Decode all matches into a slice of structs: [UnmarshalAll]
   - Decode the same shape repeatedly with cached reflect work: [Compile], [MustCompile], [Decoder]
+  - Configure a Decoder — tag key, time zone, full-match anchoring,
+    leftmost-longest matching, lenient validation, group-versus-type checks
+    ([StrictTypes]) — and inspect the result:
+    [CompileWith], [MustCompileWith], [DecoderOption], [Decoder.Config]
+  - Report every failing field of a match at once instead of the first:
+    [AggregateErrors] with [CompileWith] or [UnmarshalWith], and [DecodeErrors]
   - Stream matches lazily (Go 1.23+ range-over-func): [Decoder.Iter]
+  - Stream records from an [io.Reader] with bounded memory: [Decoder.Scan]
+  - Decode input in several line formats, trying each pattern in priority
+    order: [CompileSet], [MustCompileSet], [DecoderSet]
   - Render a struct back into a string by inverting the decoder's own compiled
     pattern (the typed inverse of [Decoder]): [Decoder.Encoder], [Encoder]
+  - Rewrite every match in place by decoding it, transforming the value, and
+    re-encoding it: [Decoder.ReplaceAll]
   - Plug in caller-defined types in the unmarshal path: [RegexUnmarshaler]
   - Plug in caller-defined types in the encode path: [RegexMarshaler]
+  - Plug in types you cannot add methods to, on both paths: [Converters],
+    [RegisterConverter], [DefaultConverters], [UseConverters]
+  - Write patterns from grok-style named sub-patterns such as %{IP:client}:
+    [Patterns], [NewPatterns], [UsePatterns]
+  - Generate reflection-free decode and encode functions for the hottest
+    parsers: the regextra-gen command, checked against the reflective path
+    with the regextratest package
   - Compare against the no-match sentinel: [ErrNoMatch]
 
 # Performance
@@ -65,7 +95,29 @@ decode of the same shape (log parsers, request handlers, config readers), use
 [Compile] / [Decoder] — it caches the per-field plan and benchmarks at roughly
 half the time and half the allocations of [Unmarshal] on equivalent input.
 [Decoder.Iter] further skips the slice allocation entirely for streaming
-consumers.
+consumers, and [Decoder.Scan] drops the whole-input string too, holding one
+record of an [io.Reader] at a time.
+
+# Byte slices
+
+Every read function has a []byte counterpart with a Bytes suffix —
+[FindNamedBytes], [NamedGroupsBytes], [ReplaceBytes], [Decoder.OneBytes] and
+the rest — for input already held as bytes, such as an mmap'd file or a
+network buffer, which would otherwise be copied into a string only to be
+searched. They follow the string form's semantics; only the types differ.
+
+Every []byte they return for a group is a subslice of the target, not a copy:
+a later write to the target shows through it, and it is valid only as long as
+the target is. Its capacity is clipped to its length, as
+[regexp.Regexp.FindSubmatch] does, so appending to it reallocates rather than
+overwriting the rest of the target. A group that did not participate reads as
+nil, one that matched an empty span as a non-nil empty slice. The Replace
+variants are the exception: they always return a new slice.
+
+The Decoder methods copy each match's text into a string before decoding, so
+their results own their memory. Under the [ZeroCopy] option they decode
+against the buffer itself instead, for callers that guarantee it outlives the
+results and is never modified.
 
 # No-match behavior
 
@@ -81,10 +133,15 @@ the no-match form that lets the caller continue without a special-case branch.
 	NamedGroups, AllNamedGroups               empty map (initialized, not nil)
 	NamedGroupsPerMatch                       []map[string]string{} (empty, not nil)
 	NamedGroupsPerMatchSeq                    iterator yields zero times
+	FindMatch                                 (nil, false)
+	FindAllMatches                            []*Match{} (empty, not nil)
 	Replace                                   target returned unchanged
 	ReplaceFirst                              target returned unchanged
 	ReplaceFunc                               target returned unchanged (fn
 	                                          never called)
+	ReplaceMatchFunc                          target returned unchanged (fn
+	                                          never called)
+	ReplaceTemplate, Replacer.Replace         target returned unchanged, nil
 	Validate                                  unrelated — checks declarations,
 	                                          not matches against a target
 	Unmarshal                                 nil error; destination struct left
@@ -94,6 +151,14 @@ the no-match form that lets the caller continue without a special-case branch.
 	Decoder.One                               zero T, [ErrNoMatch]
 	Decoder.All                               []T{}, nil
 	Decoder.Iter                              iterator yields zero times
+	Decoder.Scan                              iterator yields zero times (a
+	                                          record with no match is skipped)
+	...Bytes variants                         as their string form, with nil
+	                                          for "" and a copy of target from
+	                                          the Replace variants
+	Decoder.ReplaceAll                        target returned unchanged, nil
+	                                          (fn never called)
+	DecoderSet.One                            zero T, -1, [ErrNoMatch]
 
 The contrast worth understanding is between [Unmarshal] and [Decoder.One]:
 
@@ -115,7 +180,7 @@ Example of the [Decoder.One] no-match check:
 	    // no match — handle as data absence, not failure
 	}
 
-[Decoder.All] and [Decoder.Iter] don't have the ambiguity problem — an empty
+[Decoder.All], [Decoder.Iter], and [Decoder.Scan] don't have the ambiguity problem — an empty
 slice and a zero-iteration range are unambiguous — so they follow the same
 "no match is not an error" convention as [UnmarshalAll].
 
@@ -142,14 +207,124 @@ option. Currently recognized keys:
 	                          conversion as a real match.
 	layout=<go-time-layout>   time.Time only. Used exclusively, instead of
 	                          the default RFC3339-and-friends fallback list.
-
-The grammar also recognizes one flag-style token (no `=`):
+	layout=unix               time.Time only. Reads a count of seconds —
+	                          or, for unixmilli, unixmicro, and unixnano,
+	                          of those units — since the Unix epoch, with an
+	                          optional fraction: "1718000000.123".
+	layouts=<a>|<b>|...       time.Time only. Tries each layout in turn —
+	                          Go layouts or epoch pseudo-layouts — and takes
+	                          the first that parses. Not with layout=; the
+	                          Encoder writes the first.
+	tz=<IANA name>            time.Time only. Reads a value without its own
+	                          offset as local time in the named zone, as
+	                          time.ParseInLocation does, instead of the
+	                          DefaultLocation (UTC unless set); the Encoder
+	                          writes the value in that zone.
+	split=<sep>               Slice and array fields only. Splits each
+	                          captured value on sep; every piece becomes an
+	                          element. A comma is spelled `split=,` or
+	                          `split=comma`; whitespace `split=space` or
+	                          `split=tab`.
+	trimprefix=<text>         Any field type. A value transform (below):
+	trimsuffix=<text>         removes text from the start or end of the
+	                          matched value.
+	replace=<old>:<new>       Any field type. A value transform (below):
+	                          replaces every old in the matched value with
+	                          new; an empty new deletes old. Each side is
+	                          spelled like split=, so `replace=comma:space`
+	                          turns commas into spaces; old cannot hold a
+	                          colon.
+	base=<n>                  Integer fields only. Parses the value in base
+	                          n — 2 through 36, or 0 to take it from a Go
+	                          literal prefix (0x, 0o, 0b) — instead of 10.
+	sep=<sep>                 Integer and float fields only. A digit-group
+	                          separator, removed wherever it appears before
+	                          parsing: `sep=,` reads "1,234,567". Spelled
+	                          as split= is.
+	unit=bytes                Integer and float fields only. Reads a byte
+	                          size: a number, optional white space, and a
+	                          suffix — k, M, G, T, P, E (powers of 1000) or
+	                          Ki, Mi, Gi, Ti, Pi, Ei (powers of 1024), each
+	                          with an optional B, or a bare B or nothing —
+	                          in any case: "512KiB", "1.5GB", "10M".
+	unit=si                   Integer and float fields only. Reads a number
+	                          with an SI prefix, k (or K), M, G, T, P, or E:
+	                          "3.2k". Case matters.
+	prec=<n>                  unit= fields only. The Encoder writes at most
+	                          n digits after the point, instead of the exact
+	                          value.
+	unitbase=1000|1024        unit=bytes fields only. The Encoder writes SI
+	                          suffixes (kB, MB) for 1000, instead of the
+	                          default IEC ones (KiB, MiB).
+
+A slice or array field collects every participating occurrence of its group
+(Go's regexp allows a group name to repeat) as one element each, in
+declaration order, so `(?P<key>\w+)(?:,(?P<key>\w+))?` fills a []string.
+An occurrence inside a repetition contributes every iteration, so
+`(?P<key>\w+)(?:,(?P<key>\w+))*` does too, for a list of any length.
+Elements convert like scalar fields of the element type, and empty spans and
+empty split pieces are skipped. A slice type that converts itself (net.IP,
+say) is a single value, not a list.
+
+The grammar also recognizes four flag-style tokens (no `=`):
 
 	required                  Decode fails with a *RequiredGroupError when
 	                          the named group does not participate in the
 	                          match or matches an empty span and no default=
 	                          supplies a value. A default= satisfies the
 	                          requirement, since it always yields a value.
+	prefix                    Struct or pointer-to-struct fields only.
+	                          Flattens the nested struct: each of its fields
+	                          resolves against the group `<name>_<field>`,
+	                          where name is the tag name or, when empty, the
+	                          field's own name. Prefixes accumulate through
+	                          deeper nesting.
+	omitempty                 Encode side only. Inside an optional `?`
+	                          section of the pattern, the field's empty
+	                          value (zero, or no elements) counts as absent,
+	                          so [Encoder.Encode] drops the section. Ignored
+	                          when decoding.
+	percent                   Float fields only. Reads the value as a
+	                          percentage: "12.5%" (or "12.5") decodes to
+	                          0.125.
+
+and four value transforms, which like trimprefix=, trimsuffix=, and replace=
+normalize the matched value before it is converted:
+
+	trim                      Removes leading and trailing white space.
+	lower, upper              Changes the value's case.
+	unquote                   Removes one level of quoting. A "..." or `...`
+	                          value is read as a Go string literal, falling
+	                          back to POSIX shell rules for a "..." value
+	                          that is not valid Go; a '...' value is read
+	                          shell-style, its contents literal. A value
+	                          that does not start with a quote is left as
+	                          is; one that opens a quote and does not close
+	                          it fails to decode.
+
+Transforms apply to matched text in the order the tag lists them —
+`regex:"id,trim,trimprefix=#"` trims, then strips the `#` — and never to a
+default=. A value a transform empties counts as absent, like an empty match.
+On a slice or array field they apply to each element, after split=. The
+[Encoder] undoes unquote (quoting the value Go-style), trimprefix=,
+trimsuffix=, and replace= (putting old back for new) in reverse order; trim,
+lower, upper, and a replace= that deletes are not reversible and leave the
+encoded value as is.
+
+The [Encoder] renders base=, sep=, and percent so they round-trip: in the
+base, without a prefix (base 0 renders in decimal); with sep= between groups
+of three digits, or four in base 2 and 16; and with percent as a positional
+decimal followed by %.
+
+A unit= value is computed exactly, so "1.5GB" is 1500000000; into an integer
+field a fractional quantity rounds to the nearest whole number. The Encoder
+writes it in the largest prefix it reaches, exactly unless prec= limits the
+digits, so 1536 bytes round-trips as "1.5KiB".
+
+Untagged embedded (anonymous) structs are flattened without a prefix, the way
+encoding/json promotes embedded fields. A struct type that converts itself
+(time.Time, or one implementing [RegexUnmarshaler] or
+encoding.TextUnmarshaler) is never flattened.
 
 The two "empty" forms differ, matching the convention in encoding/json,
 encoding/xml, and gopkg.in/yaml:
@@ -172,11 +347,11 @@ Two forward-compatibility rules in the tag parser are part of the v1 contract:
     unknown keys; pin a minor version range if you need a specific
     recognized set.
 
-  - Lone tokens (no `=`) other than the recognized `required` flag are
-    silently ignored. Today, `regex:"name,foo"` is a no-op — the `foo` token is
-    dropped, so the field resolves exactly as `regex:"name"` would. This slot is
-    reserved for future flag-style options (the `required` flag above claimed the
-    first one; see the issue tracker at
+  - Lone tokens (no `=`) other than the recognized flags and transforms are
+    silently ignored. Today, `regex:"name,foo"` is a no-op — the `foo` token
+    is dropped, so the field resolves exactly as `regex:"name"` would. This
+    slot is reserved for future flag-style options (the flags and transforms
+    above claimed the first ones; see the issue tracker at
     https://github.com/Jecoms/regextra/issues). A later minor release may start
     recognizing further lone tokens and giving them meaning, so adding
     `regex:"name,foo"` today is a no-op but may stop being one. Callers must not
@@ -529,7 +704,7 @@ func Replace(re *regexp.Regexp, target string, replacements map[string]string) s
 	if len(replacements) == 0 {
 		return target
 	}
-	return replaceNamed(re, target, -1, func(name, _ string) (string, bool) {
+	return replaceNamed(re, target, -1, nil, func(name, _ string) (string, bool) {
 		repl, ok := replacements[name]
 		return repl, ok
 	})
@@ -559,7 +734,7 @@ func ReplaceFirst(re *regexp.Regexp, target string, replacements map[string]stri
 	if len(replacements) == 0 {
 		return target
 	}
-	return replaceNamed(re, target, 1, func(name, _ string) (string, bool) {
+	return replaceNamed(re, target, 1, nil, func(name, _ string) (string, bool) {
 		repl, ok := replacements[name]
 		return repl, ok
 	})
@@ -595,13 +770,49 @@ func ReplaceFirst(re *regexp.Regexp, target string, replacements map[string]stri
 //	})
 //	// out = "card ************1111 ok"
 func ReplaceFunc(re *regexp.Regexp, target string, fn func(group, match string) string) string {
-	return replaceNamed(re, target, -1, func(name, matched string) (string, bool) {
+	return replaceNamed(re, target, -1, nil, func(name, matched string) (string, bool) {
 		return fn(name, matched), true
 	})
 }
 
+// ReplaceMatchFunc is like [ReplaceFunc] but computes the replacements for a
+// whole match at once: fn is called once per match of re in target, in order,
+// with the match and every named group of it (see [FindMatch]), and returns the
+// replacement for each group it rewrites, keyed by group name. A group absent
+// from the returned map — or every group, when fn returns nil — passes through
+// unchanged. Use it when a substitution depends on a sibling group, such as
+// masking value only when key is "password".
+//
+// Overlapping groups follow [Replace]'s rule: the outermost named span
+// encountered first wins, and a replacement for a group nested inside an
+// already-substituted span is ignored.
+//
+// On no match, returns target unchanged and never calls fn. Passing a nil fn
+// is a programmer error and panics on the first match, as [ReplaceFunc] does.
+//
+// Example — mask the value of a password pair only:
+//
+//	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\S+)`)
+//	out := regextra.ReplaceMatchFunc(re, "user=ada password=hunter2", func(m *regextra.Match) map[string]string {
+//	    if m.Groups["key"].Value == "password" {
+//	        return map[string]string{"value": "***"}
+//	    }
+//	    return nil
+//	})
+//	// out = "user=ada password=***"
+func ReplaceMatchFunc(re *regexp.Regexp, target string, fn func(m *Match) map[string]string) string {
+	names := re.SubexpNames()
+	var repl map[string]string
+	return replaceNamed(re, target, -1, func(m []int) {
+		repl = fn(newMatch(names, target, m))
+	}, func(name, _ string) (string, bool) {
+		r, ok := repl[name]
+		return r, ok
+	})
+}
+
 // replaceNamed is the shared substitution engine behind [Replace],
-// [ReplaceFirst] and [ReplaceFunc]. It walks up to limit matches of re over
+// [ReplaceFirst], [ReplaceFunc] and [ReplaceMatchFunc]. It walks up to limit matches of re over
 // target and, for each participating named group, asks replFor for the
 // replacement; replFor reports false to pass the group's matched text through
 // unchanged. limit is passed straight to FindAllStringSubmatchIndex: -1
@@ -614,47 +825,25 @@ func ReplaceFunc(re *regexp.Regexp, target string, fn func(group, match string)
 // overlap rule, so a group skipped by an enclosing outermost span never reaches
 // it — [ReplaceFunc] therefore never invokes its callback for an inner group it
 // suppresses, and [Replace] does its map lookup only where it matters.
-func replaceNamed(re *regexp.Regexp, target string, limit int, replFor func(name, matched string) (string, bool)) string {
+//
+// onMatch, when non-nil, is called with each match's index pairs before any of
+// its spans reach replFor, so a caller can compute one match's replacements
+// together ([ReplaceMatchFunc]).
+func replaceNamed(re *regexp.Regexp, target string, limit int, onMatch func(m []int), replFor func(name, matched string) (string, bool)) string {
 	matches := re.FindAllStringSubmatchIndex(target, limit)
 	if len(matches) == 0 {
 		return target
 	}
 	names := re.SubexpNames()
 
-	type span struct {
-		start, end int
-		name       string
-	}
-
 	var b strings.Builder
-	spans := make([]span, 0, len(names)) // reused across matches
+	spans := make([]namedSpan, 0, len(names)) // reused across matches
 	cursor := 0
 	for _, m := range matches {
-		spans = spans[:0]
-		for i := 1; i < len(names); i++ {
-			name := names[i]
-			if name == "" {
-				continue
-			}
-			s, e := m[2*i], m[2*i+1]
-			if s < 0 || e < 0 {
-				continue
-			}
-			spans = append(spans, span{start: s, end: e, name: name})
+		if onMatch != nil {
+			onMatch(m)
 		}
-		// Sort by start ascending, then end descending: when named groups share
-		// a start offset (nested groups), the outermost span — the one with the
-		// larger end — sorts first, claims the cursor, and the inner spans it
-		// encloses are skipped, making the documented "outermost wins" tie-break
-		// deterministic. The stable sort preserves declaration order for spans
-		// that are otherwise equal. (Fixes #107: the previous sort.Slice was
-		// unstable, so the tie-break was not guaranteed.)
-		slices.SortStableFunc(spans, func(a, c span) int {
-			if a.start != c.start {
-				return cmp.Compare(a.start, c.start)
-			}
-			return cmp.Compare(c.end, a.end)
-		})
+		spans = appendNamedSpans(spans[:0], names, m)
 		for _, sp := range spans {
 			if sp.start < cursor {
 				continue // already covered (overlap with an earlier substitution)
@@ -672,6 +861,43 @@ func replaceNamed(re *regexp.Regexp, target string, limit int, replFor func(name
 	return b.String()
 }
 
+// namedSpan is the byte span of one participating named group in a match.
+type namedSpan struct {
+	start, end int
+	name       string
+}
+
+// appendNamedSpans appends the span of every participating named group of one
+// match to dst, in the order the replace engines apply them. names is
+// re.SubexpNames(); m is the match's index pairs.
+func appendNamedSpans(dst []namedSpan, names []string, m []int) []namedSpan {
+	for i := 1; i < len(names); i++ {
+		name := names[i]
+		if name == "" {
+			continue
+		}
+		s, e := m[2*i], m[2*i+1]
+		if s < 0 || e < 0 {
+			continue
+		}
+		dst = append(dst, namedSpan{start: s, end: e, name: name})
+	}
+	// Sort by start ascending, then end descending: when named groups share
+	// a start offset (nested groups), the outermost span — the one with the
+	// larger end — sorts first, claims the cursor, and the inner spans it
+	// encloses are skipped, making the documented "outermost wins" tie-break
+	// deterministic. The stable sort preserves declaration order for spans
+	// that are otherwise equal. (Fixes #107: the previous sort.Slice was
+	// unstable, so the tie-break was not guaranteed.)
+	slices.SortStableFunc(dst, func(a, c namedSpan) int {
+		if a.start != c.start {
+			return cmp.Compare(a.start, c.start)
+		}
+		return cmp.Compare(c.end, a.end)
+	})
+	return dst
+}
+
 // MissingNamedGroupsError reports the required group names that [Validate]
 // could not find declared on the pattern. It is returned (wrapped with the
 // `regextra.Validate:` prefix) whenever at least one required name is missing.
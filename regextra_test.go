@@ -827,6 +827,109 @@ func ExampleReplaceFunc() {
 	// Output: card ************1111 ok
 }
 
+func TestReplaceMatchFunc(t *testing.T) {
+	maskPassword := func(m *rx.Match) map[string]string {
+		if m.Groups["key"].Value == "password" {
+			return map[string]string{"value": "***"}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		fn      func(m *rx.Match) map[string]string
+		want    string
+	}{
+		{
+			name:    "replacement depends on a sibling group",
+			pattern: `(?P<key>\w+)=(?P<value>\S+)`,
+			target:  "user=ada password=hunter2 token=x",
+			fn:      maskPassword,
+			want:    "user=ada password=*** token=x",
+		},
+		{
+			name:    "several groups from one call",
+			pattern: `(?P<user>\w+)@(?P<domain>[\w.]+)`,
+			target:  "alice@example.com",
+			fn: func(m *rx.Match) map[string]string {
+				return map[string]string{"user": m.Groups["domain"].Value, "domain": m.Groups["user"].Value}
+			},
+			want: "example.com@alice",
+		},
+		{
+			name:    "outermost span wins",
+			pattern: `(?P<outer>(?P<inner>\w+)@[\w.]+)`,
+			target:  "alice@example.com",
+			fn: func(*rx.Match) map[string]string {
+				return map[string]string{"outer": "X", "inner": "Y"}
+			},
+			want: "X",
+		},
+		{
+			name:    "inner group replaced when outer is absent from the map",
+			pattern: `(?P<outer>(?P<inner>\w+)@[\w.]+)`,
+			target:  "alice@example.com",
+			fn: func(*rx.Match) map[string]string {
+				return map[string]string{"inner": "bob"}
+			},
+			want: "bob@example.com",
+		},
+		{
+			name:    "non-participating group in the map is ignored",
+			pattern: `(?P<key>\w+)(?:=(?P<value>\w+))?`,
+			target:  "flag",
+			fn: func(*rx.Match) map[string]string {
+				return map[string]string{"value": "x"}
+			},
+			want: "flag",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rx.ReplaceMatchFunc(regexp.MustCompile(tt.pattern), tt.target, tt.fn)
+			if got != tt.want {
+				t.Errorf("ReplaceMatchFunc() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceMatchFuncCallsOncePerMatch(t *testing.T) {
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\w+)`)
+	var starts []int
+	rx.ReplaceMatchFunc(re, "a=1 b=2 c=3", func(m *rx.Match) map[string]string {
+		starts = append(starts, m.Start)
+		return nil
+	})
+	if !reflect.DeepEqual(starts, []int{0, 4, 8}) {
+		t.Errorf("fn called for match starts %v, want [0 4 8]", starts)
+	}
+
+	called := false
+	out := rx.ReplaceMatchFunc(re, "none", func(*rx.Match) map[string]string {
+		called = true
+		return nil
+	})
+	if called || out != "none" {
+		t.Errorf("ReplaceMatchFunc(no match) = %q, called = %v; want target unchanged, fn not called", out, called)
+	}
+}
+
+func ExampleReplaceMatchFunc() {
+	// Mask a value only when its key is "password".
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<value>\S+)`)
+	out := rx.ReplaceMatchFunc(re, "user=ada password=hunter2", func(m *rx.Match) map[string]string {
+		if m.Groups["key"].Value == "password" {
+			return map[string]string{"value": "***"}
+		}
+		return nil
+	})
+	fmt.Println(out)
+	// Output: user=ada password=***
+}
+
 func TestValidate(t *testing.T) {
 	re := regexp.MustCompile(`(?P<name>\w+) (?P<age>\d+)`)
 
@@ -0,0 +1,135 @@
+// Package regextratest holds hand-written or generated decode and encode
+// functions to the behavior of the reflective [regextra.Decoder] and
+// [regextra.Encoder] they stand in for.
+//
+// Its main use is the code regextra-gen emits: a test feeds the generated
+// DecodeT and EncodeT the same inputs as the Decoder compiled from the same
+// type and pattern, and fails on any difference.
+//
+//	func TestEntryParity(t *testing.T) {
+//		dec := regextra.MustCompile[Entry](entryPattern)
+//		regextratest.AssertDecodeParity(t, dec, DecodeEntry, lines...)
+//		enc, _ := dec.Encoder()
+//		regextratest.AssertEncodeParity(t, enc, EncodeEntry, entries...)
+//	}
+//
+// Results agree when the decoded values are reflect.DeepEqual, or when both
+// calls fail alike: both with [regextra.ErrNoMatch], or both with a
+// [regextra.DecodeError], [regextra.RequiredGroupError], or
+// [regextra.EncodeError] carrying the same details, or both with some other
+// error. The errors' prefixes — "regextra.Decoder.One: " against the
+// generated function's name — are not compared.
+package regextratest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jecoms/regextra"
+)
+
+// AssertDecodeParity reports a test error for each input on which decode
+// disagrees with dec.One.
+func AssertDecodeParity[T any](t testing.TB, dec *regextra.Decoder[T], decode func(string) (T, error), inputs ...string) {
+	t.Helper()
+	for _, in := range inputs {
+		want, wantErr := dec.One(in)
+		got, gotErr := decode(in)
+		if diff := compareErrors(gotErr, wantErr); diff != "" {
+			t.Errorf("decode(%q): %s", in, diff)
+			continue
+		}
+		if wantErr == nil && !reflect.DeepEqual(got, want) {
+			t.Errorf("decode(%q) = %+v, Decoder.One = %+v", in, got, want)
+		}
+	}
+}
+
+// AssertEncodeParity reports a test error for each value on which encode
+// disagrees with enc.Encode.
+func AssertEncodeParity[T any](t testing.TB, enc *regextra.Encoder[T], encode func(T) (string, error), values ...T) {
+	t.Helper()
+	for _, v := range values {
+		want, wantErr := enc.Encode(v)
+		got, gotErr := encode(v)
+		if diff := compareErrors(gotErr, wantErr); diff != "" {
+			t.Errorf("encode(%+v): %s", v, diff)
+			continue
+		}
+		if wantErr == nil && got != want {
+			t.Errorf("encode(%+v) = %q, Encoder.Encode = %q", v, got, want)
+		}
+	}
+}
+
+// compareErrors returns a description of how got differs from the reflective
+// API's want, or "" when they agree.
+func compareErrors(got, want error) string {
+	switch {
+	case got == nil && want == nil:
+		return ""
+	case got == nil:
+		return "no error, reflective error = " + want.Error()
+	case want == nil:
+		return "error = " + got.Error() + ", reflective call succeeded"
+	}
+	if errors.Is(want, regextra.ErrNoMatch) {
+		if !errors.Is(got, regextra.ErrNoMatch) {
+			return "error = " + got.Error() + ", want ErrNoMatch"
+		}
+		return ""
+	}
+	var wantDecode, gotDecode *regextra.DecodeError
+	if errors.As(want, &wantDecode) {
+		if !errors.As(got, &gotDecode) {
+			return "error = " + got.Error() + ", want a DecodeError: " + want.Error()
+		}
+		if !sameDetails(*gotDecode, *wantDecode) {
+			return fmt.Sprintf("DecodeError = %+v, want %+v", *gotDecode, *wantDecode)
+		}
+		return ""
+	}
+	var wantRequired, gotRequired *regextra.RequiredGroupError
+	if errors.As(want, &wantRequired) {
+		if !errors.As(got, &gotRequired) {
+			return "error = " + got.Error() + ", want a RequiredGroupError: " + want.Error()
+		}
+		if *gotRequired != *wantRequired {
+			return fmt.Sprintf("RequiredGroupError = %+v, want %+v", *gotRequired, *wantRequired)
+		}
+		return ""
+	}
+	var wantEncode, gotEncode *regextra.EncodeError
+	if errors.As(want, &wantEncode) {
+		if !errors.As(got, &gotEncode) {
+			return "error = " + got.Error() + ", want an EncodeError: " + want.Error()
+		}
+		if !sameDetails(*gotEncode, *wantEncode) {
+			return fmt.Sprintf("EncodeError = %+v, want %+v", *gotEncode, *wantEncode)
+		}
+		return ""
+	}
+	return ""
+}
+
+// sameDetails reports whether two error structs agree field by field,
+// comparing their Err causes by message.
+func sameDetails[E regextra.DecodeError | regextra.EncodeError](got, want E) bool {
+	g, w := reflect.ValueOf(got), reflect.ValueOf(want)
+	for i := range g.NumField() {
+		gf, wf := g.Field(i).Interface(), w.Field(i).Interface()
+		if ge, ok := gf.(error); ok || gf == nil {
+			we, _ := wf.(error)
+			if (ge == nil) != (we == nil) || ge != nil && ge.Error() != we.Error() {
+				return false
+			}
+			continue
+		}
+		if gf != wf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package regextratest_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/jecoms/regextra"
+	"github.com/jecoms/regextra/regextratest"
+)
+
+// recorder is a testing.TB that collects reported failures instead of
+// failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type point struct {
+	X int `regex:"x"`
+	Y int `regex:"y"`
+}
+
+const pointPattern = `(?P<x>-?\d+),(?P<y>-?\d+)`
+
+func TestAssertDecodeParity(t *testing.T) {
+	dec := regextra.MustCompile[point](pointPattern)
+	same := func(s string) (point, error) {
+		p, err := dec.One(s)
+		if err != nil && !errors.Is(err, regextra.ErrNoMatch) {
+			// Re-prefix as a generated function would.
+			err = fmt.Errorf("decodePoint: %w", errors.Unwrap(err))
+		}
+		return p, err
+	}
+	r := &recorder{TB: t}
+	regextratest.AssertDecodeParity(r, dec, same, "1,2", "x", "99999999999999999999,1")
+	if len(r.errors) != 0 {
+		t.Errorf("AssertDecodeParity(same) reported %q, want nothing", r.errors)
+	}
+
+	tests := []struct {
+		name   string
+		decode func(string) (point, error)
+	}{
+		{"value", func(s string) (point, error) { return point{X: 1}, nil }},
+		{"missing error", func(s string) (point, error) { return point{}, nil }},
+		{"spurious error", func(s string) (point, error) { return point{}, errors.New("boom") }},
+		{"error details", func(s string) (point, error) {
+			_, err := strconv.Atoi(s)
+			return point{}, &regextra.DecodeError{Field: "Y", Group: "y", Value: s, Type: "int", Err: err}
+		}},
+	}
+	inputs := map[string]string{
+		"value":          "1,2",
+		"missing error":  "no match",
+		"spurious error": "1,2",
+		"error details":  "99999999999999999999,1",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			regextratest.AssertDecodeParity(r, dec, tt.decode, inputs[tt.name])
+			if len(r.errors) != 1 {
+				t.Errorf("AssertDecodeParity() reported %q, want one difference", r.errors)
+			}
+		})
+	}
+}
+
+func TestAssertEncodeParity(t *testing.T) {
+	enc, err := regextra.MustCompile[point](pointPattern).Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	format := func(p point) (string, error) { return fmt.Sprintf("%d,%d", p.X, p.Y), nil }
+	r := &recorder{TB: t}
+	regextratest.AssertEncodeParity(r, enc, format, point{1, 2}, point{-3, 0})
+	if len(r.errors) != 0 {
+		t.Errorf("AssertEncodeParity(same) reported %q, want nothing", r.errors)
+	}
+
+	swapped := func(p point) (string, error) { return fmt.Sprintf("%d,%d", p.Y, p.X), nil }
+	r = &recorder{TB: t}
+	regextratest.AssertEncodeParity(r, enc, swapped, point{1, 2}, point{4, 4})
+	if len(r.errors) != 1 {
+		t.Errorf("AssertEncodeParity(swapped) reported %q, want one difference", r.errors)
+	}
+}
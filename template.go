@@ -0,0 +1,337 @@
+package regextra
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTemplate categorizes a [CompileTemplate] or [ReplaceTemplate]
+// failure to parse the template: an unterminated or empty reference, a
+// reference to a group the pattern does not declare, or an unknown function or
+// bad function argument. Compare with errors.Is.
+var ErrInvalidTemplate = errors.New("regextra: invalid template")
+
+// Replacer rewrites every match of a regular expression from a template,
+// compiled once by [CompileTemplate] and reusable across targets. Where
+// [Replace] swaps the spans of individual groups in place, a Replacer replaces
+// the whole match with the rendered template, so it can reorder, drop, and
+// reformat groups — restructuring one log format into another.
+//
+// The template is literal text with references of the form ${name}, the value
+// of the named group, optionally piped through functions:
+// ${name|func|func:arg}. Functions apply left to right, each to the result of
+// the one before. $$ is a literal $; any other $ is literal too. A reference
+// reads its group as [NamedGroups] does: the last participating occurrence
+// when the name is reused, and "" when no occurrence participated.
+//
+// The built-in functions:
+//
+//	upper            upper-cases the value
+//	lower            lower-cases the value
+//	trim             removes leading and trailing white space
+//	default:<text>   substitutes text when the value is empty
+//	mask             replaces every character with '*'
+//	mask:<n>         replaces every character but the last n with '*'
+//	time:<layout>    parses the value as time.Time does when decoding —
+//	                 RFC 3339, then date-time, date, and time-only forms,
+//	                 in UTC — and formats it with the Go layout
+//
+// mask and time leave an empty value empty. An argument runs to the next |
+// or the closing }, so it cannot contain either; it may contain ':', as
+// time layouts do.
+//
+// A Replacer is safe for concurrent use.
+type Replacer struct {
+	re    *regexp.Regexp
+	tmpl  string
+	parts []templatePart
+}
+
+// templatePart is one piece of a compiled template: literal text when ref is
+// empty, otherwise a ${...} reference.
+type templatePart struct {
+	text  string
+	ref   string // the reference as written, for errors
+	name  string
+	idxs  []int // submatch indexes of every occurrence of name
+	pipes []templatePipe
+}
+
+// templatePipe is one function of a reference's pipeline.
+type templatePipe struct {
+	name string
+	arg  string
+	n    int // mask's parsed argument, or -1 for none
+}
+
+// TemplateError reports a template function that failed on a matched value —
+// today only time:, when the value does not parse as a time. It is returned
+// (wrapped with the calling entrypoint's prefix) by [Replacer.Replace],
+// [Replacer.ReplaceFirst], and [ReplaceTemplate]. Recover it with
+// [errors.As]; Err holds the underlying cause.
+type TemplateError struct {
+	// Ref is the failing reference as written in the template, such as
+	// "${ts|time:2006-01-02}".
+	Ref string
+	// Group is the group the reference reads.
+	Group string
+	// Value is the value the failing function was given.
+	Value string
+	// Match is the 0-based index of the match among the target's matches.
+	Match int
+	// Offset and End are the byte span of the match in the target.
+	Offset, End int
+	// Err is the underlying failure.
+	Err error
+}
+
+// Error implements the error interface. When Err is nil (only reachable by
+// constructing the value directly) it reports "no template error".
+func (e *TemplateError) Error() string {
+	if e.Err == nil {
+		return "no template error"
+	}
+	return fmt.Sprintf("%s: %v", e.Ref, e.Err)
+}
+
+// Unwrap returns the underlying failure so [errors.Is]/[errors.As] can reach
+// it.
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// CompileTemplate parses tmpl against re into a reusable [Replacer]. It fails
+// with [ErrInvalidTemplate] when tmpl references a group re does not declare,
+// names an unknown function, or is malformed.
+//
+// Example:
+//
+//	re := regexp.MustCompile(`(?P<user>\w+)@(?P<host>[\w.]+)`)
+//	r, err := regextra.CompileTemplate(re, "${host|upper}/${user}")
+//	out, err := r.Replace("mail alice@example.com")
+//	// out = "mail EXAMPLE.COM/alice"
+func CompileTemplate(re *regexp.Regexp, tmpl string) (*Replacer, error) {
+	r := &Replacer{re: re, tmpl: tmpl}
+	var lit strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		if c != '$' || i+1 == len(tmpl) || (tmpl[i+1] != '$' && tmpl[i+1] != '{') {
+			lit.WriteByte(c)
+			continue
+		}
+		if tmpl[i+1] == '$' {
+			lit.WriteByte('$')
+			i++
+			continue
+		}
+		end := strings.IndexByte(tmpl[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated reference at offset %d", ErrInvalidTemplate, i)
+		}
+		part, err := parseReference(re, tmpl[i:i+end+1])
+		if err != nil {
+			return nil, err
+		}
+		if lit.Len() > 0 {
+			r.parts = append(r.parts, templatePart{text: lit.String()})
+			lit.Reset()
+		}
+		r.parts = append(r.parts, part)
+		i += end
+	}
+	if lit.Len() > 0 {
+		r.parts = append(r.parts, templatePart{text: lit.String()})
+	}
+	return r, nil
+}
+
+// MustCompileTemplate is like [CompileTemplate] but panics on error.
+func MustCompileTemplate(re *regexp.Regexp, tmpl string) *Replacer {
+	r, err := CompileTemplate(re, tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parseReference parses one ${...} reference, braces included.
+func parseReference(re *regexp.Regexp, ref string) (templatePart, error) {
+	fields := strings.Split(ref[2:len(ref)-1], "|")
+	part := templatePart{ref: ref, name: fields[0]}
+	if part.name == "" {
+		return part, fmt.Errorf("%w: %s: missing group name", ErrInvalidTemplate, ref)
+	}
+	part.idxs = subexpIndexes(re, part.name)
+	if len(part.idxs) == 0 {
+		return part, fmt.Errorf("%w: %s: no group named %q", ErrInvalidTemplate, ref, part.name)
+	}
+	for _, f := range fields[1:] {
+		name, arg, hasArg := strings.Cut(f, ":")
+		p := templatePipe{name: name, arg: arg, n: -1}
+		switch name {
+		case "upper", "lower", "trim":
+			if hasArg {
+				return part, fmt.Errorf("%w: %s: %s takes no argument", ErrInvalidTemplate, ref, name)
+			}
+		case "default":
+		case "mask":
+			if hasArg {
+				n, err := strconv.Atoi(arg)
+				if err != nil || n < 0 {
+					return part, fmt.Errorf("%w: %s: mask wants a non-negative count, got %q", ErrInvalidTemplate, ref, arg)
+				}
+				p.n = n
+			}
+		case "time":
+			if arg == "" {
+				return part, fmt.Errorf("%w: %s: time needs a layout", ErrInvalidTemplate, ref)
+			}
+		default:
+			return part, fmt.Errorf("%w: %s: unknown function %q", ErrInvalidTemplate, ref, name)
+		}
+		part.pipes = append(part.pipes, p)
+	}
+	return part, nil
+}
+
+// ReplaceTemplate replaces every match of re in target with tmpl rendered
+// against the match. It is [CompileTemplate] followed by [Replacer.Replace];
+// compile once with CompileTemplate to reuse the template. On no match, and on
+// any error, it returns target unchanged.
+//
+// Example:
+//
+//	re := regexp.MustCompile(`(?P<level>[A-Z]+) (?P<ts>\S+) (?P<msg>.*)`)
+//	out, err := regextra.ReplaceTemplate(re, "WARN 2024-03-01T12:00:00Z disk low",
+//	    "${ts|time:Jan 2 15:04} [${level|lower}] ${msg}")
+//	// out = "Mar 1 12:00 [warn] disk low"
+func ReplaceTemplate(re *regexp.Regexp, target, tmpl string) (string, error) {
+	r, err := CompileTemplate(re, tmpl)
+	if err != nil {
+		return target, fmt.Errorf("regextra.ReplaceTemplate: %w", err)
+	}
+	out, err := r.replace(target, -1)
+	if err != nil {
+		return target, fmt.Errorf("regextra.ReplaceTemplate: %w", err)
+	}
+	return out, nil
+}
+
+// Replace replaces every match of r's pattern in target with the rendered
+// template, leaving the text between matches unchanged. On no match it
+// returns target unchanged. If a template function fails it returns target
+// unchanged and a [*TemplateError].
+func (r *Replacer) Replace(target string) (string, error) {
+	out, err := r.replace(target, -1)
+	if err != nil {
+		return target, fmt.Errorf("regextra.Replacer.Replace: %w", err)
+	}
+	return out, nil
+}
+
+// ReplaceFirst is like [Replacer.Replace] but replaces only the first match.
+func (r *Replacer) ReplaceFirst(target string) (string, error) {
+	out, err := r.replace(target, 1)
+	if err != nil {
+		return target, fmt.Errorf("regextra.Replacer.ReplaceFirst: %w", err)
+	}
+	return out, nil
+}
+
+// Template returns the template r was compiled from.
+func (r *Replacer) Template() string {
+	return r.tmpl
+}
+
+// Regexp returns the regexp r matches with.
+func (r *Replacer) Regexp() *regexp.Regexp {
+	return r.re
+}
+
+// replace renders the template over up to limit matches of target.
+func (r *Replacer) replace(target string, limit int) (string, error) {
+	matches := r.re.FindAllStringSubmatchIndex(target, limit)
+	if len(matches) == 0 {
+		return target, nil
+	}
+	var b strings.Builder
+	cursor := 0
+	for n, m := range matches {
+		b.WriteString(target[cursor:m[0]])
+		if err := r.render(&b, target, m, n); err != nil {
+			return "", err
+		}
+		cursor = m[1]
+	}
+	b.WriteString(target[cursor:])
+	return b.String(), nil
+}
+
+// render writes the template for one match, the n'th, to b.
+func (r *Replacer) render(b *strings.Builder, target string, m []int, n int) error {
+	for _, part := range r.parts {
+		if part.ref == "" {
+			b.WriteString(part.text)
+			continue
+		}
+		value := ""
+		for _, idx := range part.idxs {
+			if start := m[2*idx]; start >= 0 {
+				value = target[start:m[2*idx+1]]
+			}
+		}
+		for _, p := range part.pipes {
+			out, err := p.apply(value)
+			if err != nil {
+				return &TemplateError{
+					Ref:    part.ref,
+					Group:  part.name,
+					Value:  value,
+					Match:  n,
+					Offset: m[0],
+					End:    m[1],
+					Err:    err,
+				}
+			}
+			value = out
+		}
+		b.WriteString(value)
+	}
+	return nil
+}
+
+// apply runs one template function on value.
+func (p templatePipe) apply(value string) (string, error) {
+	switch p.name {
+	case "upper":
+		return strings.ToUpper(value), nil
+	case "lower":
+		return strings.ToLower(value), nil
+	case "trim":
+		return strings.TrimSpace(value), nil
+	case "default":
+		if value == "" {
+			return p.arg, nil
+		}
+		return value, nil
+	case "mask":
+		runes := []rune(value)
+		masked := len(runes)
+		if p.n >= 0 {
+			masked -= min(p.n, len(runes))
+		}
+		return strings.Repeat("*", masked) + string(runes[masked:]), nil
+	case "time":
+		if value == "" {
+			return "", nil
+		}
+		t, err := parseTime(value, nil)
+		if err != nil {
+			return "", err
+		}
+		return t.Format(p.arg), nil
+	}
+	// Unreachable: parseReference rejects unknown functions.
+	return value, nil
+}
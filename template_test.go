@@ -0,0 +1,199 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestReplaceTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		tmpl    string
+		want    string
+	}{
+		{
+			name:    "reorder groups",
+			pattern: `(?P<user>\w+)@(?P<host>[\w.]+)`,
+			target:  "to alice@example.com, bob@test.org!",
+			tmpl:    "${host}/${user}",
+			want:    "to example.com/alice, test.org/bob!",
+		},
+		{
+			name:    "pipes apply in order",
+			pattern: `user=(?P<user>[^;]*);`,
+			target:  "user=  Ada ;",
+			tmpl:    "${user|trim|upper}",
+			want:    "ADA",
+		},
+		{
+			name:    "lower and default",
+			pattern: `(?P<level>[A-Z]*):(?P<msg>.*)`,
+			target:  ":Boot",
+			tmpl:    "[${level|lower|default:info}] ${msg|lower}",
+			want:    "[info] boot",
+		},
+		{
+			name:    "mask keeps the last n runes",
+			pattern: `card (?P<card>\d+) pin (?P<pin>\d+)`,
+			target:  "card 4111111111111111 pin 1234",
+			tmpl:    "card ${card|mask:4} pin ${pin|mask}",
+			want:    "card ************1111 pin ****",
+		},
+		{
+			name:    "mask is rune-aware and saturates",
+			pattern: `(?P<s>.+)`,
+			target:  "héllo",
+			tmpl:    "${s|mask:2}|${s|mask:9}",
+			want:    "***lo|héllo",
+		},
+		{
+			name:    "time reformats",
+			pattern: `at (?P<ts>\S+)`,
+			target:  "at 2024-03-01T12:30:00Z",
+			tmpl:    "on ${ts|time:2006-01-02 15:04}",
+			want:    "on 2024-03-01 12:30",
+		},
+		{
+			name:    "time leaves empty values empty",
+			pattern: `at(?: (?P<ts>\S+))?`,
+			target:  "at",
+			tmpl:    "[${ts|time:2006}]",
+			want:    "[]",
+		},
+		{
+			name:    "dollar escapes and literal dollars",
+			pattern: `(?P<n>\d+)`,
+			target:  "cost 5",
+			tmpl:    "$$${n} (was $5) $",
+			want:    "cost $5 (was $5) $",
+		},
+		{
+			name:    "reused name reads the participating occurrence",
+			pattern: `(?:id=(?P<ref>\d+)|name=(?P<ref>\w+))`,
+			target:  "name=bob",
+			tmpl:    "<${ref}>",
+			want:    "<bob>",
+		},
+		{
+			name:    "no match leaves the target",
+			pattern: `(?P<n>\d+)`,
+			target:  "none",
+			tmpl:    "#${n}",
+			want:    "none",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rx.ReplaceTemplate(regexp.MustCompile(tt.pattern), tt.target, tt.tmpl)
+			if err != nil || got != tt.want {
+				t.Errorf("ReplaceTemplate() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileTemplate_errors(t *testing.T) {
+	re := regexp.MustCompile(`(?P<a>\w+)`)
+	tests := []struct {
+		tmpl, want string
+	}{
+		{"x ${a", "unterminated reference at offset 2"},
+		{"${}", "${}: missing group name"},
+		{"${b}", `${b}: no group named "b"`},
+		{"${a|shout}", `${a|shout}: unknown function "shout"`},
+		{"${a|upper:x}", "${a|upper:x}: upper takes no argument"},
+		{"${a|mask:-1}", `${a|mask:-1}: mask wants a non-negative count, got "-1"`},
+		{"${a|time}", "${a|time}: time needs a layout"},
+	}
+	for _, tt := range tests {
+		_, err := rx.CompileTemplate(re, tt.tmpl)
+		if !errors.Is(err, rx.ErrInvalidTemplate) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("CompileTemplate(%q) error = %v, want ErrInvalidTemplate containing %q", tt.tmpl, err, tt.want)
+		}
+	}
+
+	out, err := rx.ReplaceTemplate(re, "keep", "${nope}")
+	if !errors.Is(err, rx.ErrInvalidTemplate) || out != "keep" {
+		t.Errorf("ReplaceTemplate(bad template) = %q, %v; want the target and ErrInvalidTemplate", out, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompileTemplate(bad template) did not panic")
+		}
+	}()
+	rx.MustCompileTemplate(re, "${nope}")
+}
+
+func TestReplacer(t *testing.T) {
+	re := regexp.MustCompile(`(?P<k>\w+)=(?P<v>\S+)`)
+	r := rx.MustCompileTemplate(re, "${v|time:15:04}")
+	if r.Template() != "${v|time:15:04}" || r.Regexp() != re {
+		t.Errorf("Template(), Regexp() = %q, %v", r.Template(), r.Regexp())
+	}
+
+	got, err := r.ReplaceFirst("a=2024-03-01T10:00:00Z b=2024-03-01T11:00:00Z")
+	if err != nil || got != "10:00 b=2024-03-01T11:00:00Z" {
+		t.Errorf("ReplaceFirst() = %q, %v", got, err)
+	}
+
+	const bad = "a=2024-03-01T10:00:00Z b=soon"
+	got, err = r.Replace(bad)
+	var te *rx.TemplateError
+	if got != bad || !errors.As(err, &te) {
+		t.Fatalf("Replace(bad time) = %q, %v; want the target and a *TemplateError", got, err)
+	}
+	var pe *time.ParseError
+	if te.Ref != "${v|time:15:04}" || te.Group != "v" || te.Value != "soon" || te.Match != 1 || te.Offset != 23 || te.End != 29 || !errors.As(err, &pe) {
+		t.Errorf("TemplateError = %+v", te)
+	}
+	if !strings.HasPrefix(err.Error(), "regextra.Replacer.Replace: ${v|time:15:04}: ") {
+		t.Errorf("Error() = %q", err)
+	}
+	if (&rx.TemplateError{}).Error() != "no template error" {
+		t.Error("zero TemplateError message")
+	}
+
+	// A Replacer is shared safely across goroutines.
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, _ := r.Replace("x=2024-03-01T09:05:00Z"); got != "09:05" {
+				t.Errorf("concurrent Replace() = %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func ExampleReplaceTemplate() {
+	re := regexp.MustCompile(`(?P<ts>\S+) (?P<level>[A-Z]+) (?P<msg>.*)`)
+	out, _ := rx.ReplaceTemplate(re, "2024-03-01T12:00:00Z WARN disk low",
+		"level=${level|lower} time=${ts|time:Jan 2 15:04} msg=${msg}")
+	fmt.Println(out)
+	// Output:
+	// level=warn time=Mar 1 12:00 msg=disk low
+}
+
+func ExampleCompileTemplate() {
+	re := regexp.MustCompile(`user=(?P<user>\w+) card=(?P<card>\d+)`)
+	r := rx.MustCompileTemplate(re, "${user|upper} paid with ${card|mask:4}")
+	for _, line := range []string{"user=ada card=4111111111111111", "user=bob card=5500000000000004"} {
+		out, _ := r.Replace(line)
+		fmt.Println(out)
+	}
+	// Output:
+	// ADA paid with ************1111
+	// BOB paid with ************0004
+}
@@ -0,0 +1,189 @@
+package regextra
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fieldLayouts returns the layouts a time.Time field is read with: the
+// `layout=` option's one, or, with list set, the candidates of a `layouts=`
+// option, separated by `|` in the order they are tried. It returns "" when
+// the field has neither and parseTime's fallback list applies. The string is
+// returned unsplit so the per-value parse allocates nothing for it.
+func fieldLayouts(opts map[string]string) (layouts string, list bool) {
+	if layout := opts["layout"]; layout != "" {
+		return layout, false
+	}
+	return opts["layouts"], true
+}
+
+// zones caches the locations `tz=` options name, by name, so a field's zone
+// is read from the time zone database once rather than on every decode.
+var zones sync.Map
+
+// loadZone is [time.LoadLocation], cached in zones.
+func loadZone(name string) (*time.Location, error) {
+	if loc, ok := zones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz %q: %w", name, err)
+	}
+	zones.Store(name, loc)
+	return loc, nil
+}
+
+// fieldLocation returns the zone a time.Time field reads a value without its
+// own offset in: the IANA zone its `tz=` option names, or else cfg.Location
+// (nil meaning UTC).
+func fieldLocation(opts map[string]string, cfg *DecoderConfig) (*time.Location, error) {
+	if name, ok := opts["tz"]; ok {
+		return loadZone(name)
+	}
+	return cfg.Location, nil
+}
+
+// parseTimeField parses value for a time.Time field under its `layout=`,
+// `layouts=`, and `tz=` options and cfg's DefaultLocation and InferYear.
+// A `layout=` is used exclusively; the `layouts=` candidates are tried in
+// order and the first that parses wins, reporting the first one's error when
+// none does; with neither, parseTime's fallback list is tried.
+func parseTimeField(value string, opts map[string]string, cfg *DecoderConfig) (time.Time, error) {
+	loc, err := fieldLocation(opts, cfg)
+	if err != nil {
+		return time.Time{}, err
+	}
+	layouts, list := fieldLayouts(opts)
+	if layouts == "" {
+		t, err := parseTime(value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot convert %q to time.Time: %w", value, err)
+		}
+		return t, nil
+	}
+	var firstErr error
+	for rest := layouts; ; {
+		layout := rest
+		if list {
+			layout, rest, _ = strings.Cut(rest, "|")
+		} else {
+			rest = ""
+		}
+		t, err := parseInLocation(layout, value, loc)
+		if err == nil {
+			if cfg.InferYear && yearlessDate(layout) {
+				ref := cfg.YearReference
+				if ref.IsZero() {
+					ref = time.Now()
+				}
+				t = inferYear(t, ref)
+			}
+			return t, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if rest == "" {
+			break
+		}
+	}
+	if list {
+		return time.Time{}, fmt.Errorf("cannot convert %q to time.Time using layouts %q: %w", value, layouts, firstErr)
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %q to time.Time using layout %q: %w", value, layouts, firstErr)
+}
+
+// yearlessDate reports whether layout reads a date but no year, as syslog's
+// "Jan _2 15:04:05" does. Every Go layout element for the day (2, _2, 02,
+// __2, 002) contains a 2, each for the year (2006, 06) contains 06, and no
+// other element contains either.
+func yearlessDate(layout string) bool {
+	return strings.Contains(layout, "2") && !strings.Contains(layout, "06")
+}
+
+// inferYear moves t, parsed from a layout without a year and so in year 0,
+// to the year that puts it nearest ref: ref's own year, or across a new year
+// the one before or after. Years back to four before ref's are tried, so a
+// February 29 lands in a leap year.
+func inferYear(t, ref time.Time) time.Time {
+	ref = ref.In(t.Location())
+	best, bestDist := t, time.Duration(-1)
+	for y := ref.Year() - 4; y <= ref.Year()+1; y++ {
+		c := time.Date(y, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		if c.Month() != t.Month() {
+			continue // February 29 outside a leap year
+		}
+		dist := c.Sub(ref)
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+	return best
+}
+
+// formatTimeField renders a time.Time field's value with the first of its
+// layouts (see fieldLayouts), or RFC3339Nano — the first layout of the decode
+// fallback list — when it has none. The value is written in the zone it is
+// read in (see fieldLocation) — its `tz=` option's, or cfg's DefaultLocation —
+// so a layout without an offset reads back as the same instant. With neither
+// set it keeps its own zone.
+func formatTimeField(t time.Time, opts map[string]string, cfg *DecoderConfig) (string, error) {
+	loc, err := fieldLocation(opts, cfg)
+	if err != nil {
+		return "", err
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	layout := time.RFC3339Nano
+	if layouts, list := fieldLayouts(opts); layouts != "" {
+		layout = layouts
+		if list {
+			layout, _, _ = strings.Cut(layouts, "|")
+		}
+	}
+	if unit, ok := epochLayouts[layout]; ok {
+		return formatEpoch(t, unit), nil
+	}
+	return t.Format(layout), nil
+}
+
+// checkTimeOptions implements Compile's validation of the time options on a
+// field of type t (or its elements, for a slice or array): `layout=`,
+// `layouts=`, and `tz=` only on time.Time, `layout=` and `layouts=` not
+// together, no empty `layouts=` candidate, and a `tz=` the time zone database
+// knows.
+func checkTimeOptions(t reflect.Type, opts map[string]string, cfg *DecoderConfig) error {
+	ft := t
+	if isCollectionType(ft, cfg) {
+		ft = ft.Elem()
+	}
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	for _, key := range []string{"layout", "layouts", "tz"} {
+		if _, ok := opts[key]; ok && ft != timeTimeType {
+			return fmt.Errorf("has `%s=` option but is %v, not time.Time", key, t)
+		}
+	}
+	if layouts, ok := opts["layouts"]; ok {
+		if _, ok := opts["layout"]; ok {
+			return errors.New("has both `layout=` and `layouts=` options")
+		}
+		for _, layout := range strings.Split(layouts, "|") {
+			if layout == "" {
+				return fmt.Errorf("has an empty layout in `layouts=%s`", layouts)
+			}
+		}
+	}
+	_, err := fieldLocation(opts, cfg)
+	return err
+}
@@ -0,0 +1,231 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func mustLoad(t testing.TB, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone database: %v", err)
+	}
+	return loc
+}
+
+func TestTimeOptions_layouts(t *testing.T) {
+	type rec struct {
+		At time.Time `regex:"at,layouts=02/Jan/2006:15:04:05 -0700|2006-01-02|unix"`
+	}
+	dec := rx.MustCompile[rec](`at=(?P<at>.+)`)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"at=10/Jun/2024:08:13:20 +0200", time.Date(2024, 6, 10, 6, 13, 20, 0, time.UTC)},
+		{"at=2024-06-10", time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)},
+		{"at=1718000000", time.Unix(1718000000, 0)},
+	}
+	for _, tt := range tests {
+		got, err := dec.One(tt.input)
+		if err != nil || !got.At.Equal(tt.want) {
+			t.Errorf("One(%q) = %v, %v; want %v", tt.input, got.At, err, tt.want)
+		}
+	}
+
+	// With none parsing, the error names the candidates and carries the
+	// first one's failure.
+	_, err := dec.One("at=yesterday")
+	var de *rx.DecodeError
+	var pe *time.ParseError
+	if !errors.As(err, &de) || !errors.As(err, &pe) || pe.Layout != "02/Jan/2006:15:04:05 -0700" ||
+		!strings.Contains(err.Error(), `using layouts "02/Jan/2006:15:04:05 -0700|2006-01-02|unix"`) {
+		t.Errorf("One(bad) error = %v, want a DecodeError naming the layouts", err)
+	}
+
+	// The Encoder writes the first layout.
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	at := time.Date(2024, 6, 10, 8, 13, 20, 0, time.FixedZone("", 2*60*60))
+	if s, err := enc.Encode(rec{at}); err != nil || s != "at=10/Jun/2024:08:13:20 +0200" {
+		t.Errorf("Encode() = %q, %v", s, err)
+	}
+}
+
+func TestTimeOptions_tz(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	type rec struct {
+		Local  time.Time   `regex:"local,layout=2006-01-02 15:04:05,tz=America/New_York"`
+		Offset time.Time   `regex:"offset,tz=America/New_York"`
+		Plain  time.Time   `regex:"plain,layout=2006-01-02 15:04:05"`
+		Many   []time.Time `regex:"many,split=comma,layout=2006-01-02 15:04,tz=Asia/Tokyo"`
+	}
+	const pattern = `(?P<local>[^;]+);(?P<offset>[^;]+);(?P<plain>[^;]+);(?P<many>.+)`
+	const input = "2024-01-15 09:30:00;2024-01-15T09:30:00+01:00;2024-01-15 09:30:00;2024-07-01 00:00,2024-07-01 12:00"
+
+	// tz= overrides the decoder-wide DefaultLocation, which still applies to
+	// the other fields; a value with an offset keeps it.
+	dec := rx.MustCompileWith[rec](pattern, rx.DefaultLocation(time.FixedZone("X", -3*60*60)))
+	got, err := dec.One(input)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 15, 9, 30, 0, 0, ny); !got.Local.Equal(want) || got.Local.Location().String() != ny.String() {
+		t.Errorf("Local = %v, want %v", got.Local, want)
+	}
+	if _, off := got.Offset.Zone(); off != 60*60 || got.Offset.Hour() != 9 {
+		t.Errorf("Offset = %v, want 09:30 at +01:00", got.Offset)
+	}
+	if name, _ := got.Plain.Zone(); name != "X" {
+		t.Errorf("Plain = %v, want it read in the DefaultLocation", got.Plain)
+	}
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	if len(got.Many) != 2 || !got.Many[1].Equal(time.Date(2024, 7, 1, 12, 0, 0, 0, tokyo)) {
+		t.Errorf("Many = %v, want each element in Asia/Tokyo", got.Many)
+	}
+
+	// The Encoder writes a tz= field in its zone, so the offset-less layout
+	// reads back as the same instant.
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	v := got
+	v.Local = time.Date(2024, 7, 4, 16, 0, 0, 0, time.UTC)
+	s, err := enc.Encode(v)
+	if err != nil || !strings.HasPrefix(s, "2024-07-04 12:00:00;") {
+		t.Fatalf("Encode() = %q, %v; want Local written in New York time", s, err)
+	}
+	if back, err := dec.One(s); err != nil || !back.Local.Equal(v.Local) {
+		t.Errorf("One(Encode()) Local = %v, %v; want %v", back.Local, err, v.Local)
+	}
+}
+
+func TestTimeOptions_inferYear(t *testing.T) {
+	type rec struct {
+		At time.Time `regex:"at,layouts=Jan _2 15:04:05|2006 Jan _2 15:04:05|15:04:05"`
+	}
+	const pattern = `(?P<at>.+)`
+	tests := []struct {
+		name, input string
+		ref, want   time.Time
+	}{
+		{"same year", "Jun 15 10:00:00", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)},
+		{"last year", "Dec 31 23:59:59", time.Date(2025, 1, 1, 0, 5, 0, 0, time.UTC), time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{"next year", "Jan  1 00:00:01", time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 1, 0, time.UTC)},
+		{"leap day", "Feb 29 12:00:00", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"has a year", "2019 Dec 31 23:59:59", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{"has no date", "10:00:00", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rx.MustCompileWith[rec](pattern, rx.InferYear(tt.ref)).One(tt.input)
+			if err != nil || !got.At.Equal(tt.want) {
+				t.Errorf("One(%q) = %v, %v; want %v", tt.input, got.At, err, tt.want)
+			}
+		})
+	}
+
+	// Without the option the year stays 0; with a zero reference it is
+	// inferred from the clock.
+	if got, _ := rx.MustCompile[rec](pattern).One("Jun 15 10:00:00"); got.At.Year() != 0 {
+		t.Errorf("without InferYear, year = %d, want 0", got.At.Year())
+	}
+	now := time.Now()
+	got, err := rx.MustCompileWith[rec](pattern, rx.InferYear(time.Time{})).One(now.UTC().Format("Jan _2 15:04:05"))
+	if d := got.At.Sub(now); err != nil || d > time.Second || d < -time.Second {
+		t.Errorf("InferYear(zero) = %v, %v; want about %v", got.At, err, now)
+	}
+
+	var u struct {
+		At time.Time `regex:"at,layout=Jan _2"`
+	}
+	ref := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := rx.UnmarshalWith(regexp.MustCompile(pattern), "Dec 30", &u, rx.InferYear(ref)); err != nil || u.At.Year() != 2024 {
+		t.Errorf("UnmarshalWith(InferYear) = %v, %v; want 2024", u.At, err)
+	}
+}
+
+func TestTimeOptions_compile(t *testing.T) {
+	tests := []struct {
+		name string
+		try  func() error
+		want string
+	}{
+		{"tz on string", func() error {
+			_, err := rx.Compile[struct {
+				A string `regex:"a,tz=UTC"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `tz=` option but is string, not time.Time"},
+		{"layouts on duration", func() error {
+			_, err := rx.Compile[struct {
+				A []time.Duration `regex:"a,layouts=15:04|unix"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `layouts=` option but is []time.Duration, not time.Time"},
+		{"both", func() error {
+			_, err := rx.Compile[struct {
+				A time.Time `regex:"a,layout=15:04,layouts=15:04|unix"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has both `layout=` and `layouts=` options"},
+		{"empty candidate", func() error {
+			_, err := rx.Compile[struct {
+				A time.Time `regex:"a,layouts=15:04||unix"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has an empty layout in `layouts=15:04||unix`"},
+		{"unknown zone", func() error {
+			_, err := rx.Compile[struct {
+				A *time.Time `regex:"a,tz=Mars/Olympus_Mons"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, `field A invalid tz "Mars/Olympus_Mons"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.try()
+			if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() error = %v, want ErrInvalidStruct containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// Lenient defers a bad zone to decode time.
+	type rec struct {
+		A time.Time `regex:"a,tz=Mars/Olympus_Mons"`
+	}
+	_, err := rx.MustCompileWith[rec](`(?P<a>.+)`, rx.Lenient()).One("2024-01-01")
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Field != "A" {
+		t.Errorf("Lenient One() error = %v, want a DecodeError for A", err)
+	}
+}
+
+func Example_timeZones() {
+	type Event struct {
+		At time.Time `regex:"at,layouts=Jan _2 15:04:05|2006-01-02T15:04:05,tz=Europe/Berlin"`
+	}
+	ref := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	dec := rx.MustCompileWith[Event](`^(?P<at>.+?) host`, rx.InferYear(ref))
+	for _, line := range []string{
+		"Dec 31 23:59:59 host sshd[42]: accepted",
+		"2025-07-01T09:00:00 host cron[7]: ran",
+	} {
+		e, _ := dec.One(line)
+		fmt.Println(e.At)
+	}
+	// Output:
+	// 2024-12-31 23:59:59 +0100 CET
+	// 2025-07-01 09:00:00 +0200 CEST
+}
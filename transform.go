@@ -0,0 +1,194 @@
+package regextra
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// valueTransform is one value transform option of a field's tag — `trim`,
+// `lower`, `upper`, `unquote`, `trimprefix=`, `trimsuffix=`, or `replace=` —
+// in the order the tag lists it. Transforms normalize a matched value before it is
+// converted (see applyTransforms) and, where one can be undone, are undone in
+// reverse order when the field is encoded (see reverseTransforms).
+type valueTransform struct {
+	// op is the option's name.
+	op string
+	// arg is the prefix or suffix of trimprefix= and trimsuffix=, or the text
+	// replace= replaces; it is empty for a replace= option that names none.
+	arg string
+	// with is the text replace= substitutes for arg.
+	with string
+}
+
+// parseTransform reports whether the tag option k is a value transform, and
+// returns it. pair reports whether the option was written key=value, with
+// value v: trim, lower, upper, and unquote are lone tokens, trimprefix=,
+// trimsuffix=, and replace= are pairs, and the wrong form is not a transform.
+//
+// replace= is written `replace=<old>:<new>`, each side spelled as `split=`
+// spells a separator (comma, space, tab) or taken literally, so old cannot
+// contain a colon; an empty new deletes old. A value without the colon, or
+// with an empty old, yields a replace with no arg, which Compile rejects (see
+// checkTransforms) and decoding ignores.
+func parseTransform(k, v string, pair bool) (valueTransform, bool) {
+	switch k {
+	case "trim", "lower", "upper", "unquote":
+		return valueTransform{op: k}, !pair
+	case "trimprefix", "trimsuffix":
+		return valueTransform{op: k, arg: v}, pair
+	case "replace":
+		old, with, ok := strings.Cut(v, ":")
+		if !ok || old == "" {
+			return valueTransform{op: k}, pair
+		}
+		return valueTransform{op: k, arg: separatorWord(old), with: separatorWord(with)}, pair
+	}
+	return valueTransform{}, false
+}
+
+// checkTransforms implements Compile's validation of a field's transforms:
+// each replace= names the text it replaces.
+func checkTransforms(transforms []valueTransform) error {
+	for _, t := range transforms {
+		if t.op == "replace" && t.arg == "" {
+			return errors.New("has a `replace=` option not of the form `replace=<old>:<new>`")
+		}
+	}
+	return nil
+}
+
+// applyTransforms runs a field's transforms over a matched value, first to
+// last. Only unquote can fail.
+func applyTransforms(value string, transforms []valueTransform) (string, error) {
+	for _, t := range transforms {
+		switch t.op {
+		case "trim":
+			value = strings.TrimSpace(value)
+		case "lower":
+			value = strings.ToLower(value)
+		case "upper":
+			value = strings.ToUpper(value)
+		case "unquote":
+			s, err := unquote(value)
+			if err != nil {
+				return value, err
+			}
+			value = s
+		case "trimprefix":
+			value = strings.TrimPrefix(value, t.arg)
+		case "trimsuffix":
+			value = strings.TrimSuffix(value, t.arg)
+		case "replace":
+			if t.arg != "" {
+				value = strings.ReplaceAll(value, t.arg, t.with)
+			}
+		}
+	}
+	return value, nil
+}
+
+// reverseTransforms undoes a field's transforms over an encoded value, last
+// to first, so the result decodes back through applyTransforms: unquote
+// quotes the value Go-style, trimprefix= and trimsuffix= add their text back,
+// and replace= puts the old text back for the new. trim, lower, and upper
+// discard what they removed, as does a replace= that deletes, and are left as
+// is.
+func reverseTransforms(value string, transforms []valueTransform) string {
+	for i := len(transforms) - 1; i >= 0; i-- {
+		switch t := transforms[i]; t.op {
+		case "unquote":
+			value = strconv.Quote(value)
+		case "trimprefix":
+			value = t.arg + value
+		case "trimsuffix":
+			value += t.arg
+		case "replace":
+			if t.arg != "" && t.with != "" {
+				value = strings.ReplaceAll(value, t.with, t.arg)
+			}
+		}
+	}
+	return value
+}
+
+// encodeTransformed is encodeFieldValue followed by reverseTransforms. A
+// `split=` list is undone element by element, before the join, as decoding
+// applies transforms to each element after the split.
+func encodeTransformed(field reflect.Value, opts map[string]string, transforms []valueTransform, conv encodeConverter, cfg *DecoderConfig) (string, error) {
+	if len(transforms) == 0 {
+		return encodeFieldValue(field, opts, conv, cfg)
+	}
+	if conv.list {
+		sep, _ := splitSeparator(opts)
+		conv.list = false
+		parts := make([]string, field.Len())
+		for i := range parts {
+			s, err := encodeFieldValue(field.Index(i), opts, conv, cfg)
+			if err != nil {
+				return "", fmt.Errorf("element %d: %w", i, err)
+			}
+			parts[i] = reverseTransforms(s, transforms)
+		}
+		return strings.Join(parts, sep), nil
+	}
+	s, err := encodeFieldValue(field, opts, conv, cfg)
+	if err != nil {
+		return "", err
+	}
+	return reverseTransforms(s, transforms), nil
+}
+
+// errUnterminatedQuote reports a value that opens a quote it does not close.
+var errUnterminatedQuote = errors.New("unterminated quoted string")
+
+// unquote removes one level of quoting from s, Go- or shell-style, and
+// returns s unchanged when it does not start with a quote character.
+//
+// A double-quoted or back-quoted value is read as a Go string literal
+// ([strconv.Unquote]); a double-quoted value that is not valid Go is read as
+// a POSIX shell double-quoted string instead, where a backslash escapes only
+// $, `, ", \, and a newline. A single-quoted value is read shell-style: its
+// contents are literal, and it cannot contain a single quote.
+func unquote(s string) (string, error) {
+	if s == "" {
+		return s, nil
+	}
+	q := s[0]
+	if q != '"' && q != '\'' && q != '`' {
+		return s, nil
+	}
+	if len(s) < 2 || s[len(s)-1] != q {
+		return "", errUnterminatedQuote
+	}
+	inner := s[1 : len(s)-1]
+	switch q {
+	case '\'':
+		if strings.IndexByte(inner, '\'') >= 0 {
+			return "", errors.New("single-quoted string contains a single quote")
+		}
+		return inner, nil
+	case '`':
+		return strconv.Unquote(s)
+	}
+	if u, err := strconv.Unquote(s); err == nil {
+		return u, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case c == '"':
+			return "", errors.New("double-quoted string contains an unescaped double quote")
+		case c == '\\' && i+1 < len(inner) && strings.IndexByte("$`\"\\\n", inner[i+1]) >= 0:
+			i++
+			c = inner[i]
+		case c == '\\' && i+1 == len(inner):
+			return "", errUnterminatedQuote
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,222 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestTransforms(t *testing.T) {
+	type rec struct {
+		S string `regex:"s,trim,lower"`
+		N int    `regex:"n,trimprefix=#,trimsuffix=px"`
+		Q string `regex:"q,unquote"`
+		U string `regex:"u,trim,upper"`
+	}
+	dec := rx.MustCompile[rec](`s=(?P<s>[^;]*);n=(?P<n>[^;]*);q=(?P<q>[^;]*);u=(?P<u>[^;]*)`)
+	tests := []struct {
+		name, input string
+		want        rec
+	}{
+		{"all apply", `s= Hello ;n=#12px;q="a\tb";u= go `, rec{"hello", 12, "a\tb", "GO"}},
+		{"optional affixes", `s=x;n=7;q=plain;u=`, rec{"x", 7, "plain", ""}},
+		{"go back quotes", "s=;n=0;q=`a\\n`;u=", rec{"", 0, `a\n`, ""}},
+		{"shell single quotes", `s=;n=0;q='it\s $x';u=`, rec{"", 0, `it\s $x`, ""}},
+		{"shell double quotes", `s=;n=0;q="\$HOME \d";u=`, rec{"", 0, `$HOME \d`, ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dec.One(tt.input)
+			if err != nil || got != tt.want {
+				t.Errorf("One(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+			}
+		})
+	}
+
+	// Unmarshal reads the same tags.
+	var v rec
+	if err := rx.Unmarshal(dec.Regexp(), `s=A;n=#1;q='b';u=c`, &v); err != nil || v != (rec{"a", 1, "b", "C"}) {
+		t.Errorf("Unmarshal() = %+v, %v", v, err)
+	}
+}
+
+func TestTransforms_order(t *testing.T) {
+	type rec struct {
+		A string `regex:"v,trimprefix=x,trim"`
+		B string `regex:"v,trim,trimprefix=x"`
+	}
+	got, err := rx.MustCompile[rec](`=(?P<v>.*)`).One("= xy")
+	if err != nil || got != (rec{"xy", "y"}) {
+		t.Errorf("One() = %+v, %v; want {A:xy B:y}", got, err)
+	}
+}
+
+func TestTransforms_empty(t *testing.T) {
+	type rec struct {
+		Name string `regex:"name,trim,default=anon"`
+		Tag  string `regex:"tag,trimprefix=#,default=#none"`
+		ID   int    `regex:"id,unquote,required"`
+	}
+	dec := rx.MustCompile[rec](`(?P<name>[^|]*)\|(?P<tag>[^|]*)\|(?P<id>.*)`)
+
+	// A value a transform empties is absent: default= substitutes, as
+	// written.
+	got, err := dec.One(`   |#|7`)
+	if err != nil || got != (rec{"anon", "#none", 7}) {
+		t.Errorf("One() = %+v, %v", got, err)
+	}
+
+	// ...and required fails.
+	_, err = dec.One(`a|b|""`)
+	var rge *rx.RequiredGroupError
+	if !errors.As(err, &rge) || rge.Field != "ID" {
+		t.Errorf("One(empty quoted id) error = %v, want a RequiredGroupError for ID", err)
+	}
+}
+
+func TestTransforms_unquoteErrors(t *testing.T) {
+	type rec struct {
+		Q string `regex:"q,unquote"`
+	}
+	dec := rx.MustCompile[rec](`q=(?P<q>.*)`)
+	for _, input := range []string{`q="open`, `q='a'b'`, `q="a"b"`, `q="a\"`, `q='`, "q=`a"} {
+		_, err := dec.One(input)
+		var de *rx.DecodeError
+		if !errors.As(err, &de) || de.Value != input[2:] || de.Offset != 2 || de.End != len(input) {
+			t.Errorf("One(%q) error = %v, want a DecodeError for the raw value", input, err)
+		}
+	}
+	// A "..." value that is not a valid Go literal reads shell-style.
+	if got, err := dec.One(`q="\400"`); err != nil || got.Q != `\400` {
+		t.Errorf("One(bad Go escape) = %+v, %v; want the shell reading", got, err)
+	}
+}
+
+func TestTransforms_collections(t *testing.T) {
+	type rec struct {
+		Tags []string `regex:"tags,split=comma,trim,unquote"`
+		IDs  []int    `regex:"id,trimprefix=#"`
+		Def  []string `regex:"none,lower,default=A"`
+	}
+	dec := rx.MustCompile[rec](`\[(?P<tags>[^\]]*)\] (?P<id>\S+)(?: (?P<id>\S+))?(?P<none>x)?`)
+	got, err := dec.One(`[ "a b" , 'c',  , "" ] #1 #2`)
+	if err != nil {
+		t.Fatalf("One() error = %v", err)
+	}
+	// Elements are transformed after the split, and ones left empty are
+	// dropped; the default is not transformed.
+	if !slices.Equal(got.Tags, []string{"a b", "c"}) || !slices.Equal(got.IDs, []int{1, 2}) || !slices.Equal(got.Def, []string{"A"}) {
+		t.Errorf("One() = %+v", got)
+	}
+
+	// A failing element reports its raw text and span.
+	const input = `[a, "b] #1 #x`
+	_, err = dec.One(input)
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Value != ` "b` || de.Offset != 3 || de.End != 6 {
+		t.Errorf("One(%q) error = %+v, want a DecodeError for the second tag", input, de)
+	}
+	_, err = dec.One(`[a] #1 #x`)
+	if !errors.As(err, &de) || de.Value != "#x" || de.Offset != 7 {
+		t.Errorf("One(bad id) error = %+v, want a DecodeError for #x", de)
+	}
+}
+
+func TestTransforms_encode(t *testing.T) {
+	type rec struct {
+		Name  string   `regex:"name,unquote"`
+		Color int      `regex:"color,trimprefix=#,trimsuffix=h"`
+		Tags  []string `regex:"tags,split=comma,trimprefix=+"`
+		Key   string   `regex:"key,trim,lower"`
+	}
+	dec := rx.MustCompile[rec](`name=(?P<name>"(?:[^"\\]|\\.)*") color=(?P<color>#\d+h) tags=(?P<tags>\S*) key=(?P<key>\S+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	want := rec{Name: `say "hi"`, Color: 7, Tags: []string{"a", "b"}, Key: "k"}
+	s, err := enc.EncodeStrict(want)
+	if err != nil || s != `name="say \"hi\"" color=#7h tags=+a,+b key=k` {
+		t.Fatalf("EncodeStrict() = %q, %v", s, err)
+	}
+	got, err := dec.One(s)
+	if err != nil || got.Name != want.Name || got.Color != want.Color || !slices.Equal(got.Tags, want.Tags) || got.Key != want.Key {
+		t.Errorf("One(Encode()) = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestTransforms_replace(t *testing.T) {
+	type rec struct {
+		Path  string `regex:"path,replace=\\:/"`
+		Words string `regex:"words,replace=_:space,replace=comma:space"`
+		N     int    `regex:"n,replace=_:"`
+	}
+	dec := rx.MustCompile[rec](`(?P<path>\S+) (?P<words>\S+) (?P<n>\S+)`)
+	got, err := dec.One(`a\b\c x_y,z 1_000_000`)
+	if err != nil || got != (rec{"a/b/c", "x y z", 1000000}) {
+		t.Fatalf("One() = %+v, %v", got, err)
+	}
+
+	// The Encoder writes old back for new; a replace= that deletes is left
+	// as is.
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	s, err := enc.Encode(got)
+	if err != nil || s != `a\b\c x,y,z 1000000` {
+		t.Fatalf("Encode() = %q, %v", s, err)
+	}
+	if back, err := dec.One(s); err != nil || back != got {
+		t.Errorf("One(Encode()) = %+v, %v; want %+v", back, err, got)
+	}
+
+	// Compile rejects a replace= without old text.
+	_, err = rx.Compile[struct {
+		A string `regex:"a,replace=x"`
+	}](`(?P<a>.+)`)
+	if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), "field A has a `replace=` option not of the form `replace=<old>:<new>`") {
+		t.Errorf("Compile(replace=x) error = %v", err)
+	}
+	_, err = rx.Compile[struct {
+		A string `regex:"a,replace=:y"`
+	}](`(?P<a>.+)`)
+	if !errors.Is(err, rx.ErrInvalidStruct) {
+		t.Errorf("Compile(replace=:y) error = %v, want ErrInvalidStruct", err)
+	}
+}
+
+func TestTransforms_strictTypes(t *testing.T) {
+	type rec struct {
+		N int `regex:"n,unquote"`
+	}
+	dec, err := rx.CompileWith[rec](`(?P<n>"\d+")`, rx.StrictTypes())
+	if err != nil {
+		t.Fatalf("CompileWith(StrictTypes) error = %v, want transforms to skip the check", err)
+	}
+	if got, err := dec.One(`"42"`); err != nil || got.N != 42 {
+		t.Errorf("One() = %+v, %v", got, err)
+	}
+}
+
+func Example_valueTransforms() {
+	type Setting struct {
+		Key   string `regex:"key,trim,lower"`
+		Value string `regex:"value,trim,unquote"`
+		ID    int    `regex:"id,trimprefix=#"`
+	}
+	dec := rx.MustCompile[Setting](`(?P<key>[^=]+)=(?P<value>[^;]*);(?P<id>\S+)`)
+	s, _ := dec.One(`  Log_Level = "debug \"all\"" ;#42`)
+	fmt.Printf("%q %q %d\n", s.Key, s.Value, s.ID)
+
+	enc, _ := dec.Encoder()
+	out, _ := enc.Encode(s)
+	fmt.Println(out)
+	// Output:
+	// "log_level" "debug \"all\"" 42
+	// log_level="debug \"all\"";#42
+}
@@ -0,0 +1,264 @@
+package regextra
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Suffixes the Encoder writes for a `unit=` field, indexed by the power of the
+// unit's step: iecBytes and siBytes for unit=bytes in steps of 1024 and 1000,
+// siPrefixes for unit=si.
+var (
+	iecBytes   = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siBytes    = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	siPrefixes = []string{"", "k", "M", "G", "T", "P", "E"}
+)
+
+// unitPowers maps each prefix parseUnit reads, as unit=si spells it, to its
+// power of the step.
+var unitPowers = map[string]int{"k": 1, "K": 1, "M": 2, "G": 3, "T": 4, "P": 5, "E": 6}
+
+// unitOption returns a field's `unit=` option — "bytes" or "si" — or "" when
+// it is absent.
+func unitOption(opts map[string]string) (string, error) {
+	unit, ok := opts["unit"]
+	if !ok {
+		return "", nil
+	}
+	if unit != "bytes" && unit != "si" {
+		return "", fmt.Errorf("invalid unit %q: want bytes or si", unit)
+	}
+	return unit, nil
+}
+
+// unitStep returns the step between a `unit=` field's prefixes when encoding:
+// 1000 for unit=si, and for unit=bytes 1024 unless `unitbase=1000` asks for
+// SI byte suffixes.
+func unitStep(unit string, opts map[string]string) (int64, error) {
+	switch base, ok := opts["unitbase"]; {
+	case unit == "si" || base == "1000":
+		return 1000, nil
+	case !ok || base == "1024":
+		return 1024, nil
+	default:
+		return 0, fmt.Errorf("invalid unitbase %q: want 1000 or 1024", base)
+	}
+}
+
+// unitPrecision returns the `prec=` option: the most digits an encoded `unit=`
+// value keeps after the point, or -1 when the option is absent and the value
+// is written exactly.
+func unitPrecision(opts map[string]string) (int, error) {
+	s, ok := opts["prec"]
+	if !ok {
+		return -1, nil
+	}
+	prec, err := strconv.Atoi(s)
+	if err != nil || prec < 0 {
+		return 0, fmt.Errorf("invalid prec %q: want a non-negative count", s)
+	}
+	return prec, nil
+}
+
+// parseUnit parses value — a decimal number, optional white space, and a
+// suffix — as the exact quantity it names under unit:
+//
+//   - bytes: an optional B after a k, M, G, T, P, or E prefix, each 1000
+//     times the one before, or a Ki, Mi, Gi, Ti, Pi, or Ei prefix, each 1024
+//     times the one before; "512KiB", "1.5GB", "10M", "100". Case is
+//     ignored.
+//   - si: a k (or K), M, G, T, P, or E prefix, each 1000 times the one
+//     before; "3.2k", "10M". Case matters.
+func parseUnit(value, unit string) (*big.Rat, error) {
+	i := 0
+	if i < len(value) && (value[i] == '+' || value[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(value) && (value[i] >= '0' && value[i] <= '9' || value[i] == '.'); i++ {
+		if value[i] != '.' {
+			digits++
+		}
+	}
+	num := value[:i]
+	if digits == 0 || strings.Count(num, ".") > 1 {
+		return nil, strconv.ErrSyntax
+	}
+	suffix := strings.TrimLeft(value[i:], " \t")
+	mult, ok := unitMultiplier(suffix, unit)
+	if !ok {
+		return nil, fmt.Errorf("unknown %s suffix %q", unit, suffix)
+	}
+	r, _ := new(big.Rat).SetString(num)
+	return r.Mul(r, new(big.Rat).SetInt(mult)), nil
+}
+
+// unitMultiplier returns the quantity suffix stands for under unit (see
+// parseUnit).
+func unitMultiplier(suffix, unit string) (*big.Int, bool) {
+	step := int64(1000)
+	if unit == "bytes" {
+		suffix = strings.TrimSuffix(strings.ToUpper(suffix), "B")
+		if p, ok := strings.CutSuffix(suffix, "I"); ok && p != "" {
+			step, suffix = 1024, p
+		}
+	}
+	power := 0
+	if suffix != "" {
+		var ok bool
+		if power, ok = unitPowers[suffix]; !ok {
+			return nil, false
+		}
+	}
+	return new(big.Int).Exp(big.NewInt(step), big.NewInt(int64(power)), nil), true
+}
+
+// parseUnitInt parses value under unit for a signed integer field of
+// bitSize bits. A fractional quantity — "1.1KiB" is 1126.4 bytes — rounds to
+// the nearest whole number, halves away from zero, so a value the Encoder
+// rounded with `prec=` reads back.
+func parseUnitInt(value, unit string, bitSize int) (int64, error) {
+	n, err := parseUnitWhole(value, unit)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() || bitSize < 64 && (n.Int64() < -1<<(bitSize-1) || n.Int64() >= 1<<(bitSize-1)) {
+		return 0, strconv.ErrRange
+	}
+	return n.Int64(), nil
+}
+
+// parseUnitUint is parseUnitInt for an unsigned integer field.
+func parseUnitUint(value, unit string, bitSize int) (uint64, error) {
+	n, err := parseUnitWhole(value, unit)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() || bitSize < 64 && n.Uint64() >= 1<<bitSize {
+		return 0, strconv.ErrRange
+	}
+	return n.Uint64(), nil
+}
+
+// parseUnitWhole parses value under unit and rounds it to a whole number.
+func parseUnitWhole(value, unit string) (*big.Int, error) {
+	r, err := parseUnit(value, unit)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := new(big.Int).SetString(r.FloatString(0), 10)
+	return n, nil
+}
+
+// parseUnitFloat parses value under unit for a float field of bitSize bits,
+// to the float nearest the exact quantity.
+func parseUnitFloat(value, unit string, bitSize int) (float64, error) {
+	r, err := parseUnit(value, unit)
+	if err != nil {
+		return 0, err
+	}
+	var f float64
+	if bitSize == 32 {
+		f32, _ := r.Float32()
+		f = float64(f32)
+	} else {
+		f, _ = r.Float64()
+	}
+	if math.IsInf(f, 0) {
+		return 0, strconv.ErrRange
+	}
+	return f, nil
+}
+
+// formatUnitInt renders the integer v under a field's unit=, unitbase=, prec=,
+// and sep= options: in the largest prefix it reaches, written exactly or, with
+// prec=, rounded to that many digits after the point, trailing zeros dropped.
+func formatUnitInt(v *big.Int, unit string, opts map[string]string) (string, error) {
+	step, err := unitStep(unit, opts)
+	if err != nil {
+		return "", err
+	}
+	prec, err := unitPrecision(opts)
+	if err != nil {
+		return "", err
+	}
+	abs := new(big.Int).Abs(v)
+	div, bigStep := big.NewInt(1), big.NewInt(step)
+	power := 0
+	for ; power < len(siPrefixes)-1; power++ {
+		next := new(big.Int).Mul(div, bigStep)
+		if abs.Cmp(next) < 0 {
+			break
+		}
+		div = next
+	}
+	if prec < 0 {
+		// Exact: 1024 is 2^10, so each power of it adds at most ten decimal
+		// places; each power of 1000 adds three.
+		prec = 3 * power
+		if step == 1024 {
+			prec = 10 * power
+		}
+	}
+	s := trimFraction(new(big.Rat).SetFrac(v, div).FloatString(prec))
+	return groupDigits(s, groupSeparator(opts), 10) + unitSuffix(unit, step, power), nil
+}
+
+// formatUnitFloat is formatUnitInt for a float field of bitSize bits. Written
+// exactly, the result is the shortest that reads back as f.
+func formatUnitFloat(f float64, bitSize int, unit string, opts map[string]string) (string, error) {
+	step, err := unitStep(unit, opts)
+	if err != nil {
+		return "", err
+	}
+	prec, err := unitPrecision(opts)
+	if err != nil {
+		return "", err
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'g', -1, bitSize), nil
+	}
+	scale := 1.0
+	power := 0
+	for ; power < len(siPrefixes)-1 && math.Abs(f) >= scale*float64(step); power++ {
+		scale *= float64(step)
+	}
+	var s string
+	switch {
+	case prec >= 0:
+		s = trimFraction(strconv.FormatFloat(f/scale, 'f', prec, bitSize))
+	case step == 1000:
+		// Dividing by a power of 1000 rounds; moving the point of f's
+		// shortest form does not.
+		s = shiftDecimal(f, bitSize, -3*power)
+	default:
+		// Dividing by a power of two is exact.
+		s = strconv.FormatFloat(f/scale, 'f', -1, bitSize)
+	}
+	return groupDigits(s, groupSeparator(opts), 10) + unitSuffix(unit, step, power), nil
+}
+
+// unitSuffix returns the suffix the Encoder writes for the power of step
+// under unit.
+func unitSuffix(unit string, step int64, power int) string {
+	switch {
+	case unit == "si":
+		return siPrefixes[power]
+	case step == 1024:
+		return iecBytes[power]
+	default:
+		return siBytes[power]
+	}
+}
+
+// trimFraction drops trailing zeros after the point of the decimal s, and the
+// point itself when nothing follows it.
+func trimFraction(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
@@ -0,0 +1,238 @@
+package regextra_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	rx "github.com/jecoms/regextra"
+)
+
+func TestUnitOptions(t *testing.T) {
+	type rec struct {
+		Bytes int64   `regex:"v,unit=bytes"`
+		Size  uint32  `regex:"v,unit=bytes"`
+		F     float64 `regex:"v,unit=bytes"`
+	}
+	type siRec struct {
+		N int     `regex:"v,unit=si"`
+		F float32 `regex:"v,unit=si"`
+	}
+	dec := rx.MustCompile[rec](`(?P<v>.+)`)
+	siDec := rx.MustCompile[siRec](`(?P<v>.+)`)
+	tests := []struct {
+		input string
+		want  rec
+	}{
+		{"100", rec{100, 100, 100}},
+		{"100B", rec{100, 100, 100}},
+		{"512KiB", rec{524288, 524288, 524288}},
+		{"512kib", rec{524288, 524288, 524288}},
+		{"1.5GB", rec{1500000000, 1500000000, 1.5e9}},
+		{"1.5 gb", rec{1500000000, 1500000000, 1.5e9}},
+		{"10M", rec{10000000, 10000000, 1e7}},
+		{"2Ki", rec{2048, 2048, 2048}},
+		{"1.1KiB", rec{1126, 1126, 1126.4}},
+		{"0.5b", rec{1, 1, 0.5}},
+		{".25kB", rec{250, 250, 250}},
+	}
+	for _, tt := range tests {
+		got, err := dec.One(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("unit=bytes One(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+		}
+	}
+
+	siTests := []struct {
+		input string
+		want  siRec
+	}{
+		{"3.2k", siRec{3200, 3200}},
+		{"3.2K", siRec{3200, 3200}},
+		{"-7M", siRec{-7000000, -7e6}},
+		{"1.25G", siRec{1250000000, 1.25e9}},
+		{"42", siRec{42, 42}},
+	}
+	for _, tt := range siTests {
+		got, err := siDec.One(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("unit=si One(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"KiB", "1.2.3", "1XB", "1iB", "1 KiBs", "-1"} {
+		var de *rx.DecodeError
+		if _, err := dec.One(input); !errors.As(err, &de) {
+			t.Errorf("unit=bytes One(%q) error = %v, want a DecodeError", input, err)
+		}
+	}
+	for _, input := range []string{"1m", "1kB", "5Mi"} {
+		if _, err := siDec.One(input); err == nil {
+			t.Errorf("unit=si One(%q) succeeded, want an error", input)
+		}
+	}
+
+	// Range is checked against the field, after rounding.
+	type small struct {
+		A int8 `regex:"a,unit=si"`
+	}
+	if _, err := rx.MustCompile[small](`(?P<a>.+)`).One("0.128k"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("One(0.128k) into int8 error = %v, want ErrRange", err)
+	}
+}
+
+func TestUnitOptions_encode(t *testing.T) {
+	type rec struct {
+		IEC   int64   `regex:"iec,unit=bytes"`
+		SI    uint64  `regex:"si,unit=bytes,unitbase=1000"`
+		Round int     `regex:"round,unit=bytes,prec=1"`
+		Rate  float64 `regex:"rate,unit=si"`
+		Grp   float64 `regex:"grp,unit=si,sep=,"`
+		Small float32 `regex:"small,unit=si,prec=2"`
+	}
+	dec := rx.MustCompile[rec](`(?P<iec>\S+) (?P<si>\S+) (?P<round>\S+) (?P<rate>\S+) (?P<grp>\S+) (?P<small>\S+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	tests := []struct {
+		v    rec
+		want string
+	}{
+		{rec{1536, 1500000, 1234567, 3200, 1234567000, 0.5}, "1.5KiB 1.5MB 1.2MiB 3.2k 1.234567G 0.5"},
+		{rec{-1023, 999, 1023, -0.001, 12, 1234.5}, "-1023B 999B 1023B -0.001 12 1.23k"},
+		{rec{1 << 62, math.MaxUint64, 0, 1e20, 1.5e22, 0}, "4EiB 18.446744073709551615EB 0B 100E 15,000E 0"},
+		{rec{1234567, 0, 0, 1.5e-9, 0, 0}, "1.17737483978271484375MiB 0B 0B 0.0000000015 0 0"},
+	}
+	for _, tt := range tests {
+		s, err := enc.EncodeStrict(tt.v)
+		if err != nil || s != tt.want {
+			t.Errorf("EncodeStrict(%+v) = %q, %v; want %q", tt.v, s, err, tt.want)
+			continue
+		}
+		// Exact encodings read back exactly; prec= ones read back rounded.
+		got, err := dec.One(s)
+		if err != nil || got.IEC != tt.v.IEC || got.SI != tt.v.SI || got.Rate != tt.v.Rate || got.Grp != tt.v.Grp {
+			t.Errorf("One(%q) = %+v, %v; want %+v", s, got, err, tt.v)
+		}
+	}
+}
+
+func TestUnitOptions_floatRoundTrip(t *testing.T) {
+	type rec struct {
+		Bytes float64 `regex:"b,unit=bytes"`
+		SI    float64 `regex:"s,unit=si"`
+		F32   float32 `regex:"f,unit=si"`
+	}
+	dec := rx.MustCompile[rec](`(?P<b>\S+) (?P<s>\S+) (?P<f>\S+)`)
+	enc, err := dec.Encoder()
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+	for _, f := range []float64{0.1, 1.1, 1023.9, 1024.1, 0.1 + 0.2, 123456.789, 9.999e17, math.MaxFloat64 / 3} {
+		want := rec{f, f, float32(f)}
+		if math.IsInf(float64(want.F32), 0) {
+			want.F32 = 1
+		}
+		s, err := enc.Encode(want)
+		if err != nil {
+			t.Fatalf("Encode(%v) error = %v", f, err)
+		}
+		if got, err := dec.One(s); err != nil || got != want {
+			t.Errorf("One(Encode(%v)) = %+v, %v via %q", f, got, err, s)
+		}
+	}
+}
+
+func TestUnitOptions_compile(t *testing.T) {
+	tests := []struct {
+		name string
+		try  func() error
+		want string
+	}{
+		{"on string", func() error {
+			_, err := rx.Compile[struct {
+				A string `regex:"a,unit=bytes"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `unit=` option but is string, not an integer or float"},
+		{"unknown unit", func() error {
+			_, err := rx.Compile[struct {
+				A int `regex:"a,unit=bits"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, `field A invalid unit "bits": want bytes or si`},
+		{"with base", func() error {
+			_, err := rx.Compile[struct {
+				A int `regex:"a,unit=si,base=16"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `unit=` option, which does not combine with `base`"},
+		{"prec without unit", func() error {
+			_, err := rx.Compile[struct {
+				A float64 `regex:"a,prec=2"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `prec=` option but no `unit=`"},
+		{"bad prec", func() error {
+			_, err := rx.Compile[struct {
+				A float64 `regex:"a,unit=si,prec=-1"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, `field A invalid prec "-1": want a non-negative count`},
+		{"unitbase on si", func() error {
+			_, err := rx.Compile[struct {
+				A int `regex:"a,unit=si,unitbase=1000"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, "field A has `unitbase=` option, which applies only to `unit=bytes`"},
+		{"bad unitbase", func() error {
+			_, err := rx.Compile[struct {
+				A int `regex:"a,unit=bytes,unitbase=2"`
+			}](`(?P<a>\w+)`)
+			return err
+		}, `field A invalid unitbase "2": want 1000 or 1024`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.try()
+			if !errors.Is(err, rx.ErrInvalidStruct) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() error = %v, want ErrInvalidStruct containing %q", err, tt.want)
+			}
+		})
+	}
+
+	type strict struct {
+		Size int64   `regex:"size,unit=bytes"`
+		Rate float64 `regex:"rate,unit=si"`
+	}
+	if _, err := rx.CompileWith[strict](`(?P<size>[\d.]+ ?[kKmMgG]i?[bB]?) (?P<rate>[\d.]+[kM]?)`, rx.StrictTypes()); err != nil {
+		t.Errorf("CompileWith(StrictTypes) error = %v", err)
+	}
+	if _, err := rx.CompileWith[strict](`(?P<size>\d+[kKmMgG]) (?P<rate>[\d.]+[kmM])`, rx.StrictTypes()); err == nil || !strings.Contains(err.Error(), `can match "m"`) {
+		t.Errorf("CompileWith(StrictTypes) error = %v, want one naming \"m\"", err)
+	}
+}
+
+func Example_units() {
+	type Transfer struct {
+		Size  int64   `regex:"size,unit=bytes"`
+		Limit uint64  `regex:"limit,unit=bytes,unitbase=1000"`
+		Rate  float64 `regex:"rate,unit=si,prec=1"`
+	}
+	dec := rx.MustCompile[Transfer](`size=(?P<size>\S+) limit=(?P<limit>\S+) rate=(?P<rate>[\d.]+k?)/s`)
+	t, _ := dec.One("size=1.5KiB limit=10M rate=3.2k/s")
+	fmt.Printf("%+v\n", t)
+
+	t.Size *= 1024
+	t.Rate = 12345
+	enc, _ := dec.Encoder()
+	out, _ := enc.Encode(t)
+	fmt.Println(out)
+	// Output:
+	// {Size:1536 Limit:10000000 Rate:3200}
+	// size=1.5MiB limit=10MB rate=12.3k/s
+}
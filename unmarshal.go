@@ -31,11 +31,24 @@ var timeLayouts = []string{
 	time.TimeOnly, // "15:04:05"
 }
 
-// parseTime tries each layout in timeLayouts and returns the first success.
-func parseTime(value string) (time.Time, error) {
+// parseInLocation is [time.ParseInLocation], with a nil loc meaning UTC as in
+// [time.Parse], that also reads the epoch pseudo-layouts (see epochLayouts).
+func parseInLocation(layout, value string, loc *time.Location) (time.Time, error) {
+	if unit, ok := epochLayouts[layout]; ok {
+		return parseEpoch(value, unit, loc)
+	}
+	if loc == nil {
+		return time.Parse(layout, value)
+	}
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// parseTime tries each layout in timeLayouts and returns the first success,
+// reading a value without a zone offset in loc (UTC when nil).
+func parseTime(value string, loc *time.Location) (time.Time, error) {
 	var firstErr error
 	for _, layout := range timeLayouts {
-		t, err := time.Parse(layout, value)
+		t, err := parseInLocation(layout, value, loc)
 		if err == nil {
 			return t, nil
 		}
@@ -62,8 +75,14 @@ func parseTime(value string) (time.Time, error) {
 // time-parsing error) and is reachable via [errors.Is]/[errors.As] through
 // Unwrap. No match is not a DecodeError — [Unmarshal]/[UnmarshalAll] return nil
 // and [Decoder.One] returns [ErrNoMatch] in that case.
+//
+// Offset, End, Match, Line, and Column locate the bad value in the input, so a
+// tool can underline the exact span rather than just name the field. They are
+// not part of the Error message.
 type DecodeError struct {
-	// Field is the destination struct field name.
+	// Field is the destination struct field name — its Go selector path (e.g.
+	// "Client.Port") when the field sits inside an embedded or
+	// `prefix`-flattened struct.
 	Field string
 	// Group is the capture group the value was read from: the field's
 	// `regex:"..."` tag name when set, otherwise the declared group whose name
@@ -83,6 +102,21 @@ type DecodeError struct {
 	Type string
 	// Err is the underlying conversion error.
 	Err error
+	// Offset and End are the byte span of Value in the decoded target string —
+	// for [Decoder.Scan], in the record, whose own position is on the
+	// enclosing [ScanError]. For a slice or array field it is the failing
+	// element's span. When Value was not read from the target (a substituted
+	// default), it is the span of the participating empty group, or of the
+	// whole match when no occurrence of the group participated.
+	Offset, End int
+	// Match is the 0-based index of the failing match among the target's
+	// matches: always 0 for [Unmarshal] and [Decoder.One], the match's position
+	// for [UnmarshalAll], [Decoder.All], and [Decoder.Iter], and its position
+	// within the record for [Decoder.Scan].
+	Match int
+	// Line and Column locate Offset as a 1-based line number and 1-based byte
+	// column in the decoded target, for editor-style reports.
+	Line, Column int
 }
 
 // Error implements the error interface. The calling entrypoint prepends its
@@ -119,13 +153,25 @@ func (e *DecodeError) Unwrap() error { return e.Err }
 // presence check. Like MissingNamedGroupsError, there is no underlying cause to
 // unwrap — the absent value is the payload.
 type RequiredGroupError struct {
-	// Field is the destination struct field name.
+	// Field is the destination struct field name, as a selector path for a
+	// nested field (see [DecodeError].Field).
 	Field string
 	// Group is the capture group the required value was expected from: the
 	// field's `regex:"..."` tag name when set, otherwise the declared group
 	// whose name matches the field name. It is empty only when a `required`
 	// field maps to no declared group at all.
 	Group string
+	// Offset and End are the byte span in the decoded target the value was
+	// missing from: the participating empty group, or the whole match when no
+	// occurrence of the group participated. Match, Line, and Column locate it
+	// as on [DecodeError].
+	Offset, End int
+	// Match is the 0-based index of the failing match among the target's
+	// matches (see [DecodeError].Match).
+	Match int
+	// Line and Column locate Offset as a 1-based line number and 1-based byte
+	// column in the decoded target.
+	Line, Column int
 }
 
 // Error implements the error interface. The calling entrypoint prepends its own
@@ -140,6 +186,47 @@ func (e *RequiredGroupError) Error() string {
 	return fmt.Sprintf("field %s: required group %q produced no value", e.Field, e.Group)
 }
 
+// DecodeErrors reports every field that failed to decode for one match. It is
+// returned (wrapped with the calling entrypoint's prefix) in place of a single
+// [DecodeError] or [RequiredGroupError] when a decode runs with
+// [AggregateErrors], and only when at least one field failed.
+//
+// Like the result of [errors.Join], it exposes its elements through
+// Unwrap() []error, so [errors.Is]/[errors.As] see through it: errors.As finds
+// the first *DecodeError or *RequiredGroupError in field order. To report each
+// failure, recover the DecodeErrors itself and range over Errors:
+//
+//	var errs *regextra.DecodeErrors
+//	if errors.As(err, &errs) {
+//	    for _, e := range errs.Errors {
+//	        log.Print(e)
+//	    }
+//	}
+type DecodeErrors struct {
+	// Errors holds one *DecodeError or *RequiredGroupError per failing field,
+	// in the order the fields are declared on the struct.
+	Errors []error
+}
+
+// Error implements the error interface, joining the elements' messages with
+// "; ". The calling entrypoint prepends its own `regextra.<Entrypoint>:`
+// prefix when wrapping. When Errors is empty (only reachable by constructing
+// the value directly) it reports "no decode errors".
+func (e *DecodeErrors) Error() string {
+	if len(e.Errors) == 0 {
+		return "no decode errors"
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the per-field errors so [errors.Is]/[errors.As] can reach
+// each of them.
+func (e *DecodeErrors) Unwrap() []error { return e.Errors }
+
 // Unmarshal extracts named capture groups from the target string and assigns them
 // to the corresponding fields in the provided struct pointer.
 //
@@ -150,7 +237,14 @@ func (e *RequiredGroupError) Error() string {
 //   - Supports type conversion for string, bool, all int/uint widths,
 //     float32/float64, time.Time, time.Duration, and single-level pointers
 //     to any of these; types implementing [RegexUnmarshaler] convert themselves
+//   - A slice or array of any of these collects every participating
+//     occurrence of its group (a pattern may reuse a group name) as an
+//     element — every iteration, for an occurrence inside a repetition — and
+//     the `split=<sep>` option further splits each value
 //   - Unexported fields are ignored
+//   - Untagged embedded structs are flattened, and a struct field tagged
+//     `regex:"name,prefix"` is filled from groups named `name_<field>` (see the
+//     package doc's "Tag grammar" section)
 //   - A group that did not participate in the match (e.g. an optional group),
 //     or that matched an empty span, leaves the field unchanged — unless the
 //     field has a `default=` tag option, which substitutes instead
@@ -164,9 +258,9 @@ func (e *RequiredGroupError) Error() string {
 // Returns an error if:
 //   - v is not a pointer to a struct
 //   - Type conversion fails on a matched group
-//   - A matched field is a nested struct, slice, or map: these are not
-//     flattened, so a group bound to one yields an "unsupported field type"
-//     error (unless the type implements [RegexUnmarshaler] or
+//   - A matched field is a nested struct without the `prefix` flag or a map:
+//     a group bound to one yields an "unsupported field type" error
+//     (unless the type implements [RegexUnmarshaler] or
 //     encoding.TextUnmarshaler, which convert themselves)
 //
 // Example:
@@ -180,15 +274,43 @@ func (e *RequiredGroupError) Error() string {
 //	err := regextra.Unmarshal(re, "Alice is 30", &person)
 //	// person.Name = "Alice", person.Age = 30
 func Unmarshal(re *regexp.Regexp, target string, v any) error {
+	if err := unmarshal(re, target, v, &defaultDecoderConfig); err != nil {
+		return fmt.Errorf("regextra.Unmarshal: %w", err)
+	}
+	return nil
+}
+
+// UnmarshalWith is like [Unmarshal] but applies opts to the decode — for
+// example [AggregateErrors], to report every failing field at once. Every
+// [DecoderOption] applies as it does to a [Decoder], except that validation is
+// always lenient. [FullMatch] and [LongestMatch] match through a copy of re
+// built for the call; compile a Decoder with [CompileWith] to pay for that
+// once.
+func UnmarshalWith(re *regexp.Regexp, target string, v any, opts ...DecoderOption) error {
+	cfg := newDecoderConfig(opts)
+	if err := unmarshal(re, target, v, &cfg); err != nil {
+		return fmt.Errorf("regextra.UnmarshalWith: %w", err)
+	}
+	return nil
+}
+
+// defaultDecoderConfig is the configuration [Unmarshal] and [UnmarshalAll]
+// decode under. It is shared and never modified, so those calls pass it by
+// pointer rather than building a config that would escape on every call.
+var defaultDecoderConfig = newDecoderConfig(nil)
+
+// unmarshal implements [Unmarshal] and [UnmarshalWith], which prefix its
+// errors with their own names.
+func unmarshal(re *regexp.Regexp, target string, v any, cfg *DecoderConfig) error {
 	// Get reflection value and validate it's a pointer to struct
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return fmt.Errorf("regextra.Unmarshal: requires a non-nil pointer to a struct, got %T", v)
+		return fmt.Errorf("requires a non-nil pointer to a struct, got %T", v)
 	}
 
 	elem := rv.Elem()
 	if elem.Kind() != reflect.Struct {
-		return fmt.Errorf("regextra.Unmarshal: requires a pointer to a struct, got pointer to %s", elem.Kind())
+		return fmt.Errorf("requires a pointer to a struct, got pointer to %s", elem.Kind())
 	}
 
 	// Find the match. The Index variant distinguishes non-participating group
@@ -204,12 +326,22 @@ func Unmarshal(re *regexp.Regexp, target string, v any) error {
 	// Unmarshal stays best-effort rather than erroring on undeclared groups or
 	// misplaced tag options. buildDecodePlan never returns an error when
 	// strict=false; the check is kept for forward-safety.
-	fields, err := buildDecodePlan(elem.Type(), re, false)
+	fields, err := buildDecodePlan(elem.Type(), re, false, cfg)
 	if err != nil {
-		return fmt.Errorf("regextra.Unmarshal: %w", err)
+		return err
 	}
-	if err := runDecodePlan(re, fields, elem, target, matches); err != nil {
-		return fmt.Errorf("regextra.Unmarshal: %w", err)
+	// A slice field whose group sits inside a repetition decodes through an
+	// expanded copy of re (see expandRepeats), which finds the same match; the
+	// FullMatch and LongestMatch options match through a copy too, which may
+	// find a different match or none (see buildMatcher).
+	if matcher, expanded := buildMatcher(re, re.String(), fields, cfg); matcher != re {
+		re, fields = matcher, expanded
+		if matches = re.FindStringSubmatchIndex(target); matches == nil {
+			return nil
+		}
+	}
+	if err := runDecodePlan(re, fields, elem, target, matches, 0, cfg); err != nil {
+		return err
 	}
 	return nil
 }
@@ -266,13 +398,20 @@ func UnmarshalAll(re *regexp.Regexp, target string, v any) error {
 	// computed once and reused, and each match decodes in place — no reflect.New
 	// / reflect.Append copy and no map churn per match. strict=false keeps the
 	// lenient Unmarshal posture (see Unmarshal); the error is never non-nil here.
-	fields, err := buildDecodePlan(sliceElemType, re, false)
+	cfg := &defaultDecoderConfig
+	fields, err := buildDecodePlan(sliceElemType, re, false, cfg)
 	if err != nil {
 		return fmt.Errorf("regextra.UnmarshalAll: %w", err)
 	}
+	// A slice field whose group sits inside a repetition decodes through an
+	// expanded copy of re, as in Unmarshal (see buildMatcher).
+	if matcher, expanded := buildMatcher(re, re.String(), fields, cfg); matcher != re {
+		re, fields = matcher, expanded
+		allMatches = re.FindAllStringSubmatchIndex(target, -1)
+	}
 	newSlice := reflect.MakeSlice(elem.Type(), len(allMatches), len(allMatches))
 	for idx, matches := range allMatches {
-		if err := runDecodePlan(re, fields, newSlice.Index(idx), target, matches); err != nil {
+		if err := runDecodePlan(re, fields, newSlice.Index(idx), target, matches, idx, cfg); err != nil {
 			return fmt.Errorf("regextra.UnmarshalAll: match %d: %w", idx, err)
 		}
 	}
@@ -282,6 +421,29 @@ func UnmarshalAll(re *regexp.Regexp, target string, v any) error {
 	return nil
 }
 
+// fieldTag is the parsed form of one field's `regex:"..."` struct tag, as
+// returned by parseFieldTag.
+type fieldTag struct {
+	// name is the group name (the first comma-separated piece), or "" when the
+	// tag is absent or names no group.
+	name string
+	// opts holds every key=value option. Nil if the tag has none.
+	opts map[string]string
+	// required is set by the `required` flag.
+	required bool
+	// prefix is set by the `prefix` flag: a struct-typed field is flattened,
+	// its own fields resolving against groups named `<name>_<field>`.
+	prefix bool
+	// omitempty is set by the `omitempty` flag: an encode-side marker that a
+	// zero value leaves the field's optional section out. Decoding ignores it.
+	omitempty bool
+	// skip is set by the bare `-` tag: the field is excluded entirely.
+	skip bool
+	// transforms holds the value transform options (see valueTransform) in
+	// tag order. Nil if the tag has none.
+	transforms []valueTransform
+}
+
 // parseFieldTag parses a `regex:"name,key=value,key=value"` struct tag into
 // the group name and an options map. The grammar is JSON-encoding-style: the
 // first comma-separated piece is the name; each subsequent piece is a
@@ -291,72 +453,131 @@ func UnmarshalAll(re *regexp.Regexp, target string, v any) error {
 //   - default — value substituted when the named group is not declared on the
 //     regex or its match is empty.
 //   - layout  — for time.Time fields only: a single time.Parse layout used
-//     instead of the default fallback list.
+//     instead of the default fallback list, or an epoch pseudo-layout (see
+//     epochLayouts).
+//   - layouts — for time.Time fields only: several layouts, separated by |,
+//     tried in order (see parseTimeField).
+//   - tz      — for time.Time fields only: the IANA zone a value without its
+//     own offset is read in, instead of the DefaultLocation.
+//   - split   — for slice and array fields only: the separator each captured
+//     value is split on (see splitSeparator for the spellings of comma and
+//     whitespace).
+//   - trimprefix, trimsuffix — value transforms: the text removed from the
+//     start or end of the matched value before it is converted.
+//   - replace — value transform: `<old>:<new>`, replacing every old in the
+//     matched value with new before it is converted (see parseTransform).
+//   - base    — for integer fields only: the base the value is written in.
+//   - sep     — for integer and float fields only: a digit-grouping
+//     separator, removed before parsing (spelled as split is).
+//   - unit    — for integer and float fields only: bytes or si, reading a
+//     quantity with a unit suffix such as 512KiB or 3.2k; prec and unitbase
+//     shape how the Encoder writes it.
+//
+// Three flags (lone tokens, no `=`) are recognized:
+//   - required — marks the field's group as mandatory: decode fails with a
+//     *[RequiredGroupError] when the group does not participate in a match or
+//     matches an empty span and no `default=` supplies a value. It was the
+//     first recognized lone-token flag (the slot the forward-compat rules below
+//     reserved).
+//   - prefix — on a struct (or pointer-to-struct) field, flattens the nested
+//     struct: each of its fields resolves against the group named
+//     `<name>_<field name or tag>`, where name is the tag's name or, when
+//     empty, the field's own name.
+//   - omitempty — encode side only: inside a `?`-quantified section of the
+//     pattern, a zero value counts as absent, so [Encoder.Encode] drops the
+//     section. Decoding ignores it.
 //
-// The `required` flag (a lone token, no `=`) marks the field's group as
-// mandatory: decode fails with a *[RequiredGroupError] when the group does not
-// participate in a match or matches an empty span and no `default=` supplies a
-// value. It is the first recognized lone-token flag (the slot the forward-compat
-// rules below reserved).
+// as are four value transforms, `trim`, `lower`, `upper`, and `unquote`, and
+// `percent`, which reads a float field as a percentage. percent is recorded
+// in opts, under its own name with an empty value, since the conversions that
+// honor it see only opts.
+// Transforms, lone or key=value, are also collected in tag order into
+// transforms, since they apply in the order written (see applyTransforms).
 //
 // Forward-compat rules (locked in as v1 contract — see the package doc's
 // "Tag grammar" section for the full statement and rationale):
 //   - Unknown key=value pairs are preserved in the returned map so future
 //     option additions don't need to touch the parser; adding a new option
 //     key is therefore not a breaking change.
-//   - Lone tokens without `=` other than the recognized `required` flag are
-//     silently ignored today; the slot remains reserved for future flag-style
-//     options, so callers must not rely on an unrecognized lone token staying
-//     inert.
+//   - Lone tokens without `=` other than the recognized flags are silently
+//     ignored today; the slot remains reserved for future flag-style options,
+//     so callers must not rely on an unrecognized lone token staying inert.
 //
 // The two forms differ:
-//   - `regex:""` (no tag) signals "no name", returning ("", nil, false); the
+//   - `regex:""` (no tag) signals "no name", returning a zero fieldTag; the
 //     caller falls back to matching the field's own name against a group.
-//   - `regex:"-"` signals "exclude this field", returning ("", nil, true); the
-//     caller excludes the field entirely, never attempting a name fallback. This
-//     mirrors the `-` convention in encoding/json, encoding/xml, and
-//     gopkg.in/yaml. Only the bare `-` tag excludes; a leading `-` followed by
-//     options (e.g. `regex:"-,default=x"`) parses `-` as the group name, which
-//     matches no group since group names are Go identifiers.
-func parseFieldTag(field reflect.StructField) (name string, opts map[string]string, required, skip bool) {
-	tag := field.Tag.Get("regex")
+//   - `regex:"-"` signals "exclude this field", returning a fieldTag with skip
+//     set; the caller excludes the field entirely, never attempting a name
+//     fallback. This mirrors the `-` convention in encoding/json,
+//     encoding/xml, and gopkg.in/yaml. Only the bare `-` tag excludes; a
+//     leading `-` followed by options (e.g. `regex:"-,default=x"`) parses `-`
+//     as the group name, which matches no group since group names are Go
+//     identifiers.
+//
+// key is the struct tag key to read: "regex" unless the [TagKey] option set
+// another.
+func parseFieldTag(field reflect.StructField, key string) fieldTag {
+	tag := field.Tag.Get(key)
 	if tag == "-" {
-		return "", nil, false, true
+		return fieldTag{skip: true}
 	}
 	if tag == "" {
-		return "", nil, false, false
+		return fieldTag{}
 	}
 	parts := strings.Split(tag, ",")
-	name = strings.TrimSpace(parts[0])
+	ft := fieldTag{name: strings.TrimSpace(parts[0])}
 	if len(parts) == 1 {
-		return name, nil, false, false
+		return ft
 	}
 	for _, p := range parts[1:] {
 		p = strings.TrimSpace(p)
 		k, v, ok := strings.Cut(p, "=")
 		if !ok {
-			// No '=': a lone token. `required` is the one recognized flag; it
-			// marks the field's group mandatory (enforced in runDecodePlan).
-			// Any other lone token — including an empty piece from a doubled,
-			// leading, or trailing comma — is silently ignored to keep the
-			// parser forward-compatible. An empty piece needs no separate guard:
-			// strings.Cut("", "=") returns ok=false, so it lands here too.
-			if k == "required" {
-				required = true
+			// No '=': a lone token. `required`, `prefix`, and `omitempty` are
+			// the recognized flags; required marks the field's group mandatory
+			// (enforced in runDecodePlan), prefix flattens a nested struct
+			// (walked in visitStructFields), and omitempty drops an empty
+			// field's optional section on encode (sectionPresent); percent
+			// is recorded in opts for the float conversions. trim,
+			// lower, upper, and unquote are value transforms, collected in
+			// order by parseTransform. Any other lone token — including an empty
+			// piece from a doubled, leading, or trailing comma — is silently
+			// ignored to keep the parser forward-compatible. An empty piece
+			// needs no separate guard: strings.Cut("", "=") returns ok=false,
+			// so it lands here too.
+			switch k {
+			case "required":
+				ft.required = true
+			case "prefix":
+				ft.prefix = true
+			case "omitempty":
+				ft.omitempty = true
+			case "percent":
+				if ft.opts == nil {
+					ft.opts = make(map[string]string, len(parts)-1)
+				}
+				ft.opts[k] = ""
+			}
+			if t, ok := parseTransform(k, "", false); ok {
+				ft.transforms = append(ft.transforms, t)
 			}
 			continue
 		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if t, ok := parseTransform(k, v, true); ok {
+			ft.transforms = append(ft.transforms, t)
+		}
 		// Allocate the options map lazily — only a key=value pair populates it.
 		// A field with just a lone flag (e.g. `name,required`) keeps opts nil,
 		// matching the no-options case (parts==1) and avoiding a per-call,
 		// per-field empty-map allocation on the Unmarshal hot path. Consumers
 		// already treat nil opts as "no options" (nil-map reads are zero-value).
-		if opts == nil {
-			opts = make(map[string]string, len(parts)-1)
+		if ft.opts == nil {
+			ft.opts = make(map[string]string, len(parts)-1)
 		}
-		opts[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		ft.opts[k] = v
 	}
-	return name, opts, required, false
+	return ft
 }
 
 // resolveGroupValue decides what a field receives given its group's raw match
@@ -385,6 +606,126 @@ func resolveGroupValue(value string, found bool, opts map[string]string) (string
 	return "", false
 }
 
+// splitSeparator returns the separator a `split=` option names, and whether the
+// option is set. Because the tag grammar itself splits on commas, a comma
+// separator is spelled `split=,` (which parses as an empty value followed by an
+// ignored empty piece) or `split=comma`; whitespace, which the parser trims, is
+// spelled `split=space` or `split=tab`. Any other value is used literally.
+func splitSeparator(opts map[string]string) (string, bool) {
+	return separatorOption(opts, "split")
+}
+
+// separatorOption returns the separator the option key names, spelled as
+// splitSeparator describes, and whether the option is set.
+func separatorOption(opts map[string]string, key string) (string, bool) {
+	sep, ok := opts[key]
+	if !ok {
+		return "", false
+	}
+	if sep == "" {
+		return ",", true
+	}
+	return separatorWord(sep), true
+}
+
+// separatorWord returns the text a separator spelled as a word names — comma,
+// space, or tab, which the tag grammar cannot hold literally — or s itself.
+func separatorWord(s string) string {
+	switch s {
+	case "comma":
+		return ","
+	case "space":
+		return " "
+	case "tab":
+		return "\t"
+	}
+	return s
+}
+
+// setCollectionValue sets a slice or array field (see isCollectionType) from
+// values, one element per value, each converted by setFieldValue with the
+// field's opts (so `layout=` applies per element) and conv, the converter
+// resolved for the element type. With a `split=` option each
+// value is first split on the separator; empty pieces are dropped, matching the
+// "empty = absent" contract for whole values. transforms then apply to each
+// element, and an element they empty is dropped too. A slice is replaced outright; an
+// array is zeroed and filled from the front, and more values than it has
+// elements is an error. On failure it returns the offending value alongside
+// the error, for DecodeError.Value, and its byte offset in the decoded target:
+// offsets parallels values (a split piece is offset within its value), and an
+// entry of -1, or a nil offsets, means the value is not from the target.
+func setCollectionValue(field reflect.Value, values []string, offsets []int, opts map[string]string, transforms []valueTransform, conv fieldConverter, cfg *DecoderConfig) (string, int, error) {
+	if sep, ok := splitSeparator(opts); ok {
+		var parts []string
+		var partOffsets []int
+		for i, v := range values {
+			offset := elementOffset(offsets, i)
+			for {
+				p, rest, more := strings.Cut(v, sep)
+				if p != "" {
+					parts = append(parts, p)
+					partOffsets = append(partOffsets, offset)
+				}
+				if !more {
+					break
+				}
+				if offset >= 0 {
+					offset += len(p) + len(sep)
+				}
+				v = rest
+			}
+		}
+		values, offsets = parts, partOffsets
+	}
+
+	// Transformed elements are converted, but an error still reports the
+	// element as matched, so raw keeps it.
+	raw := values
+	if len(transforms) > 0 {
+		var kept, keptRaw []string
+		var keptOffsets []int
+		for i, v := range values {
+			t, err := applyTransforms(v, transforms)
+			if err != nil {
+				return v, elementOffset(offsets, i), fmt.Errorf("element %d: %w", len(kept), err)
+			}
+			if t == "" {
+				continue
+			}
+			kept = append(kept, t)
+			keptRaw = append(keptRaw, v)
+			keptOffsets = append(keptOffsets, elementOffset(offsets, i))
+		}
+		values, raw, offsets = kept, keptRaw, keptOffsets
+	}
+
+	var out reflect.Value
+	if field.Kind() == reflect.Array {
+		if len(values) > field.Len() {
+			return raw[field.Len()], elementOffset(offsets, field.Len()), fmt.Errorf("%d values do not fit in %s", len(values), field.Type())
+		}
+		out = reflect.New(field.Type()).Elem()
+	} else {
+		out = reflect.MakeSlice(field.Type(), len(values), len(values))
+	}
+	for i, v := range values {
+		if err := setFieldValue(out.Index(i), v, opts, conv, cfg); err != nil {
+			return raw[i], elementOffset(offsets, i), fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	field.Set(out)
+	return "", -1, nil
+}
+
+// elementOffset returns offsets[i], or -1 when offsets is too short to hold
+// it — as for a nil offsets slice.
+func elementOffset(offsets []int, i int) int {
+	if i < len(offsets) {
+		return offsets[i]
+	}
+	return -1
+}
+
 // RegexUnmarshaler is the interface implemented by types that know how to
 // initialize themselves from a regex group's matched string. It mirrors
 // [encoding.TextUnmarshaler] for the regextra unmarshal path: when a
@@ -423,8 +764,20 @@ var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem(
 
 // setFieldValue sets the field value with appropriate type conversion.
 // `opts` carries per-field tag options parsed from `regex:"name,key=value,..."`.
-// Currently consulted: `layout` (for time.Time fields). Pass nil for no opts.
-func setFieldValue(field reflect.Value, value string, opts map[string]string) error {
+// Currently consulted: `layout` (for time.Time fields), `base`, `sep`, and
+// `unit` (for integers), and `sep`, `percent`, and `unit` (for floats). Pass
+// nil for no opts.
+// conv is the registered converter resolved for the field's type (see
+// DecoderConfig.resolveConverter). cfg carries the decode-wide options: its
+// Location reads time.Time values without a zone offset.
+func setFieldValue(field reflect.Value, value string, opts map[string]string, conv fieldConverter, cfg *DecoderConfig) error {
+	// A converter registered for the field's exact type (see Converters) is
+	// the caller's explicit choice for that type, so it comes before every
+	// step below — the type's own methods included.
+	if conv.decode != nil && conv.derefs == 0 {
+		return setConverted(field, value, opts, conv.decode)
+	}
+
 	// 0. Pointer fields: allocate the pointee if nil, then either dispatch
 	//    on the pointer's own RegexUnmarshaler (the common case for
 	//    pointer-receiver methods) or recurse into the pointee for the
@@ -438,7 +791,8 @@ func setFieldValue(field reflect.Value, value string, opts map[string]string) er
 		if u, ok := field.Interface().(RegexUnmarshaler); ok {
 			return u.UnmarshalRegex(value)
 		}
-		return setFieldValue(field.Elem(), value, opts)
+		conv.derefs--
+		return setFieldValue(field.Elem(), value, opts, conv, cfg)
 	}
 
 	// 1. RegexUnmarshaler comes first for non-pointer fields — caller-defined
@@ -473,20 +827,12 @@ func setFieldValue(field reflect.Value, value string, opts map[string]string) er
 	//    Kind is reflect.Int64.
 	switch field.Type() {
 	case timeTimeType:
-		var t time.Time
-		var err error
-		if layout, ok := opts["layout"]; ok && layout != "" {
-			// Caller-supplied layout wins exclusively — no fallback list,
-			// because if you specified a layout you want exactly that one.
-			t, err = time.Parse(layout, value)
-			if err != nil {
-				return fmt.Errorf("cannot convert %q to time.Time using layout %q: %w", value, layout, err)
-			}
-		} else {
-			t, err = parseTime(value)
-			if err != nil {
-				return fmt.Errorf("cannot convert %q to time.Time: %w", value, err)
-			}
+		// A caller-supplied layout wins exclusively — no fallback list,
+		// because if you specified a layout you want exactly that one (see
+		// parseTimeField).
+		t, err := parseTimeField(value, opts, cfg)
+		if err != nil {
+			return err
 		}
 		field.Set(reflect.ValueOf(t))
 		return nil
@@ -534,7 +880,7 @@ func setFieldValue(field reflect.Value, value string, opts map[string]string) er
 		// Parse at the field's actual bit width so out-of-range values error
 		// instead of silently truncating on SetInt (same approach as
 		// encoding/json).
-		intVal, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		intVal, err := parseIntOption(value, opts, field.Type().Bits())
 		if err != nil {
 			return fmt.Errorf("cannot convert %q to %s: %w", value, field.Type(), err)
 		}
@@ -542,7 +888,7 @@ func setFieldValue(field reflect.Value, value string, opts map[string]string) er
 		return nil
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		uintVal, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		uintVal, err := parseUintOption(value, opts, field.Type().Bits())
 		if err != nil {
 			return fmt.Errorf("cannot convert %q to %s: %w", value, field.Type(), err)
 		}
@@ -550,7 +896,7 @@ func setFieldValue(field reflect.Value, value string, opts map[string]string) er
 		return nil
 
 	case reflect.Float32, reflect.Float64:
-		floatVal, err := strconv.ParseFloat(value, field.Type().Bits())
+		floatVal, err := parseFloatOption(value, opts, field.Type().Bits())
 		if err != nil {
 			return fmt.Errorf("cannot convert %q to %s: %w", value, field.Type(), err)
 		}
@@ -2219,3 +2219,146 @@ func TestUnmarshal_untaggedFieldNoMatchingGroupSkipped(t *testing.T) {
 		t.Errorf("Missing = %q, want it left unchanged at %q", got.Missing, "untouched")
 	}
 }
+
+// Unmarshal flattens `prefix`-flagged and embedded structs through the shared
+// decode plan. An embedded struct of an unexported type still promotes its
+// exported fields, and — on this lenient path — a stray `prefix` on a
+// non-struct field is ignored rather than rejected.
+func TestUnmarshal_nestedStructs(t *testing.T) {
+	type endpoint struct {
+		Host string
+		Port int
+	}
+	type rec struct {
+		endpoint
+		Peer endpoint `regex:"peer,prefix"`
+		Name string   `regex:"name,prefix"`
+	}
+	re := regexp.MustCompile(`(?P<name>\w+) (?P<host>\w+):(?P<port>\d+) (?P<peer_host>\w+):(?P<peer_port>\d+)`)
+	var got rec
+	if err := rx.Unmarshal(re, "svc a:1 b:2", &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := rec{endpoint: endpoint{Host: "a", Port: 1}, Peer: endpoint{Host: "b", Port: 2}, Name: "svc"}
+	if got != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+}
+
+// Unmarshal and UnmarshalAll share the Decoder's slice handling: every
+// participating occurrence becomes an element, and split= splits a value.
+func TestUnmarshal_sliceFields(t *testing.T) {
+	type rec struct {
+		Words []string `regex:"w"`
+		Flags []bool   `regex:"flags,split=;"`
+	}
+	re := regexp.MustCompile(`(?P<w>\w+)(?: (?P<w>\w+))? \{(?P<flags>[^}]*)\}`)
+	var got []rec
+	if err := rx.UnmarshalAll(re, "a b {true;false} c {}", &got); err != nil {
+		t.Fatalf("UnmarshalAll() error = %v", err)
+	}
+	want := []rec{
+		{Words: []string{"a", "b"}, Flags: []bool{true, false}},
+		{Words: []string{"c"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalAll() = %+v, want %+v", got, want)
+	}
+
+	// A bad element surfaces as a *DecodeError carrying that element.
+	var one rec
+	err := rx.Unmarshal(re, "a {true;maybe}", &one)
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Value != "maybe" {
+		t.Errorf("Unmarshal() error = %v, want a *DecodeError with Value %q", err, "maybe")
+	}
+}
+
+// A slice field inside a repetition collects every iteration on the
+// Unmarshal path too.
+func TestUnmarshal_sliceRepetition(t *testing.T) {
+	type rec struct {
+		Key  string
+		Vals []int `regex:"v"`
+	}
+	re := regexp.MustCompile(`(?P<key>\w+)=(?P<v>\d+)(?:\|(?P<v>\d+))*`)
+	var one rec
+	if err := rx.Unmarshal(re, "x=1|2|3", &one); err != nil || !reflect.DeepEqual(one, rec{"x", []int{1, 2, 3}}) {
+		t.Errorf("Unmarshal() = %+v, %v; want {x [1 2 3]}", one, err)
+	}
+	var all []rec
+	err := rx.UnmarshalAll(re, "x=1|2 y=3", &all)
+	if want := []rec{{"x", []int{1, 2}}, {"y", []int{3}}}; err != nil || !reflect.DeepEqual(all, want) {
+		t.Errorf("UnmarshalAll() = %+v, %v; want %+v", all, err, want)
+	}
+}
+
+func TestUnmarshalWith_aggregateErrors(t *testing.T) {
+	type rec struct {
+		A int
+		B int
+		C string `regex:"c,required"`
+	}
+	re := regexp.MustCompile(`(?P<a>\w+) (?P<b>\w+)(?: (?P<c>\w+))?`)
+	var v rec
+	err := rx.UnmarshalWith(re, "x y", &v, rx.AggregateErrors())
+	var errs *rx.DecodeErrors
+	if !errors.As(err, &errs) || len(errs.Errors) != 3 || !strings.HasPrefix(err.Error(), "regextra.UnmarshalWith: ") {
+		t.Fatalf("UnmarshalWith() error = %v, want a prefixed DecodeErrors with 3 elements", err)
+	}
+	var rge *rx.RequiredGroupError
+	if !errors.As(err, &rge) || rge.Field != "C" {
+		t.Errorf("errors.As(RequiredGroupError) = %v, want field C", rge)
+	}
+	// With no options UnmarshalWith is Unmarshal, under its own prefix.
+	err = rx.UnmarshalWith(re, "x y", &v)
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Field != "A" || errors.As(err, &errs) {
+		t.Errorf("UnmarshalWith() without options error = %v, want a lone DecodeError on A", err)
+	}
+	if err := rx.UnmarshalWith(re, "x", v); err == nil || !strings.HasPrefix(err.Error(), "regextra.UnmarshalWith: ") {
+		t.Errorf("UnmarshalWith(non-pointer) error = %v, want a regextra.UnmarshalWith: error", err)
+	}
+}
+
+func TestUnmarshalAll_errorLocation(t *testing.T) {
+	type rec struct {
+		N int `regex:"n"`
+	}
+	re := regexp.MustCompile(`n=(?P<n>\w+)`)
+	var all []rec
+	err := rx.UnmarshalAll(re, "n=1, n=2,\n  n=bad", &all)
+	var de *rx.DecodeError
+	if !errors.As(err, &de) || de.Match != 2 || de.Offset != 14 || de.End != 17 || de.Line != 2 || de.Column != 5 {
+		t.Errorf("UnmarshalAll() error = %v; want match 2 at [14:17], 2:5", err)
+	}
+	var one rec
+	err = rx.Unmarshal(re, "x n=bad", &one)
+	if !errors.As(err, &de) || de.Match != 0 || de.Offset != 4 || de.Column != 5 {
+		t.Errorf("Unmarshal() error = %v; want match 0 at offset 4, column 5", err)
+	}
+}
+
+func TestUnmarshalWith_options(t *testing.T) {
+	type rec struct {
+		Word string    `text:"w"`
+		At   time.Time `text:"at"`
+	}
+	loc := time.FixedZone("X", 3600)
+	re := regexp.MustCompile(`(?P<w>a|ab) (?P<at>[\d-]+)`)
+	var v rec
+	if err := rx.UnmarshalWith(re, "ab 2024-01-02", &v, rx.TagKey("text"), rx.LongestMatch(), rx.FullMatch(), rx.DefaultLocation(loc)); err != nil {
+		t.Fatalf("UnmarshalWith() error = %v", err)
+	}
+	if v.Word != "ab" || !v.At.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, loc)) {
+		t.Errorf("UnmarshalWith() = %+v, want ab at midnight in X", v)
+	}
+	// FullMatch finds nothing in a longer target: no match, no error.
+	v = rec{}
+	if err := rx.UnmarshalWith(re, "> ab 2024-01-02", &v, rx.TagKey("text"), rx.FullMatch()); err != nil || v != (rec{}) {
+		t.Errorf("UnmarshalWith(FullMatch, partial) = %+v, %v; want untouched, nil", v, err)
+	}
+	if re.FindString("ab 2024-01-02") != "ab 2024-01-02" || re.String() != `(?P<w>a|ab) (?P<at>[\d-]+)` {
+		t.Error("UnmarshalWith modified the caller's regexp")
+	}
+}